@@ -69,6 +69,20 @@ type DeploymentDetails struct {
 		Logs to keep track of for each node
 	*/
 	Logs []map[string]string `json:"logs"`
+	/*
+		SideCars are the sidecars to attach to the nodes, in addition to those of the blockchain
+	*/
+	SideCars []SideCarSpec `json:"sidecars"`
+	/*
+		Labels are the labels to give to each node, such as "validators" or "region=eu", which
+		can be used to select nodes in place of their numbers
+	*/
+	Labels [][]string `json:"labels"`
+	/*
+		Topology is the peer graph to give the nodes, for the blockchains which are given their peers.
+		Every node is given every other node as a peer if it is not set.
+	*/
+	Topology *util.Topology `json:"topology,omitempty"`
 
 	/*
 		Fairly Arbitrary extras for when additional customizations are added.
@@ -78,6 +92,14 @@ type DeploymentDetails struct {
 	kid    string
 }
 
+// GetLabels gets the labels given to the node at the given index of this deployment
+func (dd DeploymentDetails) GetLabels(index int) []string {
+	if index < 0 || index >= len(dd.Labels) {
+		return nil
+	}
+	return dd.Labels[index]
+}
+
 //SetJwt stores the callers jwt
 func (dd *DeploymentDetails) SetJwt(jwt string) error {
 	dd.jwt = jwt
@@ -116,8 +138,11 @@ func QueryBuilds(query string) ([]DeploymentDetails, error) {
 		var extras []byte
 		var images []byte
 		var files []byte
+		var sidecars []byte
+		var labels []byte
+		var topology []byte
 
-		err = rows.Scan(&build.ID, &servers, &build.Blockchain, &build.Nodes, &images, &params, &resources, &files, &environment, &logs, &extras, &build.kid)
+		err = rows.Scan(&build.ID, &servers, &build.Blockchain, &build.Nodes, &images, &params, &resources, &files, &environment, &logs, &extras, &sidecars, &labels, &topology, &build.kid)
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -161,6 +186,21 @@ func QueryBuilds(query string) ([]DeploymentDetails, error) {
 		if err != nil {
 			return nil, util.LogError(err)
 		}
+
+		err = json.Unmarshal(sidecars, &build.SideCars)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+
+		err = json.Unmarshal(labels, &build.Labels)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+
+		err = json.Unmarshal(topology, &build.Topology)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
 		builds = append(builds, build)
 	}
 	return builds, nil
@@ -170,7 +210,7 @@ func QueryBuilds(query string) ([]DeploymentDetails, error) {
 GetAllBuilds gets all of the builds done by a user
 */
 func GetAllBuilds() ([]DeploymentDetails, error) {
-	return QueryBuilds(fmt.Sprintf("SELECT testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,kid FROM %s", BuildsTable))
+	return QueryBuilds(fmt.Sprintf("SELECT testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,sidecars,labels,topology,kid FROM %s", BuildsTable))
 }
 
 /*
@@ -178,7 +218,7 @@ GetBuildByTestnet gets the build parameters based off testnet id
 */
 func GetBuildByTestnet(id string) (DeploymentDetails, error) {
 
-	details, err := QueryBuilds(fmt.Sprintf("SELECT testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,kid FROM %s WHERE testnet = \"%s\"", BuildsTable, id))
+	details, err := QueryBuilds(fmt.Sprintf("SELECT testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,sidecars,labels,topology,kid FROM %s WHERE testnet = \"%s\"", BuildsTable, id))
 	if err != nil {
 		return DeploymentDetails{}, util.LogError(err)
 	}
@@ -192,7 +232,7 @@ func GetBuildByTestnet(id string) (DeploymentDetails, error) {
 func GetLastBuildByKid(kid string) (DeploymentDetails, error) {
 
 	details, err := QueryBuilds(fmt.Sprintf(
-		"SELECT testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,kid FROM %s"+
+		"SELECT testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,sidecars,labels,topology,kid FROM %s"+
 			" WHERE kid = \"%s\" ORDER BY id DESC LIMIT 1", BuildsTable, kid))
 	if err != nil {
 		return DeploymentDetails{}, util.LogError(err)
@@ -212,8 +252,8 @@ func InsertBuild(dd DeploymentDetails, testnetID string) error {
 		return util.LogError(err)
 	}
 
-	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,kid)"+
-		" VALUES (?,?,?,?,?,?,?,?,?,?,?,?)", BuildsTable))
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,sidecars,labels,topology,kid)"+
+		" VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)", BuildsTable))
 
 	if err != nil {
 		return util.LogError(err)
@@ -228,13 +268,17 @@ func InsertBuild(dd DeploymentDetails, testnetID string) error {
 	extras, _ := json.Marshal(dd.Extras)
 	images, _ := json.Marshal(dd.Images)
 	files, _ := json.Marshal(dd.Files)
+	sidecars, _ := json.Marshal(dd.SideCars)
+	labels, _ := json.Marshal(dd.Labels)
+	topology, _ := json.Marshal(dd.Topology)
 	environment, err := json.Marshal(dd.Environments)
 	if err != nil {
 		return util.LogError(err)
 	}
 
 	_, err = stmt.Exec(testnetID, string(servers), dd.Blockchain, dd.Nodes, string(images),
-		string(params), string(resources), string(files), string(environment), string(logs), string(extras), dd.kid)
+		string(params), string(resources), string(files), string(environment), string(logs), string(extras), string(sidecars),
+		string(labels), string(topology), dd.kid)
 
 	if err != nil {
 		return util.LogError(err)
@@ -37,6 +37,18 @@ const NodesTable = "nodes"
 //BuildsTable contains name of the builds table
 const BuildsTable = "builds"
 
+//ResourceUsageTable contains name of the resource usage table
+const ResourceUsageTable = "resource_usage"
+
+//ExecAuditTable contains name of the exec audit log table
+const ExecAuditTable = "exec_audit"
+
+//FaultTable contains name of the fault history table
+const FaultTable = "faults"
+
+//OutageTable contains name of the outage intent table
+const OutageTable = "outages"
+
 var conf = util.GetConfig()
 
 var db *sql.DB
@@ -95,7 +107,7 @@ func dbInit(dataLoc string) error {
 		"ip TEXT NOT NULL",
 		"label TEXT")
 
-	buildSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s,%s,%s, %s,%s,%s, %s);",
+	buildSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s,%s,%s, %s,%s,%s, %s,%s,%s, %s);",
 		BuildsTable,
 		"id INTEGER PRIMARY KEY AUTOINCREMENT",
 		"testnet TEXT",
@@ -109,8 +121,51 @@ func dbInit(dataLoc string) error {
 		"files TEXT",
 		"logs TEXT",
 		"extras TEXT",
+		"sidecars TEXT",
+		"labels TEXT",
+		"topology TEXT",
 		"kid TEXT")
 
+	resourceUsageSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s,%s,%s, %s);",
+		ResourceUsageTable,
+		"testnet TEXT",
+		"node INTEGER",
+		"time INTEGER",
+		"cpu REAL",
+		"mem_usage INTEGER",
+		"mem_limit INTEGER",
+		"net_rx INTEGER",
+		"net_tx INTEGER",
+		"block_read INTEGER",
+		"block_write INTEGER")
+
+	execAuditSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s);",
+		ExecAuditTable,
+		"id INTEGER PRIMARY KEY AUTOINCREMENT",
+		"testnet TEXT",
+		"node INTEGER",
+		"kid TEXT",
+		"command TEXT",
+		"time INTEGER",
+		"exit_code INTEGER")
+
+	faultSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s);",
+		FaultTable,
+		"id INTEGER PRIMARY KEY AUTOINCREMENT",
+		"testnet TEXT",
+		"node INTEGER",
+		"type TEXT",
+		"details TEXT",
+		"start INTEGER",
+		"end INTEGER")
+
+	outageSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s);",
+		OutageTable,
+		"testnet TEXT",
+		"from_node INTEGER",
+		"to_node INTEGER",
+		"UNIQUE(testnet, from_node, to_node)")
+
 	versionSchema := fmt.Sprintf("CREATE TABLE meta (%s,%s);",
 		"key TEXT",
 		"value TEXT",
@@ -129,6 +184,22 @@ func dbInit(dataLoc string) error {
 	if err != nil {
 		return util.LogError(err)
 	}
+	_, err = db.Exec(resourceUsageSchema)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = db.Exec(execAuditSchema)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = db.Exec(faultSchema)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = db.Exec(outageSchema)
+	if err != nil {
+		return util.LogError(err)
+	}
 	_, err = db.Exec(versionSchema)
 	if err != nil {
 		return util.LogError(err)
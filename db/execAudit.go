@@ -0,0 +1,74 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"fmt"
+	_ "github.com/mattn/go-sqlite3" //Include sqlite as the db
+	"github.com/whiteblock/genesis/util"
+)
+
+// ExecAuditEntry is a record of a command which was executed on a node on behalf of a user
+type ExecAuditEntry struct {
+	// TestNetID is the id of the testnet to which the node belongs to
+	TestNetID string `json:"testnetId"`
+
+	// Node is the absolute number of the node the command was executed on
+	Node int `json:"node"`
+
+	// Kid is the kid of the caller, empty if the caller was not authenticated
+	Kid string `json:"kid"`
+
+	// Command is the command which was executed
+	Command string `json:"command"`
+
+	// Time is the unix timestamp in milliseconds when the command was executed
+	Time int64 `json:"time"`
+
+	// ExitCode is the exit code of the command, or -1 if it could not be run
+	ExitCode int `json:"exitCode"`
+}
+
+// InsertExecAuditEntry stores the given entry in the audit log
+func InsertExecAuditEntry(entry ExecAuditEntry) error {
+	_, err := db.Exec(fmt.Sprintf("INSERT INTO %s (testnet,node,kid,command,time,exit_code) VALUES (?,?,?,?,?,?)",
+		ExecAuditTable), entry.TestNetID, entry.Node, entry.Kid, entry.Command, entry.Time, entry.ExitCode)
+	return util.LogError(err)
+}
+
+// GetExecAuditLog fetches the audit log of the commands executed on the nodes of a testnet, ordered by time
+func GetExecAuditLog(testnetID string) ([]ExecAuditEntry, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT testnet,node,kid,command,time,exit_code FROM %s WHERE testnet = ? ORDER BY time",
+		ExecAuditTable), testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	defer rows.Close()
+
+	out := []ExecAuditEntry{}
+	for rows.Next() {
+		var entry ExecAuditEntry
+		err = rows.Scan(&entry.TestNetID, &entry.Node, &entry.Kid, &entry.Command, &entry.Time, &entry.ExitCode)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
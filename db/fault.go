@@ -0,0 +1,119 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	_ "github.com/mattn/go-sqlite3" //Include sqlite as the db
+	"github.com/whiteblock/genesis/util"
+)
+
+// Fault is a record of a fault which was injected into a node
+type Fault struct {
+	// ID is the unique id of the fault
+	ID int64 `json:"id"`
+
+	// TestNetID is the id of the testnet to which the node belongs to
+	TestNetID string `json:"testnetId"`
+
+	// Node is the absolute number of the node the fault was injected into
+	Node int `json:"node"`
+
+	// Type is the kind of fault, such as limits or stress
+	Type string `json:"type"`
+
+	// Details is the json representation of the fault
+	Details json.RawMessage `json:"details"`
+
+	// Start is the unix timestamp in milliseconds when the fault was injected
+	Start int64 `json:"start"`
+
+	// End is the unix timestamp in milliseconds when the fault was removed, or 0 if it has not been
+	End int64 `json:"end"`
+}
+
+// Active checks if the fault is in effect at the given unix timestamp in milliseconds
+func (f Fault) Active(now int64) bool {
+	return f.Start <= now && (f.End == 0 || f.End > now)
+}
+
+// InsertFault stores the given fault in the fault history, returning its id
+func InsertFault(fault Fault) (int64, error) {
+	res, err := db.Exec(fmt.Sprintf("INSERT INTO %s (testnet,node,type,details,start,end) VALUES (?,?,?,?,?,?)",
+		FaultTable), fault.TestNetID, fault.Node, fault.Type, string(fault.Details), fault.Start, fault.End)
+	if err != nil {
+		return -1, util.LogError(err)
+	}
+	return res.LastInsertId()
+}
+
+// EndFaults marks the faults of the given type on a node which are still in effect as having ended at the given time
+func EndFaults(testnetID string, node int, faultType string, end int64) error {
+	_, err := db.Exec(fmt.Sprintf("UPDATE %s SET end = ? WHERE testnet = ? AND node = ? AND type = ? AND (end = 0 OR end > ?)",
+		FaultTable), end, testnetID, node, faultType, end)
+	return util.LogError(err)
+}
+
+// GetUnendedFaults fetches the faults of the given type across every testnet which have no end time, and
+// so remain in effect until they are removed
+func GetUnendedFaults(faultType string) ([]Fault, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT id,testnet,node,type,details,start,end FROM %s WHERE type = ? AND end = 0 ORDER BY start, id",
+		FaultTable), faultType)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	defer rows.Close()
+	return scanFaults(rows)
+}
+
+// GetFaults fetches the fault history of a testnet, ordered by the time each fault was injected.
+// If node is negative, the faults for all of the nodes are returned.
+func GetFaults(testnetID string, node int) ([]Fault, error) {
+	query := fmt.Sprintf("SELECT id,testnet,node,type,details,start,end FROM %s WHERE testnet = ?", FaultTable)
+	args := []interface{}{testnetID}
+	if node >= 0 {
+		query += " AND node = ?"
+		args = append(args, node)
+	}
+	query += " ORDER BY start, id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	defer rows.Close()
+	return scanFaults(rows)
+}
+
+func scanFaults(rows *sql.Rows) ([]Fault, error) {
+	out := []Fault{}
+	for rows.Next() {
+		var fault Fault
+		var details []byte
+		err := rows.Scan(&fault.ID, &fault.TestNetID, &fault.Node, &fault.Type, &details, &fault.Start, &fault.End)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		fault.Details = json.RawMessage(details)
+		out = append(out, fault)
+	}
+	return out, nil
+}
@@ -23,6 +23,9 @@ import (
 	"fmt"
 	_ "github.com/mattn/go-sqlite3" //Include sqlite as the db
 	"github.com/whiteblock/genesis/util"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // Node represents a node within the network
@@ -45,8 +48,9 @@ type Node struct {
 	// IP is the ip address of the node
 	IP string `json:"ip"`
 
-	// Label is the string given to the node by the build process
-	Label string `json:"label"`
+	// Labels are the labels given to the node by the build process, which are either a name or
+	// a key value pair such as "region=eu"
+	Labels []string `json:"labels"`
 
 	// Image is the docker image used to build this node
 	Image string `json:"image"`
@@ -90,6 +94,22 @@ func (n Node) GetNodeName() string {
 	return fmt.Sprintf("%s%d", conf.NodePrefix, n.AbsoluteNum)
 }
 
+// Matches checks whether the labels of this node satisfy the given selector
+func (n Node) Matches(selector util.Selector) bool {
+	return selector.Matches(n.Labels)
+}
+
+// scanNode reads a node from a row which has selected id,test_net,server,local_id,ip,label,abs_num
+func scanNode(row interface{ Scan(...interface{}) error }) (Node, error) {
+	var node Node
+	var labels string
+	err := row.Scan(&node.ID, &node.TestNetID, &node.Server, &node.LocalID, &node.IP, &labels, &node.AbsoluteNum)
+	if len(labels) > 0 {
+		node.Labels = strings.Split(labels, ",")
+	}
+	return node, err
+}
+
 // GetAllNodesByServer gets all nodes that have ever existed on a server
 func GetAllNodesByServer(serverID int) ([]Node, error) {
 
@@ -101,8 +121,7 @@ func GetAllNodesByServer(serverID int) ([]Node, error) {
 
 	nodes := []Node{}
 	for rows.Next() {
-		var node Node
-		err := rows.Scan(&node.ID, &node.TestNetID, &node.Server, &node.LocalID, &node.ID, &node.Label, &node.AbsoluteNum)
+		node, err := scanNode(rows)
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -122,8 +141,7 @@ func GetAllNodesByTestNet(testID string) ([]Node, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var node Node
-		err := rows.Scan(&node.ID, &node.TestNetID, &node.Server, &node.LocalID, &node.IP, &node.Label, &node.AbsoluteNum)
+		node, err := scanNode(rows)
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -143,8 +161,7 @@ func GetAllNodes() ([]Node, error) {
 	nodes := []Node{}
 
 	for rows.Next() {
-		var node Node
-		err := rows.Scan(&node.ID, &node.TestNetID, &node.Server, &node.LocalID, &node.IP, &node.Label, &node.AbsoluteNum)
+		node, err := scanNode(rows)
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -158,9 +175,8 @@ func GetNode(id string) (Node, error) {
 
 	row := db.QueryRow(fmt.Sprintf("SELECT id,test_net,server,local_id,ip,label,abs_num FROM %s WHERE id = %s", NodesTable, id))
 
-	var node Node
-
-	if row.Scan(&node.ID, &node.TestNetID, &node.Server, &node.LocalID, &node.IP, &node.Label, &node.AbsoluteNum) == sql.ErrNoRows {
+	node, err := scanNode(row)
+	if err == sql.ErrNoRows {
 		return node, fmt.Errorf("node %s not found", id)
 
 	}
@@ -184,7 +200,7 @@ func InsertNode(node Node) (int, error) {
 
 	defer stmt.Close()
 
-	res, err := stmt.Exec(node.ID, node.TestNetID, node.Server, node.LocalID, node.IP, node.Label, node.AbsoluteNum)
+	res, err := stmt.Exec(node.ID, node.TestNetID, node.Server, node.LocalID, node.IP, strings.Join(node.Labels, ","), node.AbsoluteNum)
 	if err != nil {
 		return -1, nil
 	}
@@ -194,8 +210,25 @@ func InsertNode(node Node) (int, error) {
 	return int(id), util.LogError(err)
 }
 
+// DeleteNode deletes a node by id
+func DeleteNode(id string) error {
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", NodesTable), id)
+	return util.LogError(err)
+}
+
 /**Helper functions which do not query the database**/
 
+// SelectNodes gives the nodes which match the given selector
+func SelectNodes(nodes []Node, selector util.Selector) []Node {
+	out := []Node{}
+	for _, node := range nodes {
+		if node.Matches(selector) {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
 // GetNodeByLocalID looks up a node by its localID
 func GetNodeByLocalID(nodes []Node, localID int) (Node, error) {
 	for _, node := range nodes {
@@ -217,6 +250,29 @@ func GetNodeByAbsNum(nodes []Node, absNum int) (Node, error) {
 	return Node{}, fmt.Errorf("node %d not found", absNum)
 }
 
+// ResolveNodes finds the nodes which the given target refers to. The target is either the absolute number
+// of a node, or a label selector such as "validators" or "region=eu,!observers", which must match at least one node.
+func ResolveNodes(nodes []Node, target string) ([]Node, error) {
+	absNum, err := strconv.Atoi(target)
+	if err == nil {
+		node, err := GetNodeByAbsNum(nodes, absNum)
+		if err != nil {
+			return nil, err
+		}
+		return []Node{node}, nil
+	}
+	selector, err := util.ParseSelector(target)
+	if err != nil {
+		return nil, err
+	}
+	out := SelectNodes(nodes, selector)
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no nodes match the selector \"%s\"", target)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AbsoluteNum < out[j].AbsoluteNum })
+	return out, nil
+}
+
 // DivideNodesByAbsMatch spits the given nodes into nodes which have their absnum in the
 // given nodeNums and those who don't
 func DivideNodesByAbsMatch(nodes []Node, nodeNums []int) ([]Node, []Node, error) {
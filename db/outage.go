@@ -0,0 +1,85 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"fmt"
+	_ "github.com/mattn/go-sqlite3" //Include sqlite as the db
+	"github.com/whiteblock/genesis/util"
+)
+
+// Outage is a stored intent for traffic from one node to another node to be dropped
+type Outage struct {
+	// TestNetID is the id of the testnet to which the nodes belong to
+	TestNetID string `json:"testnetId"`
+
+	// From is the absolute number of the node whose traffic is dropped
+	From int `json:"from"`
+
+	// To is the absolute number of the node which the dropped traffic is destined for
+	To int `json:"to"`
+}
+
+// InsertOutage stores the intent for the given outage, doing nothing if it is already stored
+func InsertOutage(outage Outage) error {
+	_, err := db.Exec(fmt.Sprintf("INSERT OR IGNORE INTO %s (testnet,from_node,to_node) VALUES (?,?,?)",
+		OutageTable), outage.TestNetID, outage.From, outage.To)
+	return util.LogError(err)
+}
+
+// DeleteOutage removes the stored intent for the given outage
+func DeleteOutage(outage Outage) error {
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE testnet = ? AND from_node = ? AND to_node = ?",
+		OutageTable), outage.TestNetID, outage.From, outage.To)
+	return util.LogError(err)
+}
+
+// DeleteOutages removes all of the stored outages for a testnet
+func DeleteOutages(testnetID string) error {
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE testnet = ?", OutageTable), testnetID)
+	return util.LogError(err)
+}
+
+// DeleteNodeOutages removes all of the stored outages involving the given node of a testnet
+func DeleteNodeOutages(testnetID string, node int) error {
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE testnet = ? AND (from_node = ? OR to_node = ?)",
+		OutageTable), testnetID, node, node)
+	return util.LogError(err)
+}
+
+// GetOutages fetches all of the stored outages for a testnet, ordered by the nodes involved
+func GetOutages(testnetID string) ([]Outage, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT testnet,from_node,to_node FROM %s WHERE testnet = ? ORDER BY from_node, to_node",
+		OutageTable), testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	defer rows.Close()
+
+	out := []Outage{}
+	for rows.Next() {
+		var outage Outage
+		err = rows.Scan(&outage.TestNetID, &outage.From, &outage.To)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, outage)
+	}
+	return out, nil
+}
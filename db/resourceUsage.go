@@ -0,0 +1,126 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"fmt"
+	_ "github.com/mattn/go-sqlite3" //Include sqlite as the db
+	"github.com/whiteblock/genesis/util"
+)
+
+// ResourceSample represents the resources used by a single node at a single point in time
+type ResourceSample struct {
+	// TestNetID is the id of the testnet to which the node belongs to
+	TestNetID string `json:"testnetId"`
+
+	// Node is the absolute number of the node in the testnet
+	Node int `json:"node"`
+
+	// Time is the unix timestamp in milliseconds when the sample was taken
+	Time int64 `json:"time"`
+
+	// CPU is the cpu usage as a percentage of a single core
+	CPU float64 `json:"cpu"`
+
+	// MemoryUsage is the memory in use by the node in bytes
+	MemoryUsage int64 `json:"memoryUsage"`
+
+	// MemoryLimit is the maximum memory the node may use in bytes
+	MemoryLimit int64 `json:"memoryLimit"`
+
+	// NetRx is the total number of bytes received by the node
+	NetRx int64 `json:"netRx"`
+
+	// NetTx is the total number of bytes sent by the node
+	NetTx int64 `json:"netTx"`
+
+	// BlockRead is the total number of bytes read from block devices by the node
+	BlockRead int64 `json:"blockRead"`
+
+	// BlockWrite is the total number of bytes written to block devices by the node
+	BlockWrite int64 `json:"blockWrite"`
+}
+
+// InsertResourceSamples stores the given samples in the database
+func InsertResourceSamples(samples []ResourceSample) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (testnet,node,time,cpu,mem_usage,mem_limit,net_rx,net_tx,block_read,block_write)"+
+		" VALUES (?,?,?,?,?,?,?,?,?,?)", ResourceUsageTable))
+	if err != nil {
+		tx.Rollback()
+		return util.LogError(err)
+	}
+	defer stmt.Close()
+
+	for _, sample := range samples {
+		_, err = stmt.Exec(sample.TestNetID, sample.Node, sample.Time, sample.CPU, sample.MemoryUsage,
+			sample.MemoryLimit, sample.NetRx, sample.NetTx, sample.BlockRead, sample.BlockWrite)
+		if err != nil {
+			tx.Rollback()
+			return util.LogError(err)
+		}
+	}
+	return util.LogError(tx.Commit())
+}
+
+// GetResourceSamples fetches the resource usage samples of a testnet taken within [from,to], ordered by time.
+// If node is negative, the samples for all of the nodes are returned. If to is not positive, there is no
+// upper bound on the time.
+func GetResourceSamples(testnetID string, node int, from int64, to int64) ([]ResourceSample, error) {
+	query := fmt.Sprintf("SELECT testnet,node,time,cpu,mem_usage,mem_limit,net_rx,net_tx,block_read,block_write"+
+		" FROM %s WHERE testnet = ? AND time >= ?", ResourceUsageTable)
+	args := []interface{}{testnetID, from}
+	if to > 0 {
+		query += " AND time <= ?"
+		args = append(args, to)
+	}
+	if node >= 0 {
+		query += " AND node = ?"
+		args = append(args, node)
+	}
+	query += " ORDER BY time, node"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	defer rows.Close()
+
+	out := []ResourceSample{}
+	for rows.Next() {
+		var sample ResourceSample
+		err = rows.Scan(&sample.TestNetID, &sample.Node, &sample.Time, &sample.CPU, &sample.MemoryUsage,
+			&sample.MemoryLimit, &sample.NetRx, &sample.NetTx, &sample.BlockRead, &sample.BlockWrite)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, sample)
+	}
+	return out, nil
+}
+
+// DeleteResourceSamples removes all of the resource usage samples for a testnet
+func DeleteResourceSamples(testnetID string) error {
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE testnet = ?", ResourceUsageTable), testnetID)
+	return util.LogError(err)
+}
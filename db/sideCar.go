@@ -20,6 +20,7 @@ package db
 
 import (
 	"fmt"
+	"github.com/whiteblock/genesis/util"
 )
 
 // SideCar represents a supporting node within the network
@@ -85,3 +86,36 @@ func (n SideCar) GetTestNetID() string {
 func (n SideCar) GetNodeName() string {
 	return fmt.Sprintf("%s%d-%d", conf.NodePrefix, n.AbsoluteNodeNum, n.NetworkIndex)
 }
+
+// SideCarSpec is a request to attach a sidecar to the nodes of a deployment
+type SideCarSpec struct {
+	// Name is the name of a registered sidecar, or a name for an ad-hoc sidecar
+	Name string `json:"name"`
+	// Image is the image of an ad-hoc sidecar, or replaces the image of a registered sidecar
+	Image string `json:"image,omitempty"`
+	// Command is run in each ad-hoc sidecar once it has been started
+	Command string `json:"command,omitempty"`
+	// Nodes are the absolute numbers of the nodes to attach the sidecar to, if empty it is attached to all of them
+	Nodes []int `json:"nodes,omitempty"`
+	// SharedNamespace runs the sidecar in the network namespace of its node, instead of giving it its own ip
+	SharedNamespace bool `json:"sharedNamespace,omitempty"`
+	// Resources are the resources given to each instance of the sidecar
+	Resources util.Resources `json:"resources"`
+	// Environment is the environment variables of the sidecar
+	Environment map[string]string `json:"environment,omitempty"`
+	// Files maps the paths of files to place in the sidecar to their base64 encoded contents
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// AttachesTo checks whether the sidecar is attached to the node with the given absolute number
+func (scs SideCarSpec) AttachesTo(absNum int) bool {
+	if len(scs.Nodes) == 0 {
+		return true
+	}
+	for _, num := range scs.Nodes {
+		if num == absNum {
+			return true
+		}
+	}
+	return false
+}
@@ -62,14 +62,15 @@ func AddNodes(tn *testnet.TestNet) error {
 			return util.LogError(err)
 		}
 
-		nodeIP, err := util.GetNodeIP(tn.Servers[serverIndex].SubnetID, len(tn.Nodes), 0)
+		localID := tn.NextLocalID(serverID)
+		nodeIP, err := util.GetNodeIP(tn.Servers[serverIndex].SubnetID, localID, 0)
 		if err != nil {
 			return util.LogError(err)
 		}
 
 		node := tn.AddNode(db.Node{
 			ID: nodeID, TestNetID: tn.TestNetID, Server: serverID,
-			LocalID: tn.Servers[serverIndex].Nodes, IP: nodeIP})
+			LocalID: localID, IP: nodeIP, Labels: tn.LDD.GetLabels(i)})
 
 		tn.Servers[serverIndex].Nodes++
 
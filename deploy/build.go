@@ -35,14 +35,13 @@ import (
 var conf = util.GetConfig()
 
 func buildSideCars(tn *testnet.TestNet, server *db.Server, node *db.Node) {
-	sidecars, err := registrar.GetBlockchainSideCars(tn.LDD.Blockchain)
-	if err != nil {
-		//do not report
-		return
-	}
+	sidecars := registrar.GetDeploymentSideCars(tn.LDD)
 
 	for i, sidecar := range sidecars {
-		sideCarDetails, err := registrar.GetSideCar(sidecar)
+		if !sidecar.AttachesTo(node.AbsoluteNum) {
+			continue
+		}
+		image, err := registrar.GetSideCarImage(sidecar)
 		if err != nil {
 			tn.BuildState.ReportError(err)
 			return
@@ -53,6 +52,9 @@ func buildSideCars(tn *testnet.TestNet, server *db.Server, node *db.Node) {
 			tn.BuildState.ReportError(err)
 			return
 		}
+		if sidecar.SharedNamespace {
+			sidecarIP = node.IP
+		}
 
 		scNode := db.SideCar{
 			NodeID:          node.ID,
@@ -62,11 +64,15 @@ func buildSideCars(tn *testnet.TestNet, server *db.Server, node *db.Node) {
 			LocalID:         node.LocalID,
 			NetworkIndex:    i + 1,
 			IP:              sidecarIP,
-			Image:           sideCarDetails.Image,
-			Type:            sidecar,
+			Image:           image,
+			Type:            sidecar.Name,
 		}
 		tn.AddSideCar(scNode, i)
-		err = docker.Run(tn, server.ID, docker.NewSideCarContainer(&scNode, nil, util.Resources{}, server.SubnetID))
+		container := docker.NewSideCarContainer(&scNode, sidecar.Environment, sidecar.Resources, server.SubnetID)
+		if sidecar.SharedNamespace {
+			container = docker.NewSharedSideCarContainer(&scNode, sidecar.Environment, sidecar.Resources, server.SubnetID)
+		}
+		err = docker.Run(tn, server.ID, container)
 		if err != nil {
 			tn.BuildState.ReportError(err)
 			return
@@ -172,7 +178,7 @@ func Build(tn *testnet.TestNet, services []helpers.Service) error {
 
 		node := tn.AddNode(db.Node{
 			ID: nodeID, TestNetID: tn.TestNetID, Server: serverID,
-			LocalID: tn.Servers[serverIndex].Nodes, IP: nodeIP})
+			LocalID: tn.Servers[serverIndex].Nodes, IP: nodeIP, Labels: tn.LDD.GetLabels(i)})
 
 		tn.Servers[serverIndex].Ips = append(tn.Servers[serverIndex].Ips, nodeIP) //TODO: REMOVE
 		tn.Servers[serverIndex].Nodes++
@@ -21,8 +21,8 @@ package deploy
 import (
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/docker"
+	netem "github.com/whiteblock/genesis/net"
 	"github.com/whiteblock/genesis/protocols/helpers"
-	//netem "github.com/whiteblock/genesis/net"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
 )
@@ -46,6 +46,8 @@ func PurgeTestNetwork(tn *testnet.TestNet) error {
 		}
 		//Redundant because the network is already destroy, so the tc rules are implicitly destroyed.
 		//netem.RemoveAllOnServer(client, server.Nodes)
+		//The ifb devices which shape the uplinks are not part of the network, so they must be removed
+		netem.RemoveShapingDevices(client)
 
 		return nil
 	})
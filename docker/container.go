@@ -61,6 +61,9 @@ type Container interface {
 
 	// GetResources gets the maximum resource allocation of the node
 	GetResources() util.Resources
+
+	// GetType gets the type of the container
+	GetType() ContainerType
 }
 
 // ContainerDetails represents a docker containers details
@@ -72,6 +75,8 @@ type ContainerDetails struct {
 	SubnetID     int
 	NetworkIndex int
 	Type         ContainerType
+	// SharedNamespace places a side car into the network namespace of its node
+	SharedNamespace bool
 }
 
 // NewNodeContainer creates a representation of a container for a regular node
@@ -100,6 +105,14 @@ func NewSideCarContainer(sc *db.SideCar, env map[string]string, resources util.R
 	}
 }
 
+// NewSharedSideCarContainer creates a representation of a container for a side car node which
+// shares the network namespace of its node
+func NewSharedSideCarContainer(sc *db.SideCar, env map[string]string, resources util.Resources, SubnetID int) Container {
+	out := NewSideCarContainer(sc, env, resources, SubnetID).(*ContainerDetails)
+	out.SharedNamespace = true
+	return out
+}
+
 // GetEnvironment gives the environment variables for the container
 func (cd *ContainerDetails) GetEnvironment() map[string]string {
 	return cd.Environment
@@ -110,8 +123,12 @@ func (cd *ContainerDetails) GetImage() string {
 	return cd.Image
 }
 
-// GetIP gives the IP address for the container
+// GetIP gives the IP address for the container, which is empty if the container does not
+// have its own network namespace
 func (cd *ContainerDetails) GetIP() (string, error) {
+	if cd.SharedNamespace {
+		return "", nil
+	}
 	switch cd.Type {
 	case Node:
 		return util.GetNodeIP(cd.SubnetID, cd.Node, 0)
@@ -141,6 +158,9 @@ func (cd *ContainerDetails) GetPorts() []string {
 
 // GetNetworkName gets the name of the containers network
 func (cd *ContainerDetails) GetNetworkName() string {
+	if cd.SharedNamespace {
+		return fmt.Sprintf("container:%s%d", conf.NodePrefix, cd.Node)
+	}
 	return fmt.Sprintf("%s%d", conf.NodeNetworkPrefix, cd.Node)
 }
 
@@ -148,3 +168,8 @@ func (cd *ContainerDetails) GetNetworkName() string {
 func (cd *ContainerDetails) GetResources() util.Resources {
 	return cd.Resources
 }
+
+// GetType gets the type of the container
+func (cd *ContainerDetails) GetType() ContainerType {
+	return cd.Type
+}
@@ -30,6 +30,14 @@ import (
 	"strings"
 )
 
+const (
+	// FaketimeLibPath is where libfaketime is mounted within a node
+	FaketimeLibPath = "/usr/local/lib/faketime/libfaketime.so.1"
+
+	// FaketimeFile is the file within a node which holds its current clock skew
+	FaketimeFile = "/etc/faketimerc"
+)
+
 var conf *util.Config
 
 func init() {
@@ -42,6 +50,12 @@ func Kill(client ssh.Client, node int) error {
 	return err
 }
 
+// KillSideCar kills a single side car of a node by index on a server
+func KillSideCar(client ssh.Client, node int, networkIndex int) error {
+	_, err := client.Run(fmt.Sprintf("docker rm -f %s%d-%d", conf.NodePrefix, node, networkIndex))
+	return err
+}
+
 // KillAll kills all nodes on a server
 func KillAll(client ssh.Client) error {
 	_, err := client.Run(fmt.Sprintf("docker rm -f $(docker ps -aq -f name=\"%s\")", conf.NodePrefix))
@@ -141,17 +155,33 @@ func dockerRunCmd(c Container) (string, error) {
 	for key, value := range c.GetEnvironment() {
 		command += fmt.Sprintf(" -e \"%s=%s\"", key, value)
 	}
+	if c.GetType() == Node && len(conf.FaketimeLib) > 0 {
+		command += faketimeFlags()
+	}
 	ip, err := c.GetIP()
 	if err != nil {
 		return "", util.LogError(err)
 	}
-	command += fmt.Sprintf(" --ip %s", ip)
-	command += fmt.Sprintf(" --hostname %s", c.GetName())
+	if len(ip) > 0 { //containers which share a network namespace cannot set these
+		command += fmt.Sprintf(" --ip %s", ip)
+		command += fmt.Sprintf(" --hostname %s", c.GetName())
+	}
 	command += fmt.Sprintf(" --name %s", c.GetName())
 	command += " " + c.GetImage()
 	return command, nil
 }
 
+// faketimeFlags preloads libfaketime into a node, so that its clock can be skewed at runtime
+// by rewriting FaketimeFile within the container
+func faketimeFlags() string {
+	out := fmt.Sprintf(" -v %s:%s:ro", conf.FaketimeLib, FaketimeLibPath)
+	out += fmt.Sprintf(" -e \"LD_PRELOAD=%s\"", FaketimeLibPath)
+	out += fmt.Sprintf(" -e \"FAKETIME_TIMESTAMP_FILE=%s\"", FaketimeFile)
+	out += " -e \"FAKETIME_CACHE_DURATION=1\""
+	out += " -e \"FAKETIME_DONT_FAKE_MONOTONIC=1\""
+	return out
+}
+
 // Run starts a node
 func Run(tn *testnet.TestNet, serverID int, container Container) error {
 	command, err := dockerRunCmd(container)
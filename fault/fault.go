@@ -0,0 +1,152 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package fault injects faults into the nodes of a running testnet, and keeps a history of them
+package fault
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+)
+
+const (
+	// LimitsFault is a change to the resources a node may use
+	LimitsFault = "limits"
+
+	// StressFault is cpu or disk stress run within a node
+	StressFault = "stress"
+
+	// PauseFault is a node which has been frozen
+	PauseFault = "pause"
+)
+
+// now gives the current unix timestamp in milliseconds
+func now() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// getNode finds a node of a testnet by its absolute number, along with the client for its server
+func getNode(testnetID string, absNum int) (db.Node, ssh.Client, error) {
+	nodes, err := db.GetAllNodesByTestNet(testnetID)
+	if err != nil {
+		return db.Node{}, nil, util.LogError(err)
+	}
+	node, err := db.GetNodeByAbsNum(nodes, absNum)
+	if err != nil {
+		return db.Node{}, nil, util.LogError(err)
+	}
+	client, err := status.GetClient(node.Server)
+	if err != nil {
+		return db.Node{}, nil, util.LogError(err)
+	}
+	return node, client, nil
+}
+
+// getNodes finds the nodes of a testnet by their absolute numbers. If no numbers are given, all of
+// the nodes are given.
+func getNodes(testnetID string, absNums []int) ([]db.Node, error) {
+	nodes, err := db.GetAllNodesByTestNet(testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("the testnet has no nodes")
+	}
+	if len(absNums) == 0 {
+		return nodes, nil
+	}
+	out := []db.Node{}
+	for _, absNum := range absNums {
+		node, err := db.GetNodeByAbsNum(nodes, absNum)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, node)
+	}
+	return out, nil
+}
+
+// record adds a fault starting at the given time to the history. An end of 0 means the fault lasts until it is removed.
+func record(node db.Node, faultType string, details interface{}, start int64, end int64) error {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = db.InsertFault(db.Fault{
+		TestNetID: node.TestNetID,
+		Node:      node.AbsoluteNum,
+		Type:      faultType,
+		Details:   data,
+		Start:     start,
+		End:       end,
+	})
+	return err
+}
+
+// getActive gets the faults of the given type which are currently in effect on a node
+func getActive(node db.Node, faultType string) ([]db.Fault, error) {
+	faults, err := GetFaults(node.TestNetID, node.AbsoluteNum, false)
+	if err != nil {
+		return nil, err
+	}
+	out := []db.Fault{}
+	for _, fault := range faults {
+		if fault.Type == faultType {
+			out = append(out, fault)
+		}
+	}
+	return out, nil
+}
+
+// GetFaults gets the faults injected into the nodes of a testnet. If node is negative, the faults of every node
+// are given. Unless history is set, only the faults which are currently in effect are given.
+func GetFaults(testnetID string, node int, history bool) ([]db.Fault, error) {
+	faults, err := db.GetFaults(testnetID, node)
+	if err != nil || history {
+		return faults, err
+	}
+	out := []db.Fault{}
+	current := now()
+	for _, fault := range faults {
+		if fault.Active(current) {
+			out = append(out, fault)
+		}
+	}
+	return out, nil
+}
+
+// EndNodeFaults ends all of the faults in effect on the given nodes, for when the nodes are removed
+func EndNodeFaults(nodes []db.Node) error {
+	end := now()
+	for _, node := range nodes {
+		cancelResume(node)
+		for _, faultType := range []string{LimitsFault, StressFault, PauseFault} {
+			err := db.EndFaults(node.TestNetID, node.AbsoluteNum, faultType, end)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
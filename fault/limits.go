@@ -0,0 +1,254 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package fault
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+)
+
+// blkioKnobs are the cgroup files which throttle block io, in the order of the values given by Limits.blkio
+var blkioKnobs = []string{
+	"blkio.throttle.read_bps_device",
+	"blkio.throttle.write_bps_device",
+	"blkio.throttle.read_iops_device",
+	"blkio.throttle.write_iops_device",
+}
+
+// Limits are the resources a node may use while it is running. Values which are not given are left unchanged.
+type Limits struct {
+	// Cpus is the number of cores worth of cpu time the node may use
+	Cpus string `json:"cpus,omitempty"`
+
+	// Memory is the maximum memory the node may use, in the same format as the build resources
+	Memory string `json:"memory,omitempty"`
+
+	// ReadBps is the maximum number of bytes per second the node may read from Device
+	ReadBps int64 `json:"readBps,omitempty"`
+
+	// WriteBps is the maximum number of bytes per second the node may write to Device
+	WriteBps int64 `json:"writeBps,omitempty"`
+
+	// ReadIOps is the maximum number of read operations per second the node may perform on Device
+	ReadIOps int64 `json:"readIOps,omitempty"`
+
+	// WriteIOps is the maximum number of write operations per second the node may perform on Device
+	WriteIOps int64 `json:"writeIOps,omitempty"`
+
+	// Device is the block device to throttle, defaults to the device holding the docker data
+	Device string `json:"device,omitempty"`
+}
+
+// Validate ensures that the limits are valid and allowable
+func (l Limits) Validate() error {
+	err := util.Resources{Cpus: l.Cpus, Memory: l.Memory}.Validate()
+	if err != nil {
+		return err
+	}
+	for _, value := range l.blkio() {
+		if value < 0 {
+			return fmt.Errorf("block io limits cannot be negative")
+		}
+	}
+	return util.ValidateCommandLine(l.Device)
+}
+
+func (l Limits) blkio() []int64 {
+	return []int64{l.ReadBps, l.WriteBps, l.ReadIOps, l.WriteIOps}
+}
+
+func (l Limits) hasBlkio() bool {
+	for _, value := range l.blkio() {
+		if value != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// merge fills in the values which are not given from the previous limits
+func (l Limits) merge(prev Limits) Limits {
+	if len(l.Cpus) == 0 {
+		l.Cpus = prev.Cpus
+	}
+	if len(l.Memory) == 0 {
+		l.Memory = prev.Memory
+	}
+	if l.ReadBps == 0 {
+		l.ReadBps = prev.ReadBps
+	}
+	if l.WriteBps == 0 {
+		l.WriteBps = prev.WriteBps
+	}
+	if l.ReadIOps == 0 {
+		l.ReadIOps = prev.ReadIOps
+	}
+	if l.WriteIOps == 0 {
+		l.WriteIOps = prev.WriteIOps
+	}
+	if len(l.Device) == 0 {
+		l.Device = prev.Device
+	}
+	return l
+}
+
+// updateCommand creates the docker update command to apply the cpu and memory limits to a container
+func updateCommand(name string, res util.Resources) (string, error) {
+	cmd := "docker update"
+	if !res.NoCPULimits() {
+		cmd += fmt.Sprintf(" --cpus %s", res.Cpus)
+	}
+	if !res.NoMemoryLimits() {
+		mem, err := res.GetMemory()
+		if err != nil {
+			return "", fmt.Errorf("invalid value for memory")
+		}
+		cmd += fmt.Sprintf(" --memory %d --memory-swap -1", mem)
+	}
+	return cmd + " " + name, nil
+}
+
+// blkioCommand creates the command which writes the block io limits into the cgroup of a container.
+// A limit of 0 removes the throttling.
+func blkioCommand(name string, device string, values []int64) string {
+	dev := fmt.Sprintf("$(lsblk -ndo MAJ:MIN %s | tr -d ' ')", device)
+	if len(device) == 0 {
+		dev = "$(d=$(df --output=source $(docker info -f '{{.DockerRootDir}}') | tail -n 1);" +
+			" p=$(lsblk -ndo PKNAME $d); [ -n \"$p\" ] && d=/dev/$p; lsblk -ndo MAJ:MIN $d | tr -d ' ')"
+	}
+	cmds := []string{
+		fmt.Sprintf("dir=$(find /sys/fs/cgroup/blkio/ -maxdepth 3 -type d -name \"*$(docker inspect -f '{{.Id}}' %s)*\" | head -n 1)", name),
+		"dev=" + dev,
+		"[ -n \"$dir\" ] && [ -n \"$dev\" ]",
+	}
+	for i, knob := range blkioKnobs {
+		cmds = append(cmds, fmt.Sprintf("echo \"$dev %d\" | sudo -n tee $dir/%s > /dev/null", values[i], knob))
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// getLimits gets the limits currently applied to a node, beyond those given at build time
+func getLimits(node db.Node) (Limits, error) {
+	out := Limits{}
+	faults, err := getActive(node, LimitsFault)
+	if err != nil || len(faults) == 0 {
+		return out, err
+	}
+	return out, util.LogError(json.Unmarshal(faults[len(faults)-1].Details, &out))
+}
+
+// SetLimits changes the resources a running node may use
+func SetLimits(testnetID string, absNum int, limits Limits) error {
+	err := limits.Validate()
+	if err != nil {
+		return err
+	}
+	node, client, err := getNode(testnetID, absNum)
+	if err != nil {
+		return err
+	}
+	prev, err := getLimits(node)
+	if err != nil {
+		return err
+	}
+	blkioChanged := limits.hasBlkio()
+	if blkioChanged && prev.hasBlkio() && len(limits.Device) > 0 && limits.Device != prev.Device {
+		return fmt.Errorf("block io is already limited on another device, reset the limits first")
+	}
+
+	res := util.Resources{Cpus: limits.Cpus, Memory: limits.Memory}
+	if !res.NoLimits() {
+		cmd, err := updateCommand(node.GetNodeName(), res)
+		if err != nil {
+			return util.LogError(err)
+		}
+		_, err = client.Run(cmd)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	limits = limits.merge(prev)
+	if blkioChanged {
+		_, err = client.Run(blkioCommand(node.GetNodeName(), limits.Device, limits.blkio()))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+
+	start := now()
+	err = db.EndFaults(testnetID, absNum, LimitsFault, start)
+	if err != nil {
+		return err
+	}
+	return record(node, LimitsFault, limits, start, 0)
+}
+
+// getBuildResources gets the resources a node was given when it was built
+func getBuildResources(testnetID string, absNum int) (util.Resources, error) {
+	build, err := db.GetBuildByTestnet(testnetID)
+	if err != nil {
+		return util.Resources{}, util.LogError(err)
+	}
+	if len(build.Resources) > absNum {
+		return build.Resources[absNum], nil
+	}
+	if len(build.Resources) > 0 {
+		return build.Resources[0], nil
+	}
+	return util.Resources{}, nil
+}
+
+// ResetLimits restores the resources a node may use to those it was built with. Docker cannot remove a memory limit,
+// so a node which was built without one keeps the last memory limit it was given.
+func ResetLimits(testnetID string, absNum int) error {
+	node, client, err := getNode(testnetID, absNum)
+	if err != nil {
+		return err
+	}
+	prev, err := getLimits(node)
+	if err != nil {
+		return err
+	}
+	res, err := getBuildResources(testnetID, absNum)
+	if err != nil {
+		return err
+	}
+	if res.NoCPULimits() {
+		res.Cpus = "0" //0 removes the cpu limit
+	}
+	cmd, err := updateCommand(node.GetNodeName(), res)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = client.Run(cmd)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if prev.hasBlkio() {
+		_, err = client.Run(blkioCommand(node.GetNodeName(), prev.Device, make([]int64, len(blkioKnobs))))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	return db.EndFaults(testnetID, absNum, LimitsFault, now())
+}
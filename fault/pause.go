@@ -0,0 +1,235 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package fault
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+)
+
+var (
+	// resumeTimers holds the timers which automatically resume the nodes paused for a duration
+	resumeTimers   = map[string]*time.Timer{}
+	resumeTimerMux = sync.Mutex{}
+)
+
+// Pause describes how nodes are frozen
+type Pause struct {
+	// Nodes are the absolute numbers of the nodes to pause, all of the nodes if empty
+	Nodes []int `json:"nodes,omitempty"`
+
+	// Mode is either container, which freezes every process in the node with docker pause, or process,
+	// which only stops the main process of the node and its children with SIGSTOP. Defaults to container.
+	Mode string `json:"mode"`
+
+	// Duration is the number of seconds after which the nodes are automatically resumed, 0 to pause
+	// them until they are unpaused
+	Duration int `json:"duration"`
+}
+
+// Validate ensures that the pause is valid, filling in the default mode
+func (p *Pause) Validate() error {
+	if len(p.Mode) == 0 {
+		p.Mode = "container"
+	}
+	if p.Mode != "container" && p.Mode != "process" {
+		return fmt.Errorf("unsupported pause mode \"%s\", expected container or process", p.Mode)
+	}
+	if p.Duration < 0 {
+		return fmt.Errorf("duration cannot be negative")
+	}
+	return nil
+}
+
+func timerKey(node db.Node) string {
+	return fmt.Sprintf("%s-%d", node.TestNetID, node.AbsoluteNum)
+}
+
+func pauseNode(node db.Node, mode string) error {
+	client, err := status.GetClient(node.Server)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if mode == "process" {
+		return util.LogError(ssh.SignalMainProcess(client, node, "STOP"))
+	}
+	_, err = client.Run(fmt.Sprintf("docker pause %s", node.GetNodeName()))
+	return util.LogError(err)
+}
+
+func unpauseNode(node db.Node, mode string) error {
+	client, err := status.GetClient(node.Server)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if mode == "process" {
+		return util.LogError(ssh.SignalMainProcess(client, node, "CONT"))
+	}
+	_, err = client.Run(fmt.Sprintf("docker unpause %s", node.GetNodeName()))
+	return util.LogError(err)
+}
+
+// PauseNodes freezes the given nodes, keeping their state intact so that they can later be resumed
+func PauseNodes(testnetID string, pause Pause) error {
+	err := pause.Validate()
+	if err != nil {
+		return err
+	}
+	nodes, err := getNodes(testnetID, pause.Nodes)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		active, err := getActive(node, PauseFault)
+		if err != nil {
+			return err
+		}
+		if len(active) > 0 {
+			return fmt.Errorf("node %d is already paused", node.AbsoluteNum)
+		}
+	}
+	details := Pause{Mode: pause.Mode, Duration: pause.Duration}
+	for _, node := range nodes {
+		err = pauseNode(node, pause.Mode)
+		if err != nil {
+			return err
+		}
+		//The end is only recorded once the node has actually been resumed, so that a pending resume
+		//can be rescheduled if genesis restarts before it happens
+		err = record(node, PauseFault, details, now(), 0)
+		if err != nil {
+			return err
+		}
+		if pause.Duration > 0 {
+			scheduleResume(node, pause.Mode, time.Duration(pause.Duration)*time.Second)
+		}
+	}
+	return nil
+}
+
+// scheduleResume resumes a paused node after the given duration
+func scheduleResume(node db.Node, mode string, after time.Duration) {
+	resumeTimerMux.Lock()
+	defer resumeTimerMux.Unlock()
+	key := timerKey(node)
+	resumeTimers[key] = time.AfterFunc(after, func() {
+		resumeTimerMux.Lock()
+		delete(resumeTimers, key)
+		resumeTimerMux.Unlock()
+
+		err := unpauseNode(node, mode)
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": node.TestNetID, "node": node.AbsoluteNum,
+				"error": err}).Error("failed to resume a paused node")
+			return //leave the pause in effect, so that it can still be removed by hand
+		}
+		err = db.EndFaults(node.TestNetID, node.AbsoluteNum, PauseFault, now())
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": node.TestNetID, "node": node.AbsoluteNum,
+				"error": err}).Error("failed to record the end of a pause")
+		}
+	})
+}
+
+// ResumePendingPauses reschedules the automatic resume of the nodes which were paused for a duration
+// before genesis was restarted, resuming right away those whose duration has already passed
+func ResumePendingPauses() {
+	faults, err := db.GetUnendedFaults(PauseFault)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("failed to fetch the pending pauses")
+		return
+	}
+	current := now()
+	for _, fault := range faults {
+		var pause Pause
+		err = json.Unmarshal(fault.Details, &pause)
+		if err != nil {
+			log.WithFields(log.Fields{"id": fault.ID, "error": err}).Error("failed to parse a stored pause")
+			continue
+		}
+		if pause.Duration <= 0 {
+			continue //paused until unpaused
+		}
+		nodes, err := getNodes(fault.TestNetID, []int{fault.Node})
+		if err != nil { //the node no longer exists, so neither does the pause
+			log.WithFields(log.Fields{"testnet": fault.TestNetID, "node": fault.Node,
+				"error": err}).Warn("ending the pause of a missing node")
+			db.EndFaults(fault.TestNetID, fault.Node, PauseFault, current)
+			continue
+		}
+		remaining := fault.Start + int64(pause.Duration)*1000 - current
+		if remaining < 0 {
+			remaining = 0
+		}
+		scheduleResume(nodes[0], pause.Mode, time.Duration(remaining)*time.Millisecond)
+	}
+}
+
+func cancelResume(node db.Node) {
+	resumeTimerMux.Lock()
+	defer resumeTimerMux.Unlock()
+	key := timerKey(node)
+	if timer, ok := resumeTimers[key]; ok {
+		timer.Stop()
+		delete(resumeTimers, key)
+	}
+}
+
+// UnpauseNodes resumes the given nodes, or all of the paused nodes if none are given
+func UnpauseNodes(testnetID string, absNums []int) error {
+	nodes, err := getNodes(testnetID, absNums)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		active, err := getActive(node, PauseFault)
+		if err != nil {
+			return err
+		}
+		if len(active) == 0 {
+			if len(absNums) > 0 {
+				return fmt.Errorf("node %d is not paused", node.AbsoluteNum)
+			}
+			continue
+		}
+		var pause Pause
+		err = json.Unmarshal(active[len(active)-1].Details, &pause)
+		if err != nil {
+			return util.LogError(err)
+		}
+		cancelResume(node)
+		err = unpauseNode(node, pause.Mode)
+		if err != nil {
+			return err
+		}
+		err = db.EndFaults(testnetID, node.AbsoluteNum, PauseFault, now())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package fault
+
+import (
+	"fmt"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+)
+
+const (
+	// stressMarker identifies the stress processes within a node, so that they can be stopped early
+	stressMarker = "wb_stress"
+
+	// maxStressWorkers is the most stress processes which may be run in a node at once
+	maxStressWorkers = 64
+)
+
+// Stress is a workload which is run within a node to starve it of cpu or disk bandwidth
+type Stress struct {
+	// Type is the resource to stress, either cpu or disk
+	Type string `json:"type"`
+
+	// Workers is the number of processes generating the stress, defaults to 1
+	Workers int `json:"workers"`
+
+	// Duration is the number of seconds the stress lasts for
+	Duration int `json:"duration"`
+}
+
+// Validate ensures that the stress is valid
+func (s Stress) Validate() error {
+	if s.Type != "cpu" && s.Type != "disk" {
+		return fmt.Errorf("unsupported stress type \"%s\", expected cpu or disk", s.Type)
+	}
+	if s.Workers < 0 || s.Workers > maxStressWorkers {
+		return fmt.Errorf("workers must be between 1 and %d", maxStressWorkers)
+	}
+	if s.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	return nil
+}
+
+// command creates the command which runs the stress in a node. It only relies on sh and timeout,
+// so that it works in most images.
+func (s Stress) command() string {
+	workers := s.Workers
+	if workers == 0 {
+		workers = 1
+	}
+	work := "while :; do :; done"
+	if s.Type == "disk" {
+		work = fmt.Sprintf("while :; do dd if=/dev/zero of=/tmp/%s_$i bs=1M count=64 conv=fsync 2>/dev/null; done", stressMarker)
+	}
+	return fmt.Sprintf("sh -c 'i=0; while [ $i -lt %d ]; do timeout %d sh -c \"%s; : %s\" & i=$((i+1)); done; wait; rm -f /tmp/%s_*'",
+		workers, s.Duration, work, stressMarker, stressMarker)
+}
+
+// StartStress runs the given stress in a node in the background
+func StartStress(testnetID string, absNum int, stress Stress) error {
+	err := stress.Validate()
+	if err != nil {
+		return err
+	}
+	node, client, err := getNode(testnetID, absNum)
+	if err != nil {
+		return err
+	}
+	_, err = client.DockerExecd(node, stress.command())
+	if err != nil {
+		return util.LogError(err)
+	}
+	start := now()
+	return record(node, StressFault, stress, start, start+int64(stress.Duration)*1000)
+}
+
+// StopStress stops any stress running in a node
+func StopStress(testnetID string, absNum int) error {
+	node, client, err := getNode(testnetID, absNum)
+	if err != nil {
+		return err
+	}
+	_, err = client.DockerExec(node, fmt.Sprintf("sh -c 'for p in /proc/[0-9]*; do pid=${p#/proc/};"+
+		" [ $pid != $$ ] && grep -q %s $p/cmdline 2>/dev/null && kill $pid; done; rm -f /tmp/%s_*; true'",
+		stressMarker, stressMarker))
+	if err != nil {
+		return util.LogError(err)
+	}
+	return db.EndFaults(testnetID, absNum, StressFault, now())
+}
@@ -18,5 +18,6 @@ require (
 	github.com/whiteblock/mustache v1.0.1
 	github.com/whiteblock/scp v0.0.0-20190401151346-3a0c9dc7020d
 	golang.org/x/crypto v0.0.0-20190530122614-20be4c3c3ed5
+	golang.org/x/net v0.0.0-20190522155817-f3200d17e092
 	golang.org/x/sync v0.0.0-20190423024810-112230192c58
 )
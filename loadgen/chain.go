@@ -0,0 +1,50 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package loadgen
+
+import (
+	"fmt"
+
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+)
+
+// chain submits transactions to, and watches the blocks of, a specific type of blockchain
+type chain interface {
+	// submit sends n transactions through the given node. It returns the ids of the accepted transactions,
+	// and the number which were rejected.
+	submit(client ssh.Client, node ssh.Node, n int) ([]string, int, error)
+
+	// head gets the number of the latest block
+	head(client ssh.Client, node ssh.Node) (int64, error)
+
+	// blocks gets the ids of the transactions in the blocks from from to to, inclusive
+	blocks(client ssh.Client, node ssh.Node, from int64, to int64) ([]string, error)
+}
+
+// getChain creates the chain for the blockchain of the given testnet, from the accounts created during its build
+func getChain(tn *testnet.TestNet) (chain, error) {
+	switch tn.LDD.Blockchain {
+	case "ethereum", "geth", "pantheon", "parity", "mixedeth":
+		return newEthChain(tn)
+	case "eos":
+		return newEosChain(tn)
+	}
+	return nil, fmt.Errorf("load generation is not supported for %s", tn.LDD.Blockchain)
+}
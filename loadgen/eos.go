@@ -0,0 +1,155 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+const eosRPCPort = 8889
+
+// eosChain sends token transfers between the user accounts created during the build. The key of
+// every account is in the wallet of every node, so each node signs the transfers it is sent.
+type eosChain struct {
+	accounts  []string
+	passwords []string
+
+	mux  sync.Mutex
+	next int
+}
+
+func newEosChain(tn *testnet.TestNet) (chain, error) {
+	out := &eosChain{}
+	rawAccounts, ok := tn.BuildState.GetExt("accounts")
+	if !ok {
+		return nil, fmt.Errorf("no accounts were found for the testnet")
+	}
+	err := remarshal(rawAccounts, &out.accounts)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	if len(out.accounts) < 2 {
+		return nil, fmt.Errorf("at least two accounts are needed to generate load")
+	}
+	rawPasswords, ok := tn.BuildState.GetExt("passwords")
+	if !ok {
+		return nil, fmt.Errorf("no wallet passwords were found for the testnet")
+	}
+	return out, util.LogError(remarshal(rawPasswords, &out.passwords))
+}
+
+// decodeAll decodes each of the json objects in the output of several cleos commands
+func decodeAll(res string, fn func(*json.Decoder) error) error {
+	decoder := json.NewDecoder(strings.NewReader(res))
+	for {
+		err := fn(decoder)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (ec *eosChain) submit(client ssh.Client, node ssh.Node, n int) ([]string, int, error) {
+	cmds := []string{}
+	if node.GetAbsoluteNumber() < len(ec.passwords) {
+		cmds = append(cmds, fmt.Sprintf("cleos wallet unlock --password %s >/dev/null 2>&1",
+			ec.passwords[node.GetAbsoluteNumber()]))
+	}
+	ec.mux.Lock()
+	for i := 0; i < n; i++ {
+		from := ec.accounts[ec.next%len(ec.accounts)]
+		to := ec.accounts[(ec.next+1)%len(ec.accounts)]
+		//the memo keeps otherwise identical transfers from being rejected as duplicates
+		cmds = append(cmds, fmt.Sprintf(`cleos -u http://%s:%d transfer -j %s %s "0.0001 SYS" "load %d" 2>/dev/null`,
+			node.GetIP(), eosRPCPort, from, to, ec.next))
+		ec.next++
+	}
+	ec.mux.Unlock()
+
+	//the script always succeeds, so that the failed transfers are counted from the output instead of
+	//the exit status of the last transfer throwing away the others
+	res, err := client.DockerExec(node, fmt.Sprintf("bash -c '%s; true'", strings.Join(cmds, "; ")))
+	if err != nil {
+		return nil, n, util.LogError(err)
+	}
+	ids := []string{}
+	err = decodeAll(res, func(decoder *json.Decoder) error {
+		var tx struct {
+			ID string `json:"transaction_id"`
+		}
+		err := decoder.Decode(&tx)
+		if err == nil && len(tx.ID) > 0 {
+			ids = append(ids, tx.ID)
+		}
+		return err
+	})
+	return ids, n - len(ids), util.LogError(err)
+}
+
+func (ec *eosChain) head(client ssh.Client, node ssh.Node) (int64, error) {
+	res, err := client.DockerExec(node, fmt.Sprintf("cleos -u http://%s:%d get info", node.GetIP(), eosRPCPort))
+	if err != nil {
+		return 0, util.LogError(err)
+	}
+	var info struct {
+		HeadBlockNum int64 `json:"head_block_num"`
+	}
+	return info.HeadBlockNum, util.LogError(json.Unmarshal([]byte(res), &info))
+}
+
+func (ec *eosChain) blocks(client ssh.Client, node ssh.Node, from int64, to int64) ([]string, error) {
+	res, err := client.DockerExec(node, fmt.Sprintf("bash -c 'for n in $(seq %d %d); do cleos -u http://%s:%d get block $n; done'",
+		from, to, node.GetIP(), eosRPCPort))
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := []string{}
+	err = decodeAll(res, func(decoder *json.Decoder) error {
+		var block struct {
+			Transactions []struct {
+				Trx json.RawMessage `json:"trx"`
+			} `json:"transactions"`
+		}
+		err := decoder.Decode(&block)
+		if err != nil {
+			return err
+		}
+		for _, tx := range block.Transactions {
+			var trx struct {
+				ID string `json:"id"`
+			}
+			if json.Unmarshal(tx.Trx, &trx) == nil && len(trx.ID) > 0 {
+				out = append(out, trx.ID)
+			}
+		}
+		return nil
+	})
+	return out, util.LogError(err)
+}
@@ -0,0 +1,247 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/whiteblock/genesis/protocols/ethereum"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+const (
+	defaultEthRPCPort = 8545
+	txGasLimit        = 21000
+)
+
+var txGasPrice = big.NewInt(1000000000) //1 gwei, the default minimum gas price of geth
+
+// ethChain sends value transfers between the genesis funded accounts of an ethereum network. The transactions
+// are signed by genesis, so that any client can be driven through eth_sendRawTransaction.
+type ethChain struct {
+	port     int
+	accounts []*ethereum.Account
+
+	mux    sync.Mutex
+	next   int
+	nonces map[common.Address]uint64
+}
+
+// getEthAccounts gets the funded accounts of the testnet, which are stored either as accounts in the
+// build state, or as addresses in the external state with each private key stored under its address
+func getEthAccounts(tn *testnet.TestNet) ([]*ethereum.Account, error) {
+	var accounts []*ethereum.Account
+	if tn.BuildState.GetP("accounts", &accounts) && len(accounts) > 0 {
+		return accounts, nil
+	}
+	rawAddresses, ok := tn.BuildState.GetExt("accounts")
+	if !ok {
+		return nil, fmt.Errorf("no accounts were found for the testnet")
+	}
+	var addresses []string
+	err := remarshal(rawAddresses, &addresses)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	for _, address := range addresses {
+		rawKeys, ok := tn.BuildState.GetExt(address)
+		if !ok {
+			return nil, fmt.Errorf("missing the private key of %s", address)
+		}
+		var keys map[string]string
+		err = remarshal(rawKeys, &keys)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		account, err := ethereum.CreateAccountFromHex(keys["privateKey"])
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		accounts = append(accounts, account)
+	}
+	if len(accounts) < 2 {
+		return nil, fmt.Errorf("at least two accounts are needed to generate load")
+	}
+	return accounts, nil
+}
+
+func newEthChain(tn *testnet.TestNet) (chain, error) {
+	accounts, err := getEthAccounts(tn)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := &ethChain{port: defaultEthRPCPort, accounts: accounts, nonces: map[common.Address]uint64{}}
+	if rawPort, ok := tn.BuildState.GetExt("port"); ok {
+		remarshal(rawPort, &out.port)
+	}
+	return out, nil
+}
+
+// rpc makes a batch of json rpc calls to the client of the given node
+func (ec *ethChain) rpc(client ssh.Client, node ssh.Node, calls []ethereum.RPCCall) (map[int]ethereum.RPCResult, error) {
+	return ethereum.BatchRPC(client, node, ec.port, calls)
+}
+
+// syncNonces fetches the pending nonce of each account which does not have a known nonce. Must be called with
+// the mutex held.
+func (ec *ethChain) syncNonces(client ssh.Client, node ssh.Node) error {
+	calls := []ethereum.RPCCall{}
+	missing := []common.Address{}
+	for _, account := range ec.accounts {
+		if _, ok := ec.nonces[account.Address]; ok {
+			continue
+		}
+		missing = append(missing, account.Address)
+		calls = append(calls, ethereum.RPCCall{Method: "eth_getTransactionCount",
+			Params: []interface{}{strings.ToLower(account.Address.Hex()), "pending"}})
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+	results, err := ec.rpc(client, node, calls)
+	if err != nil {
+		return util.LogError(err)
+	}
+	for i, address := range missing {
+		var nonce hexutil.Uint64
+		err = json.Unmarshal(results[i].Result, &nonce)
+		if err != nil {
+			return fmt.Errorf("could not get the nonce of %s", address.Hex())
+		}
+		ec.nonces[address] = uint64(nonce)
+	}
+	return nil
+}
+
+func (ec *ethChain) submit(client ssh.Client, node ssh.Node, n int) ([]string, int, error) {
+	ec.mux.Lock()
+	err := ec.syncNonces(client, node)
+	if err != nil {
+		ec.mux.Unlock()
+		return nil, n, util.LogError(err)
+	}
+	calls := make([]ethereum.RPCCall, n)
+	senders := make([]common.Address, n)
+	ids := make([]string, n)
+	for i := 0; i < n; i++ {
+		from := ec.accounts[ec.next%len(ec.accounts)]
+		to := ec.accounts[(ec.next+1)%len(ec.accounts)]
+		ec.next++
+
+		raw, hash, err := signTransfer(from, to.Address, ec.nonces[from.Address])
+		if err != nil {
+			ec.mux.Unlock()
+			return nil, n, util.LogError(err)
+		}
+		ec.nonces[from.Address]++
+		senders[i] = from.Address
+		ids[i] = hash
+		calls[i] = ethereum.RPCCall{Method: "eth_sendRawTransaction", Params: []interface{}{hexutil.Encode(raw)}}
+	}
+	ec.mux.Unlock()
+
+	results, err := ec.rpc(client, node, calls)
+	accepted := []string{}
+	ec.mux.Lock()
+	defer ec.mux.Unlock()
+	for i := range calls {
+		result, ok := results[i]
+		if err != nil || !ok || result.Error != nil {
+			delete(ec.nonces, senders[i]) //The nonces after it will now be wrong, so refetch them
+			continue
+		}
+		accepted = append(accepted, ids[i])
+	}
+	return accepted, n - len(accepted), err
+}
+
+func (ec *ethChain) head(client ssh.Client, node ssh.Node) (int64, error) {
+	results, err := ec.rpc(client, node, []ethereum.RPCCall{{Method: "eth_blockNumber", Params: []interface{}{}}})
+	if err != nil {
+		return 0, util.LogError(err)
+	}
+	var num hexutil.Uint64
+	err = json.Unmarshal(results[0].Result, &num)
+	return int64(num), util.LogError(err)
+}
+
+func (ec *ethChain) blocks(client ssh.Client, node ssh.Node, from int64, to int64) ([]string, error) {
+	calls := []ethereum.RPCCall{}
+	for num := from; num <= to; num++ {
+		calls = append(calls, ethereum.RPCCall{Method: "eth_getBlockByNumber",
+			Params: []interface{}{hexutil.EncodeUint64(uint64(num)), false}})
+	}
+	results, err := ec.rpc(client, node, calls)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := []string{}
+	for _, result := range results {
+		var block struct {
+			Transactions []string `json:"transactions"`
+		}
+		if json.Unmarshal(result.Result, &block) != nil {
+			continue
+		}
+		for _, tx := range block.Transactions {
+			out = append(out, strings.ToLower(tx))
+		}
+	}
+	return out, nil
+}
+
+// signTransfer creates a raw homestead transaction sending 1 wei, which is valid regardless of the chain id
+// and the forks of the network. It returns the raw transaction and its hash.
+func signTransfer(from *ethereum.Account, to common.Address, nonce uint64) ([]byte, string, error) {
+	fields := []interface{}{nonce, txGasPrice, uint64(txGasLimit), to, big.NewInt(1), []byte{}}
+	unsigned, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, "", util.LogError(err)
+	}
+	sig, err := crypto.Sign(crypto.Keccak256(unsigned), from.PrivateKey)
+	if err != nil {
+		return nil, "", util.LogError(err)
+	}
+	fields = append(fields, big.NewInt(int64(sig[64])+27), new(big.Int).SetBytes(sig[:32]),
+		new(big.Int).SetBytes(sig[32:64]))
+	raw, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, "", util.LogError(err)
+	}
+	return raw, hexutil.Encode(crypto.Keccak256(raw)), nil
+}
+
+// remarshal converts a value from the build state into the type of out
+func remarshal(in interface{}, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
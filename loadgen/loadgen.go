@@ -0,0 +1,373 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package loadgen drives transactions into a running testnet and measures how the network handles them
+package loadgen
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/testnet"
+)
+
+const (
+	// tick is how often transactions are sent, and blocks are checked
+	tick = time.Second
+	// maxBlocksPerPoll limits how many blocks are fetched at once while catching up
+	maxBlocksPerPoll = 20
+	// drainTimeout is how long to keep watching for the pending transactions after the generator is stopped
+	drainTimeout = 30 * time.Second
+)
+
+var (
+	generators   = map[string]*generator{}
+	generatorMux = sync.Mutex{}
+)
+
+// generator sends transactions to the nodes of a single testnet according to a pattern
+type generator struct {
+	tn    *testnet.TestNet
+	chain chain
+	rec   *recorder
+
+	mux          sync.Mutex
+	pattern      Pattern
+	nodes        []db.Node
+	patternStart time.Time
+	started      time.Time
+	stopped      time.Time
+	stop         chan bool
+	// forgotten is set once the testnet is gone, so that the pending transactions are no longer watched
+	forgotten bool
+}
+
+// getNodes gets the nodes the pattern sends transactions to
+func getNodes(tn *testnet.TestNet, pattern Pattern) ([]db.Node, error) {
+	if len(pattern.Selector) > 0 {
+		return db.ResolveNodes(tn.Nodes, pattern.Selector)
+	}
+	if len(pattern.Nodes) == 0 {
+		return tn.Nodes, nil
+	}
+	out := []db.Node{}
+	for _, absNum := range pattern.Nodes {
+		found := false
+		for _, node := range tn.Nodes {
+			if node.AbsoluteNum == absNum {
+				out = append(out, node)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("node %d does not exist", absNum)
+		}
+	}
+	return out, nil
+}
+
+// Start starts sending transactions to the given testnet. Any results from a previous run are discarded.
+func Start(testnetID string, pattern Pattern) error {
+	err := pattern.Validate()
+	if err != nil {
+		return err
+	}
+	generatorMux.Lock()
+	defer generatorMux.Unlock()
+	if gen, ok := generators[testnetID]; ok && gen.running() {
+		return fmt.Errorf("load is already being generated for this testnet, adjust or stop it instead")
+	}
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return err
+	}
+	nodes, err := getNodes(tn, pattern)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("the testnet has no nodes")
+	}
+	ch, err := getChain(tn)
+	if err != nil {
+		return err
+	}
+	node := nodes[0]
+	head, err := ch.head(tn.Clients[node.Server], node)
+	if err != nil {
+		return fmt.Errorf("could not reach node %d: %s", node.AbsoluteNum, err.Error())
+	}
+
+	now := time.Now()
+	gen := &generator{
+		tn:           tn,
+		chain:        ch,
+		rec:          newRecorder(),
+		pattern:      pattern,
+		nodes:        nodes,
+		patternStart: now,
+		started:      now,
+		stop:         make(chan bool),
+	}
+	generators[testnetID] = gen
+	log.WithFields(log.Fields{"testnet": testnetID, "pattern": pattern}).Info("starting load generation")
+	go gen.send()
+	go gen.watch(head)
+	return nil
+}
+
+// Adjust replaces the pattern of a running generator, the new pattern starts from the beginning
+func Adjust(testnetID string, pattern Pattern) error {
+	err := pattern.Validate()
+	if err != nil {
+		return err
+	}
+	gen, err := get(testnetID)
+	if err != nil {
+		return err
+	}
+	if !gen.running() {
+		return fmt.Errorf("load generation has already been stopped for this testnet")
+	}
+	nodes, err := getNodes(gen.tn, pattern)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("the testnet has no nodes")
+	}
+	gen.mux.Lock()
+	defer gen.mux.Unlock()
+	gen.pattern = pattern
+	gen.nodes = nodes
+	gen.patternStart = time.Now()
+	log.WithFields(log.Fields{"testnet": testnetID, "pattern": pattern}).Info("adjusted load generation")
+	return nil
+}
+
+// Stop stops sending transactions to the given testnet. Transactions which have already been sent
+// are still watched for a while, so that their inclusion is recorded.
+func Stop(testnetID string) error {
+	gen, err := get(testnetID)
+	if err != nil {
+		return err
+	}
+	gen.mux.Lock()
+	defer gen.mux.Unlock()
+	if !gen.stopped.IsZero() {
+		return fmt.Errorf("load generation has already been stopped for this testnet")
+	}
+	gen.halt()
+	log.WithFields(log.Fields{"testnet": testnetID}).Info("stopped load generation")
+	return nil
+}
+
+// Forget stops any load generation for the given testnet and discards its results, as the testnet is gone
+func Forget(testnetID string) {
+	generatorMux.Lock()
+	gen, ok := generators[testnetID]
+	delete(generators, testnetID)
+	generatorMux.Unlock()
+	if !ok {
+		return
+	}
+	gen.mux.Lock()
+	defer gen.mux.Unlock()
+	gen.forgotten = true
+	gen.halt()
+	log.WithFields(log.Fields{"testnet": testnetID}).Info("forgot load generation")
+}
+
+// RemoveNodes stops sending transactions to the given nodes, which have been removed from the testnet.
+// The load generation is stopped if none of its nodes remain.
+func RemoveNodes(testnetID string, absNums []int) {
+	gen, err := get(testnetID)
+	if err != nil {
+		return //load is not being generated for this testnet
+	}
+	gen.mux.Lock()
+	defer gen.mux.Unlock()
+	nodes := []db.Node{}
+	for _, node := range gen.nodes {
+		removed := false
+		for _, absNum := range absNums {
+			if node.AbsoluteNum == absNum {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			nodes = append(nodes, node)
+		}
+	}
+	gen.nodes = nodes
+	if len(nodes) == 0 && gen.halt() {
+		log.WithFields(log.Fields{"testnet": testnetID}).Info("stopped load generation, as all of its nodes were removed")
+	}
+}
+
+// GetSummary gets the results of the current or last load generation run for the given testnet
+func GetSummary(testnetID string) (Summary, error) {
+	gen, err := get(testnetID)
+	if err != nil {
+		return Summary{}, err
+	}
+	gen.mux.Lock()
+	out := Summary{
+		Pattern: gen.pattern,
+		Running: gen.stopped.IsZero(),
+		Started: gen.started.Unix(),
+	}
+	end := time.Now()
+	if !gen.stopped.IsZero() {
+		out.Stopped = gen.stopped.Unix()
+		end = gen.stopped
+	}
+	gen.mux.Unlock()
+	gen.rec.summarize(&out, gen.started, end)
+	return out, nil
+}
+
+func get(testnetID string) (*generator, error) {
+	generatorMux.Lock()
+	defer generatorMux.Unlock()
+	gen, ok := generators[testnetID]
+	if !ok {
+		return nil, fmt.Errorf("load is not being generated for this testnet")
+	}
+	return gen, nil
+}
+
+// halt stops sending transactions, if that has not already happened. The caller must hold gen.mux.
+// Returns whether the generator was running.
+func (gen *generator) halt() bool {
+	if !gen.stopped.IsZero() {
+		return false
+	}
+	gen.stopped = time.Now()
+	close(gen.stop)
+	return true
+}
+
+func (gen *generator) running() bool {
+	gen.mux.Lock()
+	defer gen.mux.Unlock()
+	return gen.stopped.IsZero()
+}
+
+// send sends the transactions each tick, spreading them across the nodes
+func (gen *generator) send() {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	credit := 0.0
+	inFlight := map[int]bool{}
+	flightMux := sync.Mutex{}
+	for {
+		select {
+		case <-gen.stop:
+			return
+		case <-ticker.C:
+		}
+		gen.mux.Lock()
+		credit += gen.pattern.Rate(time.Since(gen.patternStart)) * tick.Seconds()
+		nodes := gen.nodes
+		gen.mux.Unlock()
+
+		total := int(credit)
+		credit -= float64(total)
+		for i, node := range nodes {
+			n := total / len(nodes)
+			if i < total%len(nodes) {
+				n++
+			}
+			if n == 0 {
+				continue
+			}
+			flightMux.Lock()
+			busy := inFlight[node.AbsoluteNum]
+			inFlight[node.AbsoluteNum] = true
+			flightMux.Unlock()
+			if busy {
+				//the node is still handling the last batch, so the generator cannot keep up with the pattern
+				gen.rec.submit(nil, n, time.Now(), 0)
+				continue
+			}
+			go func(node db.Node, n int) {
+				defer func() {
+					flightMux.Lock()
+					delete(inFlight, node.AbsoluteNum)
+					flightMux.Unlock()
+				}()
+				start := time.Now()
+				ids, failed, err := gen.chain.submit(gen.tn.Clients[node.Server], node, n)
+				if err != nil {
+					log.WithFields(log.Fields{"testnet": gen.tn.TestNetID, "node": node.AbsoluteNum,
+						"error": err}).Warn("failed to submit transactions")
+				}
+				gen.rec.submit(ids, failed, start, time.Since(start))
+			}(node, n)
+		}
+	}
+}
+
+// watch checks for new blocks each tick, recording the inclusion of the transactions which were sent
+func (gen *generator) watch(last int64) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	var drainUntil time.Time
+	for range ticker.C {
+		gen.mux.Lock()
+		if gen.forgotten || len(gen.nodes) == 0 {
+			gen.mux.Unlock()
+			return
+		}
+		node := gen.nodes[0]
+		if !gen.stopped.IsZero() {
+			drainUntil = gen.stopped.Add(drainTimeout)
+		}
+		gen.mux.Unlock()
+		if !drainUntil.IsZero() && (time.Now().After(drainUntil) || gen.rec.pendingCount() == 0) {
+			return
+		}
+
+		client := gen.tn.Clients[node.Server]
+		head, err := gen.chain.head(client, node)
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": gen.tn.TestNetID, "error": err}).Warn("failed to get the latest block")
+			continue
+		}
+		if head <= last {
+			continue
+		}
+		to := head
+		if to-last > maxBlocksPerPoll {
+			to = last + maxBlocksPerPoll
+		}
+		ids, err := gen.chain.blocks(client, node, last+1, to)
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": gen.tn.TestNetID, "error": err}).Warn("failed to get the latest blocks")
+			continue
+		}
+		gen.rec.include(ids, time.Now())
+		last = to
+	}
+}
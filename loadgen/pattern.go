@@ -0,0 +1,91 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package loadgen
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxTPS is the highest rate a pattern can have, as every transaction is a separate command in a node
+const MaxTPS = 1000
+
+// Pattern describes the rate at which transactions are sent
+type Pattern struct {
+	// Type is one of constant, ramp or burst
+	Type string `json:"type"`
+	// TPS is the rate of constant load, and the base rate between bursts
+	TPS float64 `json:"tps"`
+	// EndTPS is the rate which a ramp reaches after RampSeconds, starting from TPS
+	EndTPS float64 `json:"endTps,omitempty"`
+	// RampSeconds is the length of the ramp, after which the rate stays at EndTPS
+	RampSeconds int64 `json:"rampSeconds,omitempty"`
+	// BurstTPS is the rate during a burst
+	BurstTPS float64 `json:"burstTps,omitempty"`
+	// BurstSeconds is the length of each burst
+	BurstSeconds int64 `json:"burstSeconds,omitempty"`
+	// IntervalSeconds is the time from the start of one burst to the start of the next
+	IntervalSeconds int64 `json:"intervalSeconds,omitempty"`
+	// Nodes are the absolute numbers of the nodes to send transactions to, empty means all nodes
+	Nodes []int `json:"nodes,omitempty"`
+	// Selector picks out the nodes to send transactions to by their labels, in place of Nodes
+	Selector string `json:"selector,omitempty"`
+}
+
+// Validate checks that the pattern is complete and sensible
+func (p Pattern) Validate() error {
+	if p.TPS < 0 || p.EndTPS < 0 || p.BurstTPS < 0 {
+		return fmt.Errorf("rates cannot be negative")
+	}
+	if p.TPS > MaxTPS || p.EndTPS > MaxTPS || p.BurstTPS > MaxTPS {
+		return fmt.Errorf("rates cannot be more than %d transactions per second", MaxTPS)
+	}
+	switch p.Type {
+	case "constant":
+	case "ramp":
+		if p.RampSeconds <= 0 {
+			return fmt.Errorf("a ramp must have a positive rampSeconds")
+		}
+	case "burst":
+		if p.BurstSeconds <= 0 || p.IntervalSeconds < p.BurstSeconds {
+			return fmt.Errorf("a burst must have a positive burstSeconds, which is no more than intervalSeconds")
+		}
+	default:
+		return fmt.Errorf("unknown pattern type \"%s\"", p.Type)
+	}
+	return nil
+}
+
+// Rate gets the target transactions per second at the given time since the pattern started
+func (p Pattern) Rate(elapsed time.Duration) float64 {
+	switch p.Type {
+	case "ramp":
+		progress := elapsed.Seconds() / float64(p.RampSeconds)
+		if progress >= 1 {
+			return p.EndTPS
+		}
+		return p.TPS + (p.EndTPS-p.TPS)*progress
+	case "burst":
+		if int64(elapsed.Seconds())%p.IntervalSeconds < p.BurstSeconds {
+			return p.BurstTPS
+		}
+		return p.TPS
+	}
+	return p.TPS
+}
@@ -0,0 +1,136 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package loadgen
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Percentiles summarizes a set of latencies, in milliseconds
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// Summary is the current state and results of a load generator
+type Summary struct {
+	Pattern   Pattern `json:"pattern"`
+	Running   bool    `json:"running"`
+	Started   int64   `json:"started"`
+	Stopped   int64   `json:"stopped,omitempty"`
+	Submitted int64   `json:"submitted"`
+	Failed    int64   `json:"failed"`
+	Included  int64   `json:"included"`
+	Pending   int     `json:"pending"`
+	// SubmitTPS is the rate at which transactions were accepted by the nodes
+	SubmitTPS float64 `json:"submitTps"`
+	// TPS is the rate at which the submitted transactions were included in blocks
+	TPS               float64     `json:"tps"`
+	SubmissionLatency Percentiles `json:"submissionLatency"`
+	InclusionLatency  Percentiles `json:"inclusionLatency"`
+}
+
+// recorder collects the results of a load generator
+type recorder struct {
+	mux        sync.Mutex
+	submitted  int64
+	failed     int64
+	submitLat  []time.Duration
+	includeLat []time.Duration
+	pending    map[string]time.Time
+	last       time.Time
+}
+
+func newRecorder() *recorder {
+	return &recorder{pending: map[string]time.Time{}}
+}
+
+// submit records the transactions accepted by a node in a single request, which took the given time
+func (r *recorder) submit(ids []string, failed int, at time.Time, took time.Duration) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.failed += int64(failed)
+	for _, id := range ids {
+		r.submitted++
+		r.pending[id] = at
+		r.submitLat = append(r.submitLat, took)
+	}
+}
+
+// include records that the transactions with the given ids were seen in a block at the given time
+func (r *recorder) include(ids []string, at time.Time) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, id := range ids {
+		sent, ok := r.pending[id]
+		if !ok {
+			continue
+		}
+		delete(r.pending, id)
+		r.last = at
+		r.includeLat = append(r.includeLat, at.Sub(sent))
+	}
+}
+
+// percentiles calculates the percentiles of the given latencies
+func percentiles(latencies []time.Duration) Percentiles {
+	if len(latencies) == 0 {
+		return Percentiles{}
+	}
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p float64) float64 {
+		index := int(p*float64(len(sorted))+0.5) - 1
+		if index < 0 {
+			index = 0
+		}
+		return float64(sorted[index]) / float64(time.Millisecond)
+	}
+	return Percentiles{P50: at(0.5), P90: at(0.9), P99: at(0.99), Max: at(1)}
+}
+
+// summarize fills in the results of the summary, for a run which began at start and ended at end
+func (r *recorder) summarize(out *Summary, start time.Time, end time.Time) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	out.Submitted = r.submitted
+	out.Failed = r.failed
+	out.Included = int64(len(r.includeLat))
+	out.Pending = len(r.pending)
+	if elapsed := end.Sub(start).Seconds(); elapsed > 0 {
+		out.SubmitTPS = float64(r.submitted) / elapsed
+	}
+	if window := r.last.Sub(start).Seconds(); window > 0 {
+		out.TPS = float64(len(r.includeLat)) / window
+	}
+	out.SubmissionLatency = percentiles(r.submitLat)
+	out.InclusionLatency = percentiles(r.includeLat)
+}
+
+// pendingCount gets the number of transactions which have been sent, but not yet seen in a block
+func (r *recorder) pendingCount() int {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return len(r.pending)
+}
@@ -19,7 +19,11 @@
 package main
 
 import (
+	"github.com/whiteblock/genesis/fault"
+	"github.com/whiteblock/genesis/manager"
+	"github.com/whiteblock/genesis/protocols/plugin"
 	"github.com/whiteblock/genesis/rest"
+	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/util"
 	"log"
 )
@@ -30,5 +34,9 @@ func main() {
 	util.DisplayBanner()
 	conf = util.GetConfig()
 	log.SetFlags(log.LstdFlags | log.Llongfile)
+	plugin.LoadPlugins()
+	manager.RestoreActiveTestnets()
+	fault.ResumePendingPauses()
+	status.RestoreSampling()
 	rest.StartServer()
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/deploy"
 	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/report"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
@@ -61,6 +62,12 @@ func AddNodes(details *db.DeploymentDetails, testnetID string) error {
 		}
 	}
 
+	err = validateLabels(details)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+
 	if details.Nodes > conf.MaxNodes {
 		buildState.ReportError(fmt.Errorf("too many nodes"))
 		return fmt.Errorf("too many nodes")
@@ -94,6 +101,6 @@ func AddNodes(details *db.DeploymentDetails, testnetID string) error {
 		buildState.ReportError(err)
 		return err
 	}
-
-	return nil
+	trackTestnet(tn)
+	return util.LogError(report.RefreshObserving(testnetID))
 }
@@ -20,64 +20,131 @@ package manager
 
 import (
 	"fmt"
+	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/docker"
-	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/fault"
+	"github.com/whiteblock/genesis/loadgen"
+	netem "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/report"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 )
 
-// DelNodes simply attempts to remove the given number of nodes from the
-// network.
-func DelNodes(num int, testnetID string) error {
-	//buildState := state.GetBuildStateByServerId(details.Servers[0])
-	//defer buildState.DoneBuilding()
+// selectNodesToDelete finds the nodes with the given absolute numbers, making sure that
+// at least one node is left in the network
+func selectNodesToDelete(nodes []db.Node, absNums []int) (removed []db.Node, remaining []db.Node, err error) {
+	if len(absNums) == 0 {
+		return nil, nil, fmt.Errorf("no nodes were given to remove")
+	}
+	remove := map[int]bool{}
+	for _, absNum := range absNums {
+		if remove[absNum] {
+			return nil, nil, fmt.Errorf("node %d was given more than once", absNum)
+		}
+		if _, err := db.GetNodeByAbsNum(nodes, absNum); err != nil {
+			return nil, nil, err
+		}
+		remove[absNum] = true
+	}
+	for _, node := range nodes {
+		if remove[node.AbsoluteNum] {
+			removed = append(removed, node)
+		} else {
+			remaining = append(remaining, node)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil, nil, fmt.Errorf("can't remove all the nodes in the network, delete the testnet instead")
+	}
+	return removed, remaining, nil
+}
+
+// deleteNode removes the containers and network of a node, along with its side cars
+func deleteNode(tn *testnet.TestNet, node db.Node) error {
+	client, ok := tn.Clients[node.Server]
+	if !ok {
+		return fmt.Errorf("no connection to server %d", node.Server)
+	}
+	for i := range tn.SideCars {
+		for _, sidecar := range tn.SideCars[i] {
+			if sidecar.AbsoluteNodeNum != node.AbsoluteNum {
+				continue
+			}
+			err := docker.KillSideCar(client, sidecar.LocalID, sidecar.NetworkIndex)
+			if err != nil {
+				return util.LogError(err)
+			}
+		}
+	}
 
-	nodes, err := db.GetAllNodesByTestNet(testnetID)
+	err := docker.Kill(client, node.LocalID)
 	if err != nil {
-		//buildState.ReportError(err)
 		return util.LogError(err)
 	}
 
-	if num >= len(nodes) {
-		err = fmt.Errorf("can't remove more than all the nodes in the network")
-		//buildState.ReportError(err)
-		return err
+	err = docker.NetworkDestroy(client, node.LocalID)
+	if err != nil {
+		return util.LogError(err)
 	}
+	return db.DeleteNode(node.ID)
+}
 
-	servers, err := status.GetLatestServers(testnetID)
+// DelNodes removes the nodes with the given absolute numbers from the network, along with their side cars,
+// network conditions and outages. The slots of the removed nodes are freed up for new nodes, however
+// the absolute numbers are not reused. The errors are not reported to the build state, as that would
+// trigger the cleanup of the original build.
+func DelNodes(testnetID string, absNums []int) error {
+	buildState, err := state.GetBuildStateByID(testnetID)
 	if err != nil {
-		//buildState.ReportError(err)
 		return util.LogError(err)
 	}
 
-	toRemove := num
-	for _, server := range servers {
-		client, err := status.GetClient(server.ID)
-		if err != nil {
-			//buildState.ReportError(err)
-			return util.LogError(err)
-		}
-		for i := len(server.Ips); i > 0; i++ {
-			err = docker.Kill(client, i)
-			if err != nil {
-				//buildState.ReportError(err)
-				return util.LogError(err)
-			}
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		buildState.DoneBuilding()
+		return util.LogError(err)
+	}
+	defer tn.FinishedBuilding()
 
-			err = docker.NetworkDestroy(client, i)
-			if err != nil {
-				//buildState.ReportError(err)
-				return util.LogError(err)
-			}
+	removed, remaining, err := selectNodesToDelete(tn.Nodes, absNums)
+	if err != nil {
+		return util.LogError(err)
+	}
+	tn.BuildState.SetBuildStage("Removing the nodes")
 
-			toRemove--
-			if toRemove == 0 {
-				break
-			}
-		}
-		if toRemove == 0 {
+	err = netem.RemoveNodes(removed)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = netem.RemoveNodeOutages(removed, remaining)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = fault.EndNodeFaults(removed)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	deleted := []int{}
+	for _, node := range removed {
+		err = deleteNode(tn, node)
+		if err != nil {
 			break
 		}
+		log.WithFields(log.Fields{"testnet": testnetID, "node": node.AbsoluteNum}).Info("removed a node")
+		deleted = append(deleted, node.AbsoluteNum)
+	}
+	//Only forget about the nodes which are actually gone
+	tn.RemoveNodes(deleted)
+	loadgen.RemoveNodes(testnetID, deleted)
+	trackTestnet(tn)
+	util.LogError(report.RefreshObserving(testnetID))
+	if err != nil {
+		return util.LogError(err)
 	}
 	return nil
 }
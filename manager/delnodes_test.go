@@ -0,0 +1,63 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+)
+
+func TestSelectNodesToDelete(t *testing.T) {
+	nodes := []db.Node{{AbsoluteNum: 0}, {AbsoluteNum: 1}, {AbsoluteNum: 3}}
+
+	var test = []struct {
+		absNums   []int
+		removed   []db.Node
+		remaining []db.Node
+		valid     bool
+	}{
+		{absNums: []int{1}, removed: []db.Node{nodes[1]}, remaining: []db.Node{nodes[0], nodes[2]}, valid: true},
+		{absNums: []int{3, 0}, removed: []db.Node{nodes[0], nodes[2]}, remaining: []db.Node{nodes[1]}, valid: true},
+		{absNums: []int{}, valid: false},
+		{absNums: []int{2}, valid: false},
+		{absNums: []int{1, 1}, valid: false},
+		{absNums: []int{0, 1, 3}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			removed, remaining, err := selectNodesToDelete(nodes, tt.absNums)
+			if (err == nil) != tt.valid {
+				t.Fatalf("selectNodesToDelete returned %v, expected valid to be %v", err, tt.valid)
+			}
+			if !tt.valid {
+				return
+			}
+			if !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("removed %v, expected %v", removed, tt.removed)
+			}
+			if !reflect.DeepEqual(remaining, tt.remaining) {
+				t.Errorf("remaining %v, expected %v", remaining, tt.remaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecResult is the outcome of running a command on a single node
+type ExecResult struct {
+	// Node is the absolute number of the node
+	Node int `json:"node"`
+	// ExitCode is the exit code of the command, or -1 if it could not be run
+	ExitCode int `json:"exitCode"`
+	// Error contains the reason the command could not be run, if any
+	Error string `json:"error,omitempty"`
+}
+
+// ExecRequest is a request to execute a command on some of the nodes of a testnet
+type ExecRequest struct {
+	// Command is the command to execute inside of each of the nodes
+	Command string `json:"command"`
+	// Nodes are the absolute numbers of the nodes to execute the command on, if empty,
+	// the command is executed on all of the nodes
+	Nodes []int `json:"nodes"`
+	// Selector picks out the nodes to execute the command on by their labels, in place of Nodes
+	Selector string `json:"selector,omitempty"`
+}
+
+// validateExecCommand checks that the given command may be executed. Unless admin is set, the command
+// must pass util.ValidateCommandLine.
+func validateExecCommand(command string, admin bool) error {
+	if len(strings.TrimSpace(command)) == 0 {
+		return fmt.Errorf("command cannot be empty")
+	}
+	if admin {
+		return nil
+	}
+	return util.ValidateCommandLine(command)
+}
+
+// execCommandLine wraps the given command so that it is interpreted by a shell inside of the node,
+// rather than by the shell on the host
+func execCommandLine(command string) string {
+	return "bash -c " + util.ShellQuote(command)
+}
+
+// ExecOnNodes runs the given command on the nodes of a testnet in parallel, recording each
+// execution in the audit log under the given kid. Unless admin is set, the command must pass
+// util.ValidateCommandLine. The output of each node is streamed to the writer returned by output for that node.
+func ExecOnNodes(testnetID string, req ExecRequest, kid string, admin bool, output func(node int) io.Writer) ([]ExecResult, error) {
+	err := validateExecCommand(req.Command, admin)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := selectNodes(testnetID, req.Nodes, req.Selector)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	log.WithFields(log.Fields{"testnet": testnetID, "kid": kid, "command": req.Command,
+		"nodes": len(nodes)}).Info("executing a command on the nodes")
+
+	out := make([]ExecResult, len(nodes))
+	wg := sync.WaitGroup{}
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node db.Node) {
+			defer wg.Done()
+			out[i] = execOnNode(testnetID, node, req.Command, kid, output(node.AbsoluteNum))
+		}(i, node)
+	}
+	wg.Wait()
+	return out, nil
+}
+
+func execOnNode(testnetID string, node db.Node, command string, kid string, output io.Writer) ExecResult {
+	result := ExecResult{Node: node.AbsoluteNum, ExitCode: -1}
+	start := time.Now()
+
+	client, err := status.GetClient(node.Server)
+	if err == nil {
+		result.ExitCode, err = client.DockerExecStream(node, execCommandLine(command), output)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	util.LogError(db.InsertExecAuditEntry(db.ExecAuditEntry{
+		TestNetID: testnetID,
+		Node:      node.AbsoluteNum,
+		Kid:       kid,
+		Command:   command,
+		Time:      start.UnixNano() / int64(time.Millisecond),
+		ExitCode:  result.ExitCode,
+	}))
+	return result
+}
@@ -0,0 +1,74 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_validateExecCommand(t *testing.T) {
+	var test = []struct {
+		command string
+		admin   bool
+		valid   bool
+	}{
+		{command: "ls -la /geth", admin: false, valid: true},
+		{command: "ls -la /geth", admin: true, valid: true},
+		{command: "", admin: false, valid: false},
+		{command: "  ", admin: true, valid: false},
+		{command: "ls; rm -rf /", admin: false, valid: false},
+		{command: "ls; rm -rf /", admin: true, valid: true},
+		{command: "cat /geth/* | grep block", admin: false, valid: false},
+		{command: "cat /geth/* | grep block", admin: true, valid: true},
+		{command: "echo $(id)", admin: false, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := validateExecCommand(tt.command, tt.admin)
+			if tt.valid && err != nil {
+				t.Errorf("validateExecCommand(\"%s\", %v) returned an error: %s", tt.command, tt.admin, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("validateExecCommand(\"%s\", %v) did not return an error", tt.command, tt.admin)
+			}
+		})
+	}
+}
+
+func Test_execCommandLine(t *testing.T) {
+	var test = []struct {
+		command  string
+		expected string
+	}{
+		{command: "ls -la /geth", expected: "bash -c 'ls -la /geth'"},
+		{command: "ls; rm -rf /", expected: "bash -c 'ls; rm -rf /'"},
+		{command: "echo 'hi' > /tmp/x", expected: `bash -c 'echo '\''hi'\'' > /tmp/x'`},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := execCommandLine(tt.command)
+			if out != tt.expected {
+				t.Errorf("execCommandLine(\"%s\") returned %s, expected %s", tt.command, out, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileUpload represents a file or archive to be copied into the nodes
+type FileUpload struct {
+	// Dest is the destination path inside of the node. If Tar is true, this is
+	// the directory into which the archive will be extracted.
+	Dest string
+	// Data is the contents of the file or archive
+	Data []byte
+	// Tar indicates that Data is a tar archive which should be extracted into Dest
+	Tar bool
+}
+
+// getNodesByAbsNum fetches the nodes of the testnet with the given absolute numbers.
+// If nodeNums is empty, all of the nodes in the testnet are returned.
+func getNodesByAbsNum(testnetID string, nodeNums []int) ([]db.Node, error) {
+	nodes, err := db.GetAllNodesByTestNet(testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("testnet %s has no nodes", testnetID)
+	}
+	if len(nodeNums) == 0 {
+		return nodes, nil
+	}
+	out := []db.Node{}
+	for _, num := range nodeNums {
+		node, err := db.GetNodeByAbsNum(nodes, num)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, node)
+	}
+	return out, nil
+}
+
+// selectNodes fetches the nodes of the testnet which match the given selector, or if there isn't a
+// selector, those with the given absolute numbers
+func selectNodes(testnetID string, nodeNums []int, selector string) ([]db.Node, error) {
+	if len(selector) == 0 {
+		return getNodesByAbsNum(testnetID, nodeNums)
+	}
+	if len(nodeNums) > 0 {
+		return nil, fmt.Errorf("cannot give both nodes and a selector")
+	}
+	nodes, err := db.GetAllNodesByTestNet(testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return db.ResolveNodes(nodes, selector)
+}
+
+func copyUploadToNode(client ssh.Client, buildState *state.BuildState, node ssh.Node, upload FileUpload) error {
+	if upload.Tar {
+		_, err := client.DockerExec(node, fmt.Sprintf("mkdir -p %s", upload.Dest))
+		if err != nil {
+			return util.LogError(err)
+		}
+		return helpers.SingleCpArchive(client, buildState, node, upload.Data, upload.Dest)
+	}
+	_, err := client.DockerExec(node, fmt.Sprintf("mkdir -p %s", filepath.Dir(upload.Dest)))
+	if err != nil {
+		return util.LogError(err)
+	}
+	return helpers.SingleCp(client, buildState, node, upload.Data, upload.Dest)
+}
+
+// CopyToNodes copies the given uploads into the running nodes of a testnet. nodeNums are
+// the absolute numbers of the nodes to copy the files into, if empty, the files are copied into every node.
+// The caller is responsible for marking the build state of the testnet as done.
+func CopyToNodes(testnetID string, nodeNums []int, uploads []FileUpload) error {
+	for _, upload := range uploads {
+		err := util.ValidateNodePath(upload.Dest)
+		if err != nil {
+			return err
+		}
+	}
+	nodes, err := getNodesByAbsNum(testnetID, nodeNums)
+	if err != nil {
+		return util.LogError(err)
+	}
+	log.WithFields(log.Fields{"testnet": testnetID, "nodes": len(nodes), "files": len(uploads)}).Info("copying files into the nodes")
+
+	buildState, err := state.GetBuildStateByID(testnetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	wg := sync.WaitGroup{}
+	errChan := make(chan error, len(nodes)*len(uploads))
+	for _, node := range nodes {
+		client, err := status.GetClient(node.Server)
+		if err != nil {
+			return util.LogError(err)
+		}
+		for _, upload := range uploads {
+			wg.Add(1)
+			go func(node db.Node, upload FileUpload) {
+				defer wg.Done()
+				errChan <- copyUploadToNode(client, buildState, node, upload)
+			}(node, upload)
+		}
+	}
+	wg.Wait()
+	close(errChan)
+	for err := range errChan {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tmpFileReader is a temporary file which is removed once it is closed
+type tmpFileReader struct {
+	*os.File
+}
+
+func (tfr tmpFileReader) Close() error {
+	defer os.Remove(tfr.Name())
+	return tfr.File.Close()
+}
+
+// DownloadFromNode fetches the file or directory at the given path in a node, as a tar archive. The archive
+// is streamed into a temporary file, which is removed when the returned reader is closed. Fails if the archive
+// is larger than maxDownloadSize.
+func DownloadFromNode(testnetID string, nodeNum int, path string) (io.ReadCloser, error) {
+	err := util.ValidateNodePath(path)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := getNodesByAbsNum(testnetID, []int{nodeNum})
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	node := nodes[0]
+	client, err := status.GetClient(node.Server)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+
+	tmpFile, err := ioutil.TempFile("", "genesis-download")
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := tmpFileReader{tmpFile}
+	err = client.DockerCpFrom(node, path, out, conf.MaxDownloadSize)
+	if err == nil {
+		_, err = out.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		out.Close()
+		return nil, util.LogError(err)
+	}
+	return out, nil
+}
@@ -0,0 +1,303 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultStopTimeout is how long a process is given to exit after SIGTERM, before it is killed
+	defaultStopTimeout = 10 * time.Second
+	// killTimeout is how long a process is given to exit after SIGKILL
+	killTimeout = 5 * time.Second
+	// stopPollInterval is how often a stopping process is checked on
+	stopPollInterval = 250 * time.Millisecond
+)
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LifecycleRequest describes how to stop, start or restart the main process of a node or one of its side cars
+type LifecycleRequest struct {
+	// SideCar is the name of the side car of the node to act on, instead of the node itself
+	SideCar string `json:"sidecar,omitempty"`
+	// Timeout is the number of seconds the process is given to exit after SIGTERM, before it
+	// is killed with SIGKILL. Defaults to 10.
+	Timeout int `json:"timeout,omitempty"`
+	// Command replaces the command the process was last started with
+	Command string `json:"command,omitempty"`
+	// Args are appended to the command the process was last started with
+	Args string `json:"args,omitempty"`
+	// Env holds environment variables to add to those the process was last started with
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// ChangesCommand checks if the request modifies the command the process is started with, which
+// allows anything to be run in the node
+func (req LifecycleRequest) ChangesCommand() bool {
+	return len(req.Command) > 0 || len(req.Args) > 0 || len(req.Env) > 0
+}
+
+// ProcessStatus is the state of the main process of a node or side car
+type ProcessStatus struct {
+	// Name is the name of the container the process runs in
+	Name string `json:"name"`
+	// Running is whether the process, or any of its children, is still running
+	Running bool `json:"running"`
+	// Command is the command the process was last started with
+	Command string `json:"command"`
+	// Env holds the environment variables the process was last started with
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// validateStart checks the modifications to the command which are made when the process is started
+func (req LifecycleRequest) validateStart() error {
+	if req.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	if strings.Contains(req.Command, "'") || strings.Contains(req.Args, "'") {
+		return fmt.Errorf("the command cannot contain ' characters")
+	}
+	for key, value := range req.Env {
+		if !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid environment variable name \"%s\"", key)
+		}
+		if strings.Contains(value, "'") {
+			return fmt.Errorf("the value of %s cannot contain ' characters", key)
+		}
+	}
+	return nil
+}
+
+func (req LifecycleRequest) stopTimeout() time.Duration {
+	if req.Timeout == 0 {
+		return defaultStopTimeout
+	}
+	return time.Duration(req.Timeout) * time.Second
+}
+
+// modify applies the requested modifications to the command
+func (req LifecycleRequest) modify(cmd util.Command) util.Command {
+	if len(req.Command) > 0 {
+		cmd.Cmdline = req.Command
+	}
+	if len(req.Args) > 0 {
+		cmd.Cmdline += " " + req.Args
+	}
+	if len(req.Env) > 0 {
+		env := map[string]string{}
+		for key, value := range cmd.Env {
+			env[key] = value
+		}
+		for key, value := range req.Env {
+			env[key] = value
+		}
+		cmd.Env = env
+	}
+	return cmd
+}
+
+// getProcessTarget finds the node, or the side car of the node, whose main process is to be acted on
+func getProcessTarget(testnetID string, absNum int, sidecar string) (ssh.Client, ssh.Node, *state.BuildState, error) {
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return nil, nil, nil, util.LogError(err)
+	}
+	node, err := db.GetNodeByAbsNum(tn.Nodes, absNum)
+	if err != nil {
+		return nil, nil, nil, util.LogError(err)
+	}
+	client, err := status.GetClient(node.Server)
+	if err != nil {
+		return nil, nil, nil, util.LogError(err)
+	}
+	if len(sidecar) == 0 {
+		return client, node, tn.BuildState, nil
+	}
+	sc, err := tn.GetNodesSideCar(node, sidecar)
+	if err != nil {
+		return nil, nil, nil, util.LogError(err)
+	}
+	return client, *sc, tn.BuildState, nil
+}
+
+// waitForExit waits up to timeout for the main process to exit, returning whether it did
+func waitForExit(client ssh.Client, node ssh.Node, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		running, err := ssh.MainProcessRunning(client, node)
+		if err != nil {
+			return false, util.LogError(err)
+		}
+		if !running {
+			return true, nil
+		}
+		if time.Now().After(deadline) {
+			return false, nil
+		}
+		time.Sleep(stopPollInterval)
+	}
+}
+
+// stopProcess sends the given signal to the main process, killing it if it does not exit within the timeout
+func stopProcess(client ssh.Client, node ssh.Node, signal string, timeout time.Duration) error {
+	running, err := ssh.MainProcessRunning(client, node)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if !running {
+		return nil
+	}
+	log.WithFields(log.Fields{"node": node.GetNodeName(), "signal": signal,
+		"timeout": timeout}).Info("stopping the main process")
+	err = ssh.SignalMainProcess(client, node, signal)
+	if err != nil {
+		return util.LogError(err)
+	}
+	exited, err := waitForExit(client, node, timeout)
+	if err != nil || exited {
+		return err
+	}
+	log.WithFields(log.Fields{"node": node.GetNodeName()}).Warn("main process did not exit in time, killing it")
+	err = ssh.SignalMainProcess(client, node, "KILL")
+	if err != nil {
+		return util.LogError(err)
+	}
+	exited, err = waitForExit(client, node, killTimeout)
+	if err != nil {
+		return err
+	}
+	if !exited {
+		return fmt.Errorf("the main process of %s could not be killed", node.GetNodeName())
+	}
+	return nil
+}
+
+func startProcess(client ssh.Client, node ssh.Node, bs *state.BuildState, req LifecycleRequest) error {
+	running, err := ssh.MainProcessRunning(client, node)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if running {
+		return fmt.Errorf("the main process of %s is already running", node.GetNodeName())
+	}
+	var cmd util.Command
+	if !bs.GetP(ssh.CommandKey(node), &cmd) {
+		if len(req.Command) == 0 {
+			return fmt.Errorf("no command has been recorded for %s, one must be given", node.GetNodeName())
+		}
+	}
+	cmd = req.modify(cmd)
+	log.WithFields(log.Fields{"node": node.GetNodeName(), "command": cmd.Cmdline}).Info("starting the main process")
+	return client.DockerExecdLogCommand(node, cmd, true)
+}
+
+// StopNode gracefully stops the main process of a node or side car, killing it if it does not exit within the timeout
+func StopNode(testnetID string, absNum int, req LifecycleRequest) error {
+	if req.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	client, node, _, err := getProcessTarget(testnetID, absNum, req.SideCar)
+	if err != nil {
+		return err
+	}
+	return stopProcess(client, node, "TERM", req.stopTimeout())
+}
+
+// KillNode interrupts the main process of a node or side car with SIGINT, killing it if it does not exit within the timeout
+func KillNode(testnetID string, absNum int, req LifecycleRequest) error {
+	if req.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	client, node, _, err := getProcessTarget(testnetID, absNum, req.SideCar)
+	if err != nil {
+		return err
+	}
+	return stopProcess(client, node, "INT", req.stopTimeout())
+}
+
+// StartNode starts the main process of a node or side car with the command it was last started with, along with any
+// of the requested modifications. The modified command is kept for later restarts.
+func StartNode(testnetID string, absNum int, req LifecycleRequest) error {
+	err := req.validateStart()
+	if err != nil {
+		return err
+	}
+	client, node, bs, err := getProcessTarget(testnetID, absNum, req.SideCar)
+	if err != nil {
+		return err
+	}
+	return startProcess(client, node, bs, req)
+}
+
+// RestartNode stops and then starts the main process of a node or side car
+func RestartNode(testnetID string, absNum int, req LifecycleRequest) error {
+	err := req.validateStart()
+	if err != nil {
+		return err
+	}
+	client, node, bs, err := getProcessTarget(testnetID, absNum, req.SideCar)
+	if err != nil {
+		return err
+	}
+	err = stopProcess(client, node, "TERM", req.stopTimeout())
+	if err != nil {
+		return err
+	}
+	return startProcess(client, node, bs, req)
+}
+
+// SignalNode sends a signal to the main process of a node or side car, along with all of its children
+func SignalNode(testnetID string, absNum int, sidecar string, signal string) error {
+	err := util.ValidateCommandLine(signal)
+	if err != nil {
+		return err
+	}
+	client, node, _, err := getProcessTarget(testnetID, absNum, sidecar)
+	if err != nil {
+		return err
+	}
+	return util.LogError(ssh.SignalMainProcess(client, node, signal))
+}
+
+// GetProcessStatus reports on the main process of a node or side car
+func GetProcessStatus(testnetID string, absNum int, sidecar string) (ProcessStatus, error) {
+	client, node, bs, err := getProcessTarget(testnetID, absNum, sidecar)
+	if err != nil {
+		return ProcessStatus{}, err
+	}
+	out := ProcessStatus{Name: node.GetNodeName()}
+	var cmd util.Command
+	if bs.GetP(ssh.CommandKey(node), &cmd) {
+		out.Command = cmd.Cmdline
+		out.Env = cmd.Env
+	}
+	out.Running, err = ssh.MainProcessRunning(client, node)
+	return out, util.LogError(err)
+}
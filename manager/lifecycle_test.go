@@ -0,0 +1,111 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/util"
+)
+
+func TestLifecycleRequest_validateStart(t *testing.T) {
+	var test = []struct {
+		req   LifecycleRequest
+		valid bool
+	}{
+		{req: LifecycleRequest{}, valid: true},
+		{req: LifecycleRequest{Args: "--verbosity 5", Env: map[string]string{"RUST_LOG": "debug"}}, valid: true},
+		{req: LifecycleRequest{Timeout: -1}, valid: false},
+		{req: LifecycleRequest{Command: "geth --datadir '/geth'"}, valid: false},
+		{req: LifecycleRequest{Env: map[string]string{"1FOO": "bar"}}, valid: false},
+		{req: LifecycleRequest{Env: map[string]string{"FOO BAR": "bar"}}, valid: false},
+		{req: LifecycleRequest{Env: map[string]string{"FOO": "it's"}}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.req.validateStart()
+			if (err == nil) != tt.valid {
+				t.Errorf("validateStart returned %v, expected valid to be %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestLifecycleRequest_ChangesCommand(t *testing.T) {
+	var test = []struct {
+		req      LifecycleRequest
+		expected bool
+	}{
+		{req: LifecycleRequest{}, expected: false},
+		{req: LifecycleRequest{SideCar: "orion", Timeout: 30}, expected: false},
+		{req: LifecycleRequest{Command: "geth --datadir /geth"}, expected: true},
+		{req: LifecycleRequest{Args: "--verbosity 5"}, expected: true},
+		{req: LifecycleRequest{Env: map[string]string{"RUST_LOG": "debug"}}, expected: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if tt.req.ChangesCommand() != tt.expected {
+				t.Errorf("ChangesCommand returned %v, expected %v", !tt.expected, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLifecycleRequest_modify(t *testing.T) {
+	var test = []struct {
+		req      LifecycleRequest
+		cmd      util.Command
+		expected util.Command
+	}{
+		{
+			req:      LifecycleRequest{},
+			cmd:      util.Command{Cmdline: "geth --nodiscover", ServerID: 1},
+			expected: util.Command{Cmdline: "geth --nodiscover", ServerID: 1},
+		},
+		{
+			req:      LifecycleRequest{Args: "--verbosity 5"},
+			cmd:      util.Command{Cmdline: "geth --nodiscover"},
+			expected: util.Command{Cmdline: "geth --nodiscover --verbosity 5"},
+		},
+		{
+			req:      LifecycleRequest{Command: "parity", Args: "--no-discovery"},
+			cmd:      util.Command{Cmdline: "geth --nodiscover"},
+			expected: util.Command{Cmdline: "parity --no-discovery"},
+		},
+		{
+			req: LifecycleRequest{Env: map[string]string{"B": "3", "C": "4"}},
+			cmd: util.Command{Cmdline: "node", Env: map[string]string{"A": "1", "B": "2"}},
+			expected: util.Command{Cmdline: "node",
+				Env: map[string]string{"A": "1", "B": "3", "C": "4"}},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := tt.req.modify(tt.cmd)
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("modify returned %+v, expected %+v", out, tt.expected)
+			}
+		})
+	}
+}
@@ -26,21 +26,28 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/loadgen"
+	"github.com/whiteblock/genesis/metrics"
 	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/plugin"
 	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/report"
+	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
-	"sync"
+	"time"
 	//Put the relative path to your blockchain/sidecar library below this line, otherwise it won't be compiled
 	//blockchains
 	_ "github.com/whiteblock/genesis/protocols/artemis"
 	_ "github.com/whiteblock/genesis/protocols/beam"
 	_ "github.com/whiteblock/genesis/protocols/cosmos"
+	_ "github.com/whiteblock/genesis/protocols/custom"
 	_ "github.com/whiteblock/genesis/protocols/eos"
 	_ "github.com/whiteblock/genesis/protocols/geth"
 	_ "github.com/whiteblock/genesis/protocols/libp2p-test"
 	_ "github.com/whiteblock/genesis/protocols/lighthouse"
 	_ "github.com/whiteblock/genesis/protocols/lodestar"
+	_ "github.com/whiteblock/genesis/protocols/mixedeth"
 	_ "github.com/whiteblock/genesis/protocols/pantheon"
 	_ "github.com/whiteblock/genesis/protocols/parity"
 	_ "github.com/whiteblock/genesis/protocols/plumtree"
@@ -64,6 +71,14 @@ func init() {
 // AddTestNet implements the build command. All blockchains Build command must be
 // implemented here, other it will not be called during the build process.
 func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
+	start := time.Now()
+	err := addTestNet(details, testnetID)
+	metrics.BuildsTotal.Inc(details.Blockchain, metrics.Outcome(err))
+	metrics.BuildDuration.Observe(time.Since(start).Seconds(), details.Blockchain, metrics.Outcome(err))
+	return err
+}
+
+func addTestNet(details *db.DeploymentDetails, testnetID string) error {
 	if details.Servers == nil || len(details.Servers) == 0 {
 		log.WithFields(log.Fields{"build": testnetID}).Error("build request doesn't have any servers")
 		return fmt.Errorf("missing servers")
@@ -103,6 +118,7 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 		tn.BuildState.ReportError(err)
 		return err
 	}
+	untrackReplacedTestnets(tn.Servers)
 	log.WithFields(log.Fields{"build": testnetID}).Trace("Built the docker containers")
 
 	buildFn, err := registrar.GetBuildFunc(details.Blockchain)
@@ -110,8 +126,8 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 		buildState.ReportError(err)
 		return err
 	}
-	sidecars, err := registrar.GetBlockchainSideCars(tn.LDD.Blockchain)
-	if err == nil && len(sidecars) > 0 {
+	sidecars := registrar.GetDeploymentSideCars(tn.LDD)
+	if len(sidecars) > 0 {
 		tn.BuildState.SetSidecars(len(sidecars))
 	}
 
@@ -121,18 +137,12 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 		return err
 	}
 
-	if err == nil && len(sidecars) > 0 {
+	if len(sidecars) > 0 {
 		tn.BuildState.SetBuildStage("setting up the sidecars")
-		steps := 0
-		for _, sidecarName := range sidecars {
-			sidecar, err := registrar.GetSideCar(sidecarName)
-			if err != nil {
-				buildState.ReportError(err)
-				return err
-			}
-			if sidecar.BuildStepsCalc != nil {
-				steps += sidecar.BuildStepsCalc(tn.LDD.Nodes, len(tn.Servers))
-			}
+		steps, err := getSideCarSteps(tn, sidecars)
+		if err != nil {
+			buildState.ReportError(err)
+			return err
 		}
 		tn.BuildState.SetSidecarSteps(steps)
 		tn.BuildState.FinishMainBuild()
@@ -154,40 +164,13 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 		buildState.ReportError(err)
 		return err
 	}
-	return nil
-}
-
-func handleSideCars(tn *testnet.TestNet, append bool) error {
-	sidecars, err := registrar.GetBlockchainSideCars(tn.LDD.Blockchain)
-	if err != nil || sidecars == nil || len(sidecars) == 0 {
-		return nil //Not an error, just means that the blockchain doesn't have any sidecars
-	}
-	wg := sync.WaitGroup{}
-	wg.Add(len(sidecars))
-	for i, sidecar := range sidecars { //In future, should probably check all the sidecars before running any builds
-		var buildFn func(*testnet.Adjunct) error
-		if append {
-			buildFn, err = registrar.GetAddSideCar(sidecar)
-		} else {
-			buildFn, err = registrar.GetBuildSideCar(sidecar)
-		}
-
-		if err != nil {
-			return util.LogError(err)
-		}
-		ad, err := tn.SpawnAdjunct(append, i)
-		if err != nil {
-			return util.LogError(err)
-		}
-		go func(i int) {
-			defer wg.Done()
-			err := buildFn(ad)
-			if err != nil {
-				tn.BuildState.ReportError(err)
-			}
-		}(i)
-	}
-	wg.Wait()
+	trackTestnet(tn)
+	if conf.ResourceSampleInterval > 0 {
+		util.LogError(status.StartSampling(testnetID, time.Duration(conf.ResourceSampleInterval)*time.Second))
+	}
+	if conf.BlockObserveInterval > 0 && report.IsSupported(details.Blockchain) {
+		util.LogError(report.StartObserving(testnetID, time.Duration(conf.BlockObserveInterval)*time.Millisecond))
+	}
 	return nil
 }
 
@@ -211,23 +194,63 @@ func declareTestnet(testnetID string, details *db.DeploymentDetails) error {
 
 // DeleteTestNet destroys all of the nodes of a testnet
 func DeleteTestNet(testnetID string) error {
+	status.StopSampling(testnetID)
+	loadgen.Forget(testnetID)
+	util.LogError(db.DeleteResourceSamples(testnetID))
+	report.ForgetObservations(testnetID)
 	tn, err := testnet.RestoreTestNet(testnetID)
 	if err != nil {
 		return util.LogError(err)
 	}
-
+	untrackReplacedTestnets(tn.Servers)
 	return deploy.Destroy(tn)
 }
 
-// GetParams fetches the name and type of each available
-// blockchain specific parameter for the given blockchain.
-// Ensure that the blockchain you have implemented is included
-// in the switch statement.
-func GetParams(blockchain string) ([]byte, error) {
+// GetParamSchema fetches the schema of each available blockchain specific
+// parameter for the given blockchain.
+func GetParamSchema(blockchain string) ([]ParamSchema, error) {
+	schema, _, err := getParamSchema(blockchain)
+	return schema, err
+}
+
+// getParamSchema is GetParamSchema, which also returns whether the blockchain rejects unknown params
+func getParamSchema(blockchain string) ([]ParamSchema, bool, error) {
 	if blockchain == "ethereum" {
-		return GetParams("geth")
+		return getParamSchema("geth")
+	}
+	data, err := helpers.GetStaticBlockchainConfig(blockchain, "params.json")
+	if err != nil {
+		var ok bool
+		data, ok = plugin.GetParams(blockchain)
+		if !ok {
+			return nil, false, err
+		}
+	}
+	return parseParamSchemaFile(data)
+}
+
+// GetParams fetches the schema of each available blockchain specific
+// parameter for the given blockchain, as json.
+func GetParams(blockchain string) ([]byte, error) {
+	schema, err := GetParamSchema(blockchain)
+	if err != nil {
+		return nil, err
 	}
-	return helpers.GetStaticBlockchainConfig(blockchain, "params.json")
+	return json.Marshal(schema)
+}
+
+// GetParamPairs fetches the name and type of each available blockchain specific
+// parameter for the given blockchain, in the legacy [name, type] format.
+func GetParamPairs(blockchain string) ([]byte, error) {
+	schema, err := GetParamSchema(blockchain)
+	if err != nil {
+		return nil, err
+	}
+	out := [][]string{}
+	for _, param := range schema {
+		out = append(out, []string{param.Name, param.Type})
+	}
+	return json.Marshal(out)
 }
 
 // GetDefaults gets the default parameters for a blockchain. Ensure that
@@ -235,7 +258,15 @@ func GetParams(blockchain string) ([]byte, error) {
 // statement.
 func GetDefaults(blockchain string) ([]byte, error) {
 	if blockchain == "ethereum" {
-		return GetParams("geth")
+		return GetDefaults("geth")
+	}
+	data, err := helpers.GetStaticBlockchainConfig(blockchain, "defaults.json")
+	if err != nil {
+		var ok bool
+		data, ok = plugin.GetDefaults(blockchain)
+		if !ok {
+			return nil, err
+		}
 	}
-	return helpers.GetStaticBlockchainConfig(blockchain, "defaults.json")
+	return data, nil
 }
@@ -0,0 +1,115 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/loadgen"
+	"github.com/whiteblock/genesis/metrics"
+	"github.com/whiteblock/genesis/report"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+)
+
+// activeTestnetsKey is the meta key under which the active testnets are stored, so that
+// the gauges survive a restart
+const activeTestnetsKey = "active_testnets"
+
+type activeTestnet struct {
+	Servers []int `json:"servers"`
+	Nodes   int   `json:"nodes"`
+}
+
+var (
+	activeTestnets   = map[string]activeTestnet{}
+	activeTestnetMux = sync.Mutex{}
+)
+
+// setActiveGauges sets the active testnet and node gauges from activeTestnets.
+// activeTestnetMux must be held by the caller.
+func setActiveGauges() {
+	nodes := 0
+	for _, tn := range activeTestnets {
+		nodes += tn.Nodes
+	}
+	metrics.ActiveTestnets.Set(float64(len(activeTestnets)))
+	metrics.ActiveNodes.Set(float64(nodes))
+}
+
+// updateActiveGauges recalculates the active testnet and node gauges and stores the active
+// testnets. activeTestnetMux must be held by the caller.
+func updateActiveGauges() {
+	setActiveGauges()
+	db.DeleteMeta(activeTestnetsKey)
+	util.LogError(db.SetMeta(activeTestnetsKey, activeTestnets))
+}
+
+// RestoreActiveTestnets seeds the active testnet and node gauges from the testnets which were
+// active when genesis last stopped
+func RestoreActiveTestnets() {
+	activeTestnetMux.Lock()
+	defer activeTestnetMux.Unlock()
+	stored := map[string]activeTestnet{}
+	err := db.GetMetaP(activeTestnetsKey, &stored)
+	if err != nil {
+		return //nothing has been built yet
+	}
+	activeTestnets = stored
+	setActiveGauges()
+}
+
+// trackTestnet records the given testnet as active, or updates its node count if it already is
+func trackTestnet(tn *testnet.TestNet) {
+	activeTestnetMux.Lock()
+	defer activeTestnetMux.Unlock()
+	servers := []int{}
+	for _, server := range tn.Servers {
+		servers = append(servers, server.ID)
+	}
+	activeTestnets[tn.TestNetID] = activeTestnet{Servers: servers, Nodes: len(tn.Nodes)}
+	updateActiveGauges()
+}
+
+// untrackReplacedTestnets stops tracking any testnet which was on one of the given servers, since
+// a new build or a teardown removes everything on those servers
+func untrackReplacedTestnets(servers []db.Server) {
+	activeTestnetMux.Lock()
+	defer activeTestnetMux.Unlock()
+	for testnetID, tn := range activeTestnets {
+		for _, serverID := range tn.Servers {
+			replaced := false
+			for _, server := range servers {
+				if server.ID == serverID {
+					replaced = true
+					break
+				}
+			}
+			if replaced {
+				delete(activeTestnets, testnetID)
+				report.ForgetObservations(testnetID)
+				status.StopSampling(testnetID) //the names of its nodes now belong to the new testnet
+				loadgen.Forget(testnetID)
+				break
+			}
+		}
+	}
+	updateActiveGauges()
+}
@@ -0,0 +1,255 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// globalParams are the params which are handled outside of the blockchain implementations,
+// and so are allowed for every blockchain
+var globalParams = []string{"prometheusInstrumentationPort", "logFolder"}
+
+// ParamSchema describes a single blockchain specific parameter
+type ParamSchema struct {
+	// Name is the key of the parameter in the params object
+	Name string `json:"name"`
+	// Type is the type of the value, one of int, int64, float, string, bool, []string or object
+	Type string `json:"type"`
+	// Description is a human readable explanation of the parameter
+	Description string `json:"description,omitempty"`
+	// Min is the minimum allowed value of a numeric parameter
+	Min *float64 `json:"min,omitempty"`
+	// Max is the maximum allowed value of a numeric parameter
+	Max *float64 `json:"max,omitempty"`
+	// Enum is the set of allowed values, if non-empty
+	Enum []interface{} `json:"enum,omitempty"`
+	// Required indicates that the parameter must be given
+	Required bool `json:"required,omitempty"`
+	// Dependencies are the other parameters which must be given when this parameter is given
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// ParamError is a validation failure of a single parameter
+type ParamError struct {
+	Param   string `json:"param"`
+	Message string `json:"message"`
+}
+
+// ParamErrors is the collection of the validation failures of a set of params
+type ParamErrors []ParamError
+
+func (pe ParamErrors) Error() string {
+	msgs := []string{}
+	for _, err := range pe {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", err.Param, err.Message))
+	}
+	return "invalid params: " + strings.Join(msgs, "; ")
+}
+
+// paramSchemaFile is the object form of a params.json file, which allows a blockchain to opt into
+// having params which are not in its schema or defaults rejected
+type paramSchemaFile struct {
+	Strict bool            `json:"strict"`
+	Params json.RawMessage `json:"params"`
+}
+
+// ParseParamSchema parses the contents of a params.json file. Both the rich format, a list of
+// objects matching ParamSchema, and the legacy format, a list of [name, type] pairs, are accepted.
+// Either list may also be wrapped in an object as {"strict": true, "params": [...]}.
+func ParseParamSchema(data []byte) ([]ParamSchema, error) {
+	schema, _, err := parseParamSchemaFile(data)
+	return schema, err
+}
+
+// parseParamSchemaFile is ParseParamSchema, which also returns whether the schema is strict
+func parseParamSchemaFile(data []byte) ([]ParamSchema, bool, error) {
+	var file paramSchemaFile
+	if json.Unmarshal(data, &file) == nil && file.Params != nil {
+		schema, err := parseParamSchemaList(file.Params)
+		return schema, file.Strict, err
+	}
+	schema, err := parseParamSchemaList(data)
+	return schema, false, err
+}
+
+func parseParamSchemaList(data []byte) ([]ParamSchema, error) {
+	var raw []json.RawMessage
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+	out := []ParamSchema{}
+	for i, entry := range raw {
+		var pair []string
+		if json.Unmarshal(entry, &pair) == nil {
+			if len(pair) != 2 {
+				return nil, fmt.Errorf("param %d: expected a [name, type] pair", i)
+			}
+			out = append(out, ParamSchema{Name: pair[0], Type: pair[1]})
+			continue
+		}
+		var schema ParamSchema
+		err = json.Unmarshal(entry, &schema)
+		if err != nil {
+			return nil, fmt.Errorf("param %d: %s", i, err.Error())
+		}
+		if len(schema.Name) == 0 {
+			return nil, fmt.Errorf("param %d: missing name", i)
+		}
+		out = append(out, schema)
+	}
+	return out, nil
+}
+
+// toFloat converts a json number into a float64
+func toFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// checkType checks that the given value is of the type in the schema
+func (ps ParamSchema) checkType(val interface{}) error {
+	switch ps.Type {
+	case "int", "int64":
+		f, ok := toFloat(val)
+		if !ok || f != math.Trunc(f) {
+			return fmt.Errorf("expected an integer, got %v", val)
+		}
+	case "float", "float64", "number":
+		if _, ok := toFloat(val); !ok {
+			return fmt.Errorf("expected a number, got %v", val)
+		}
+	case "bool":
+		if _, ok := val.(bool); !ok {
+			return fmt.Errorf("expected a boolean, got %v", val)
+		}
+	case "string":
+		switch val.(type) {
+		case string, json.Number: //large numbers, such as balances, are commonly given unquoted
+		default:
+			return fmt.Errorf("expected a string, got %v", val)
+		}
+	case "[]string":
+		arr, ok := val.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected a list of strings, got %v", val)
+		}
+		for _, elem := range arr {
+			if _, ok := elem.(string); !ok {
+				return fmt.Errorf("expected a list of strings, but it contains %v", elem)
+			}
+		}
+	case "object":
+		if _, ok := val.(map[string]interface{}); !ok {
+			return fmt.Errorf("expected an object, got %v", val)
+		}
+	}
+	return nil
+}
+
+// check validates a given value against the schema
+func (ps ParamSchema) check(val interface{}) error {
+	err := ps.checkType(val)
+	if err != nil {
+		return err
+	}
+	if f, ok := toFloat(val); ok {
+		if ps.Min != nil && f < *ps.Min {
+			return fmt.Errorf("must be at least %v", *ps.Min)
+		}
+		if ps.Max != nil && f > *ps.Max {
+			return fmt.Errorf("must be at most %v", *ps.Max)
+		}
+	}
+	if len(ps.Enum) > 0 {
+		for _, allowed := range ps.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(val) {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %v", ps.Enum)
+	}
+	return nil
+}
+
+// validateParams checks the given params against the schema. If strict is set, keys which are not in the schema,
+// in the known keys or in globalParams are rejected.
+func validateParams(params map[string]interface{}, schema []ParamSchema, strict bool, known []string) error {
+	errs := ParamErrors{}
+	bySchema := map[string]ParamSchema{}
+	for _, ps := range schema {
+		bySchema[ps.Name] = ps
+	}
+	allowed := map[string]bool{}
+	for _, key := range append(known, globalParams...) {
+		allowed[key] = true
+	}
+
+	keys := []string{}
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ps, ok := bySchema[key]
+		if !ok {
+			if strict && !allowed[key] {
+				errs = append(errs, ParamError{Param: key, Message: "unknown parameter"})
+			}
+			continue
+		}
+		if params[key] == nil {
+			continue
+		}
+		err := ps.check(params[key])
+		if err != nil {
+			errs = append(errs, ParamError{Param: key, Message: err.Error()})
+		}
+		for _, dep := range ps.Dependencies {
+			if _, ok := params[dep]; !ok {
+				errs = append(errs, ParamError{Param: key, Message: fmt.Sprintf("requires %s to also be given", dep)})
+			}
+		}
+	}
+	for _, ps := range schema {
+		if _, ok := params[ps.Name]; ps.Required && !ok {
+			errs = append(errs, ParamError{Param: ps.Name, Message: "is required"})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
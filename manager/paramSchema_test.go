@@ -0,0 +1,229 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseParamSchema(t *testing.T) {
+	min := float64(1)
+	var test = []struct {
+		data      string
+		expected  []ParamSchema
+		expectErr bool
+	}{
+		{
+			data:     `[["networkId","int"],["initBalance","string"]]`,
+			expected: []ParamSchema{{Name: "networkId", Type: "int"}, {Name: "initBalance", Type: "string"}},
+		},
+		{
+			data: `[{"name":"networkId","type":"int","description":"the network id","min":1,"required":true},["maxPeers","int"]]`,
+			expected: []ParamSchema{
+				{Name: "networkId", Type: "int", Description: "the network id", Min: &min, Required: true},
+				{Name: "maxPeers", Type: "int"},
+			},
+		},
+		{
+			data:     `[]`,
+			expected: []ParamSchema{},
+		},
+		{data: `[["networkId"]]`, expectErr: true},
+		{data: `[{"type":"int"}]`, expectErr: true},
+		{
+			data:     `{"strict":true,"params":[["networkId","int"]]}`,
+			expected: []ParamSchema{{Name: "networkId", Type: "int"}},
+		},
+		{data: `{"networkId":"int"}`, expectErr: true},
+		{data: `{"strict":true,"params":{"networkId":"int"}}`, expectErr: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			schema, err := ParseParamSchema([]byte(tt.data))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error result: %v", err)
+			}
+			if !tt.expectErr && !reflect.DeepEqual(schema, tt.expected) {
+				t.Errorf("return value of ParseParamSchema %+v does not match expected value %+v", schema, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_validateParams(t *testing.T) {
+	min := float64(0)
+	max := float64(100)
+	schema := []ParamSchema{
+		{Name: "networkId", Type: "int", Required: true},
+		{Name: "maxPeers", Type: "int", Min: &min, Max: &max},
+		{Name: "initBalance", Type: "string"},
+		{Name: "consensus", Type: "string", Enum: []interface{}{"ethash", "clique"}},
+		{Name: "blockPeriodSeconds", Type: "int", Dependencies: []string{"consensus"}},
+		{Name: "mine", Type: "bool"},
+		{Name: "options", Type: "[]string"},
+		{Name: "templates", Type: "object"},
+	}
+
+	var test = []struct {
+		params   string
+		schema   []ParamSchema
+		strict   bool
+		expected []string
+	}{
+		{
+			params:   `{"networkId":15468,"maxPeers":50,"initBalance":1000000000000000000000,"consensus":"clique","blockPeriodSeconds":5}`,
+			schema:   schema,
+			strict:   true,
+			expected: nil,
+		},
+		{
+			params:   `{"networkId":15468,"mine":true,"options":["a","b"],"logFolder":"/tmp","extra":1}`,
+			schema:   schema,
+			strict:   true,
+			expected: []string{"extra"},
+		},
+		{
+			params:   `{"maxPeers":150}`,
+			schema:   schema,
+			strict:   true,
+			expected: []string{"maxPeers", "networkId"},
+		},
+		{
+			params:   `{"networkId":"15468","gaslimit":4000000}`,
+			schema:   schema,
+			strict:   true,
+			expected: []string{"gaslimit", "networkId"},
+		},
+		{
+			params:   `{"networkId":1.5,"consensus":"pow","blockPeriodSeconds":5,"mine":"yes","options":[1]}`,
+			schema:   schema[:6],
+			strict:   true,
+			expected: []string{"consensus", "mine", "networkId", "options"},
+		},
+		{
+			params:   `{"networkId":1,"blockPeriodSeconds":5}`,
+			schema:   schema,
+			strict:   true,
+			expected: []string{"blockPeriodSeconds"},
+		},
+		{
+			params:   `{"networkId":1,"templates":{"/etc/node.conf":"ip={{ip}}"}}`,
+			schema:   schema,
+			strict:   true,
+			expected: nil,
+		},
+		{
+			params:   `{"networkId":1,"templates":["ip={{ip}}"]}`,
+			schema:   schema,
+			strict:   true,
+			expected: []string{"templates"},
+		},
+		{
+			params:   `{"anything":1}`,
+			schema:   []ParamSchema{},
+			expected: nil,
+		},
+		{
+			params:   `{"networkId":15468,"outputFile":"/tmp/log.json"}`,
+			schema:   schema,
+			strict:   false,
+			expected: nil,
+		},
+		{
+			params:   `{"outputFile":"/tmp/log.json","maxPeers":-1}`,
+			schema:   schema,
+			strict:   false,
+			expected: []string{"maxPeers", "networkId"},
+		},
+		{
+			params:   `{}`,
+			schema:   schema,
+			strict:   false,
+			expected: []string{"networkId"},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var params map[string]interface{}
+			decoder := json.NewDecoder(strings.NewReader(tt.params))
+			decoder.UseNumber()
+			err := decoder.Decode(&params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = validateParams(params, tt.schema, tt.strict, []string{"extraAccounts"})
+			if tt.expected == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			paramErrs, ok := err.(ParamErrors)
+			if !ok {
+				t.Fatalf("expected ParamErrors, got %v", err)
+			}
+			failed := []string{}
+			for _, paramErr := range paramErrs {
+				failed = append(failed, paramErr.Param)
+			}
+			if !reflect.DeepEqual(failed, tt.expected) {
+				t.Errorf("params which failed %v do not match expected %v: %v", failed, tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestBundledParamSchemasAreStrict(t *testing.T) {
+	resourceDir := conf.ResourceDir
+	conf.ResourceDir = "../resources"
+	defer func() { conf.ResourceDir = resourceDir }()
+
+	//rchain passes its params through to its configuration, so any param is accepted
+	var test = []struct {
+		blockchain string
+		strict     bool
+	}{
+		{blockchain: "artemis", strict: true},
+		{blockchain: "eos", strict: true},
+		{blockchain: "geth", strict: true},
+		{blockchain: "lighthouse", strict: true},
+		{blockchain: "mixedeth", strict: true},
+		{blockchain: "prysm", strict: true},
+		{blockchain: "tendermint", strict: true},
+		{blockchain: "rchain", strict: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, strict, err := getParamSchema(tt.blockchain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strict != tt.strict {
+				t.Errorf("expected the schema of %s to have strict %v", tt.blockchain, tt.strict)
+			}
+		})
+	}
+}
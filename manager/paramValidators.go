@@ -19,6 +19,7 @@
 package manager
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/util"
@@ -56,6 +57,42 @@ func validateImages(details *db.DeploymentDetails) error {
 	return nil
 }
 
+func validateLabels(details *db.DeploymentDetails) error {
+	if len(details.Labels) > details.Nodes {
+		return fmt.Errorf("given labels for %d nodes, but there are only %d nodes", len(details.Labels), details.Nodes)
+	}
+	for i, labels := range details.Labels {
+		for _, label := range labels {
+			err := util.ValidateLabel(label)
+			if err != nil {
+				return fmt.Errorf("%s. For node %d", err.Error(), i)
+			}
+		}
+	}
+	return nil
+}
+
+// topologyBlockchains are the blockchains which give their nodes peers generated from the topology
+var topologyBlockchains = map[string]bool{
+	"geth":     true,
+	"pantheon": true,
+	"mixedeth": true,
+}
+
+func validateTopology(details *db.DeploymentDetails) error {
+	if details.Topology == nil {
+		return nil
+	}
+	if !topologyBlockchains[details.Blockchain] {
+		return fmt.Errorf("%s does not support a topology", details.Blockchain)
+	}
+	_, err := util.GenerateTopology(*details.Topology, details.Nodes)
+	if err != nil {
+		return fmt.Errorf("invalid topology: %s", err.Error())
+	}
+	return nil
+}
+
 func validateBlockchain(details *db.DeploymentDetails) error {
 	err := util.ValidateCommandLine(details.Blockchain)
 	if err != nil {
@@ -64,6 +101,28 @@ func validateBlockchain(details *db.DeploymentDetails) error {
 	return nil
 }
 
+func validateBlockchainParams(details *db.DeploymentDetails) error {
+	schema, strict, err := getParamSchema(details.Blockchain)
+	if err != nil {
+		return nil //Blockchains without a params schema cannot be checked
+	}
+	params := details.Params
+	if params == nil {
+		params = map[string]interface{}{} //the required params must still be checked
+	}
+	known := []string{}
+	rawDefaults, err := GetDefaults(details.Blockchain)
+	if err == nil {
+		var defaults map[string]interface{}
+		if json.Unmarshal(rawDefaults, &defaults) == nil {
+			for key := range defaults {
+				known = append(known, key)
+			}
+		}
+	}
+	return validateParams(params, schema, strict, known)
+}
+
 func checkForNilOrMissing(details *db.DeploymentDetails) error {
 	if details.Servers == nil {
 		return fmt.Errorf("servers cannot be null")
@@ -109,5 +168,25 @@ func validate(details *db.DeploymentDetails) error {
 		return util.LogError(err)
 	}
 
-	return validateBlockchain(details)
+	err = validateBlockchain(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateSideCars(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateLabels(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateTopology(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	return validateBlockchainParams(details)
 }
@@ -252,6 +252,107 @@ func Test_validateBlockchain(t *testing.T) {
 	}
 }
 
+func Test_validateLabels(t *testing.T) {
+	var test = []struct {
+		details  *db.DeploymentDetails
+		expected error
+	}{
+		{
+			details:  &db.DeploymentDetails{Nodes: 2, Labels: [][]string{{"validators", "region=eu"}, {}}},
+			expected: nil,
+		},
+		{
+			details:  &db.DeploymentDetails{Nodes: 1, Labels: [][]string{{"validators"}, {"observers"}}},
+			expected: errors.New("given labels for 2 nodes, but there are only 1 nodes"),
+		},
+		{
+			details:  &db.DeploymentDetails{Nodes: 2, Labels: [][]string{{"validators"}, {"region=eu,asia"}}},
+			expected: errors.New("invalid value for label \"region=eu,asia\". For node 1"),
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if !reflect.DeepEqual(validateLabels(tt.details), tt.expected) {
+				t.Errorf("validateLabels returned %v, expected %v", validateLabels(tt.details), tt.expected)
+			}
+		})
+	}
+}
+
+func Test_validateTopology(t *testing.T) {
+	var test = []struct {
+		details  *db.DeploymentDetails
+		expected error
+	}{
+		{details: &db.DeploymentDetails{Blockchain: "geth", Nodes: 3}, expected: nil},
+		{details: &db.DeploymentDetails{Blockchain: "tendermint", Nodes: 3}, expected: nil},
+		{
+			details:  &db.DeploymentDetails{Blockchain: "geth", Nodes: 3, Topology: &util.Topology{Type: util.TopologyRing}},
+			expected: nil,
+		},
+		{
+			details:  &db.DeploymentDetails{Blockchain: "mixedeth", Nodes: 3, Topology: &util.Topology{Type: util.TopologyRing}},
+			expected: nil,
+		},
+		{
+			details:  &db.DeploymentDetails{Blockchain: "pantheon", Nodes: 3, Topology: &util.Topology{Type: util.TopologyStar, Center: 3}},
+			expected: errors.New("invalid topology: the center 3 is not one of the 3 nodes"),
+		},
+		{
+			details:  &db.DeploymentDetails{Blockchain: "geth", Nodes: 3, Topology: &util.Topology{Type: "tree"}},
+			expected: errors.New("invalid topology: unknown topology \"tree\""),
+		},
+		{
+			details:  &db.DeploymentDetails{Blockchain: "tendermint", Nodes: 3, Topology: &util.Topology{Type: util.TopologyRing}},
+			expected: errors.New("tendermint does not support a topology"),
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if !reflect.DeepEqual(validateTopology(tt.details), tt.expected) {
+				t.Errorf("validateTopology returned %v, expected %v", validateTopology(tt.details), tt.expected)
+			}
+		})
+	}
+}
+
+func Test_validateBlockchainParams(t *testing.T) {
+	resourceDir := conf.ResourceDir
+	conf.ResourceDir = "../resources"
+	defer func() { conf.ResourceDir = resourceDir }()
+
+	var test = []struct {
+		details *db.DeploymentDetails
+		valid   bool
+	}{
+		{details: &db.DeploymentDetails{Blockchain: "geth"}, valid: true},
+		{details: &db.DeploymentDetails{Blockchain: "geth", Params: map[string]interface{}{"networkId": 15468}}, valid: true},
+		{details: &db.DeploymentDetails{Blockchain: "ethereum", Params: map[string]interface{}{"networkID": 15468}}, valid: false},
+		{details: &db.DeploymentDetails{Blockchain: "geth", Params: map[string]interface{}{"networkId": "one"}}, valid: false},
+		{
+			details: &db.DeploymentDetails{Blockchain: "artemis",
+				Params: map[string]interface{}{"outputFile": "/artemis/log.json", "providerType": "JSON"}},
+			valid: true,
+		},
+		{details: &db.DeploymentDetails{Blockchain: "artemis", Params: map[string]interface{}{"validators": "a"}}, valid: false},
+		{details: &db.DeploymentDetails{Blockchain: "nonexistent", Params: map[string]interface{}{"a": 1}}, valid: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := validateBlockchainParams(tt.details)
+			if tt.valid && err != nil {
+				t.Errorf("validateBlockchainParams returned an error: %s", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("validateBlockchainParams did not return an error")
+			}
+		})
+	}
+}
+
 func Test_checkForNilOrMissing(t *testing.T) {
 	var test = []struct {
 		details  *db.DeploymentDetails
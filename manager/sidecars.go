@@ -0,0 +1,184 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"strings"
+	"sync"
+)
+
+// validateSideCars checks the sidecars requested in the deployment details
+func validateSideCars(details *db.DeploymentDetails) error {
+	for _, sidecar := range details.SideCars {
+		if len(sidecar.Name) == 0 {
+			return fmt.Errorf("side cars must have a name")
+		}
+		err := util.ValidateCommandLine(sidecar.Name)
+		if err != nil {
+			return err
+		}
+		if !registrar.IsRegisteredSideCar(sidecar.Name) && len(sidecar.Image) == 0 {
+			return fmt.Errorf("side car \"%s\" is not registered, so it must be given an image", sidecar.Name)
+		}
+		err = util.ValidateCommandLine(sidecar.Image)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(sidecar.Command, "'") {
+			return fmt.Errorf("the command of side car \"%s\" cannot contain ' characters", sidecar.Name)
+		}
+		for _, node := range sidecar.Nodes {
+			if node < 0 || node >= details.Nodes {
+				return fmt.Errorf("side car \"%s\" is attached to node %d, which does not exist", sidecar.Name, node)
+			}
+		}
+		err = sidecar.Resources.ValidateAndSetDefaults()
+		if err != nil {
+			return fmt.Errorf("%s. For side car \"%s\"", err.Error(), sidecar.Name)
+		}
+		for path, data := range sidecar.Files {
+			err = util.ValidateNodePath(path)
+			if err != nil {
+				return err
+			}
+			_, err = base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return fmt.Errorf("file \"%s\" of side car \"%s\" is not valid base64", path, sidecar.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// getSideCarSteps calculates the number of build steps the given sidecars will take
+func getSideCarSteps(tn *testnet.TestNet, sidecars []db.SideCarSpec) (int, error) {
+	steps := 0
+	for i, spec := range sidecars {
+		if !registrar.IsRegisteredSideCar(spec.Name) {
+			if i < len(tn.SideCars) {
+				steps += len(tn.SideCars[i])
+			}
+			continue
+		}
+		sidecar, err := registrar.GetSideCar(spec.Name)
+		if err != nil {
+			return 0, util.LogError(err)
+		}
+		if sidecar.BuildStepsCalc != nil {
+			steps += sidecar.BuildStepsCalc(tn.LDD.Nodes, len(tn.Servers))
+		}
+	}
+	return steps, nil
+}
+
+func handleSideCars(tn *testnet.TestNet, append bool) error {
+	sidecars := registrar.GetDeploymentSideCars(tn.LDD)
+	if len(sidecars) == 0 {
+		return nil //Not an error, just means that there aren't any sidecars
+	}
+	wg := sync.WaitGroup{}
+	for i, sidecar := range sidecars { //In future, should probably check all the sidecars before running any builds
+		if i >= len(tn.SideCars) {
+			continue //Not attached to any of the nodes
+		}
+		sidecar := sidecar
+		var buildFn func(*testnet.Adjunct) error
+		var err error
+		if !registrar.IsRegisteredSideCar(sidecar.Name) {
+			buildFn = func(ad *testnet.Adjunct) error {
+				return buildAdHocSideCar(ad, sidecar, append)
+			}
+		} else if append {
+			buildFn, err = registrar.GetAddSideCar(sidecar.Name)
+		} else {
+			buildFn, err = registrar.GetBuildSideCar(sidecar.Name)
+		}
+		if err != nil {
+			return util.LogError(err)
+		}
+
+		ad, err := tn.SpawnAdjunct(append, i)
+		if err != nil {
+			return util.LogError(err)
+		}
+		if len(ad.Nodes) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(sidecar db.SideCarSpec) {
+			defer wg.Done()
+			err := copySideCarFiles(ad, sidecar, append)
+			if err == nil {
+				err = buildFn(ad)
+			}
+			if err != nil {
+				tn.BuildState.ReportError(err)
+			}
+		}(sidecar)
+	}
+	wg.Wait()
+	return nil
+}
+
+// sideCarExec runs fn on each instance of the sidecar, or only on the new instances if newOnly is set
+func sideCarExec(ad *testnet.Adjunct, newOnly bool, fn func(ssh.Client, *db.Server, ssh.Node) error) error {
+	if newOnly {
+		return helpers.AllNewNodeExecConSC(ad, fn)
+	}
+	return helpers.AllNodeExecConSC(ad, fn)
+}
+
+// copySideCarFiles places the files given for a sidecar into each of its instances
+func copySideCarFiles(ad *testnet.Adjunct, sidecar db.SideCarSpec, newOnly bool) error {
+	if len(sidecar.Files) == 0 {
+		return nil
+	}
+	return sideCarExec(ad, newOnly, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		for path, data := range sidecar.Files {
+			raw, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return util.LogError(err)
+			}
+			err = helpers.SingleCp(client, ad.BuildState, node, raw, path)
+			if err != nil {
+				return util.LogError(err)
+			}
+		}
+		return nil
+	})
+}
+
+// buildAdHocSideCar starts the command of a sidecar which was not registered
+func buildAdHocSideCar(ad *testnet.Adjunct, sidecar db.SideCarSpec, newOnly bool) error {
+	return sideCarExec(ad, newOnly, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		defer ad.BuildState.IncrementSideCarProgress()
+		if len(sidecar.Command) == 0 {
+			return nil
+		}
+		return client.DockerExecdLog(node, sidecar.Command)
+	})
+}
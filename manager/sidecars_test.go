@@ -0,0 +1,71 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+)
+
+func Test_validateSideCars(t *testing.T) {
+	var test = []struct {
+		sidecars []db.SideCarSpec
+		valid    bool
+	}{
+		{sidecars: nil, valid: true},
+		{
+			sidecars: []db.SideCarSpec{{Name: "exporter", Image: "prom/node-exporter", Nodes: []int{0, 2}}},
+			valid:    true,
+		},
+		{
+			sidecars: []db.SideCarSpec{{Name: "exporter", Image: "prom/node-exporter",
+				Files: map[string]string{"/etc/exporter.yml": "bGV2ZWw6IGluZm8K"}}},
+			valid: true,
+		},
+		{sidecars: []db.SideCarSpec{{Image: "prom/node-exporter"}}, valid: false},
+		{sidecars: []db.SideCarSpec{{Name: "exporter"}}, valid: false},
+		{sidecars: []db.SideCarSpec{{Name: "exporter;rm", Image: "prom/node-exporter"}}, valid: false},
+		{
+			sidecars: []db.SideCarSpec{{Name: "exporter", Image: "prom/node-exporter", Command: "node_exporter --web.listen-address=:9100"}},
+			valid:    true,
+		},
+		{
+			sidecars: []db.SideCarSpec{{Name: "exporter", Image: "prom/node-exporter", Command: "sh -c 'node_exporter'"}},
+			valid:    false,
+		},
+		{sidecars: []db.SideCarSpec{{Name: "exporter", Image: "prom/node-exporter", Nodes: []int{3}}}, valid: false},
+		{sidecars: []db.SideCarSpec{{Name: "exporter", Image: "prom/node-exporter", Nodes: []int{-1}}}, valid: false},
+		{
+			sidecars: []db.SideCarSpec{{Name: "exporter", Image: "prom/node-exporter",
+				Files: map[string]string{"/etc/exporter.yml": "not base64!"}}},
+			valid: false,
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := validateSideCars(&db.DeploymentDetails{Nodes: 3, SideCars: tt.sidecars})
+			if (err == nil) != tt.valid {
+				t.Errorf("validateSideCars returned %v, expected valid to be %v", err, tt.valid)
+			}
+		})
+	}
+}
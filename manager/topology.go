@@ -0,0 +1,46 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+// AppliedTopology is the peer graph which was given to the nodes of a testnet, along with the topology
+// it was generated from
+type AppliedTopology struct {
+	util.Topology
+
+	// Peers contains the absolute numbers of the peers of each node, indexed by absolute number
+	Peers [][]int `json:"peers"`
+}
+
+// GetTopology gets the peer graph which was given to the nodes of a testnet when it was built
+func GetTopology(testnetID string) (AppliedTopology, error) {
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return AppliedTopology{}, util.LogError(err)
+	}
+	if tn.Topology == nil {
+		return AppliedTopology{}, fmt.Errorf("the nodes of testnet %s were not given their peers", testnetID)
+	}
+	return AppliedTopology{Topology: *tn.Topology, Peers: tn.Peers}, nil
+}
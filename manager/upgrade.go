@@ -0,0 +1,326 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/docker"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	upgrades   = map[string]*UpgradeStatus{}
+	upgradeMux = sync.Mutex{}
+
+	// volumeNamePattern matches the names docker allows for named volumes
+	volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+)
+
+// UpgradeRequest is a request to replace the image of some of the nodes of a running testnet, while keeping
+// their ip address, data and identity
+type UpgradeRequest struct {
+	// Image is the image to run the nodes from
+	Image string `json:"image"`
+	// Nodes are the absolute numbers of the nodes to upgrade, in order. If empty, all of the nodes are upgraded.
+	Nodes []int `json:"nodes,omitempty"`
+	// Selector picks out the nodes to upgrade by their labels, in place of Nodes
+	Selector string `json:"selector,omitempty"`
+	// Delay is the number of seconds to wait between upgrading each node
+	Delay int `json:"delay,omitempty"`
+	// Timeout is the number of seconds each node is given to exit gracefully before it is killed. Defaults to 10.
+	Timeout int `json:"timeout,omitempty"`
+	// Command replaces the command the nodes were last started with
+	Command string `json:"command,omitempty"`
+	// Args are appended to the command the nodes were last started with
+	Args string `json:"args,omitempty"`
+	// Env holds environment variables to add to those the nodes were last started with
+	Env map[string]string `json:"env,omitempty"`
+	// Preserve are paths within the nodes which are not on a volume, but should be carried over to the new containers,
+	// such as generated keys or configuration files
+	Preserve []string `json:"preserve,omitempty"`
+	// DiscardData allows nodes without any volumes to be upgraded, losing the data which was not preserved
+	DiscardData bool `json:"discardData,omitempty"`
+}
+
+// UpgradeStatus is the progress of an upgrade
+type UpgradeStatus struct {
+	// Image is the image the nodes are being upgraded to
+	Image string `json:"image"`
+	// Nodes are the nodes being upgraded
+	Nodes []int `json:"nodes"`
+	// Upgraded are the nodes which have been upgraded so far
+	Upgraded []int `json:"upgraded"`
+	// Current is the node currently being upgraded, or -1 if there isn't one
+	Current int `json:"current"`
+	// Finished is whether the upgrade is over, either because it completed or because it failed
+	Finished bool `json:"finished"`
+	// Error is the reason the upgrade failed, if it did
+	Error string `json:"error,omitempty"`
+}
+
+func (req UpgradeRequest) validate() error {
+	if len(req.Image) == 0 {
+		return fmt.Errorf("image cannot be empty")
+	}
+	err := util.ValidateCommandLine(req.Image)
+	if err != nil {
+		return err
+	}
+	if req.Delay < 0 {
+		return fmt.Errorf("delay cannot be negative")
+	}
+	for _, p := range req.Preserve {
+		err = util.ValidateNodePath(p)
+		if err != nil {
+			return err
+		}
+	}
+	return req.lifecycle().validateStart()
+}
+
+// ChangesCommand checks if the request modifies the command the nodes are started with
+func (req UpgradeRequest) ChangesCommand() bool {
+	return req.lifecycle().ChangesCommand()
+}
+
+func (req UpgradeRequest) lifecycle() LifecycleRequest {
+	return LifecycleRequest{Timeout: req.Timeout, Command: req.Command, Args: req.Args, Env: req.Env}
+}
+
+// getNodeBuildConfig gets the resources and environment a node was built with, from the deployment which
+// added it to the testnet
+func getNodeBuildConfig(tn *testnet.TestNet, absNum int) (util.Resources, map[string]string) {
+	offset := 0
+	for _, dd := range tn.Details {
+		if absNum >= offset+dd.Nodes {
+			offset += dd.Nodes
+			continue
+		}
+		index := absNum - offset
+		resources := util.Resources{}
+		if len(dd.Resources) > index {
+			resources = dd.Resources[index]
+		} else if len(dd.Resources) > 0 {
+			resources = dd.Resources[0]
+		}
+		var env map[string]string
+		if len(dd.Environments) > index {
+			env = dd.Environments[index]
+		}
+		return resources, env
+	}
+	return util.Resources{}, nil
+}
+
+// hasPersistentVolume checks if any of the given volumes outlives the container, by being mounted from a
+// named volume or a directory on the host. Anonymous volumes are removed along with the container.
+func hasPersistentVolume(volumes []string) bool {
+	for _, volume := range volumes {
+		parts := strings.Split(volume, ":")
+		if len(parts) < 2 || len(parts[1]) == 0 {
+			continue
+		}
+		if path.IsAbs(parts[0]) || volumeNamePattern.MatchString(parts[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpgradeNodes starts replacing the image of the requested nodes one at a time, in the background.
+// Each node is stopped, has its container recreated from the new image on the same network, and is
+// started again with its stored command. Its data must be on one of its volumes to survive.
+func UpgradeNodes(testnetID string, req UpgradeRequest) error {
+	err := req.validate()
+	if err != nil {
+		return err
+	}
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	nodes, err := selectNodes(testnetID, req.Nodes, req.Selector)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("the testnet has no nodes")
+	}
+	for _, node := range nodes {
+		res, _ := getNodeBuildConfig(tn, node.AbsoluteNum)
+		if !hasPersistentVolume(res.Volumes) && !req.DiscardData {
+			return fmt.Errorf("node %d does not have its data on a volume, set discardData to upgrade it anyway",
+				node.AbsoluteNum)
+		}
+	}
+
+	upgradeMux.Lock()
+	defer upgradeMux.Unlock()
+	if prev, ok := upgrades[testnetID]; ok && !prev.Finished {
+		return fmt.Errorf("an upgrade is already in progress on this testnet")
+	}
+	if !tn.BuildState.Done() {
+		return fmt.Errorf("there is a build in progress")
+	}
+	//hold the build state until the upgrade is finished, so that nodes cannot be added or removed meanwhile
+	tn.BuildState.Reset()
+	upgradeStatus := &UpgradeStatus{Image: req.Image, Nodes: []int{}, Upgraded: []int{}, Current: -1}
+	for _, node := range nodes {
+		upgradeStatus.Nodes = append(upgradeStatus.Nodes, node.AbsoluteNum)
+	}
+	upgrades[testnetID] = upgradeStatus
+
+	go func() {
+		defer tn.BuildState.DoneBuilding()
+		err := rollingUpgrade(tn, nodes, req, upgradeStatus)
+		upgradeMux.Lock()
+		defer upgradeMux.Unlock()
+		upgradeStatus.Finished = true
+		upgradeStatus.Current = -1
+		if err != nil {
+			upgradeStatus.Error = err.Error()
+		}
+	}()
+	return nil
+}
+
+// GetUpgradeStatus gets the progress of the latest upgrade of a testnet
+func GetUpgradeStatus(testnetID string) (UpgradeStatus, error) {
+	upgradeMux.Lock()
+	defer upgradeMux.Unlock()
+	upgradeStatus, ok := upgrades[testnetID]
+	if !ok {
+		return UpgradeStatus{}, fmt.Errorf("no upgrades have been done on this testnet")
+	}
+	return *upgradeStatus, nil
+}
+
+func rollingUpgrade(tn *testnet.TestNet, nodes []db.Node, req UpgradeRequest, upgradeStatus *UpgradeStatus) error {
+	for i, node := range nodes {
+		if i > 0 && req.Delay > 0 {
+			time.Sleep(time.Duration(req.Delay) * time.Second)
+		}
+		upgradeMux.Lock()
+		upgradeStatus.Current = node.AbsoluteNum
+		upgradeMux.Unlock()
+
+		log.WithFields(log.Fields{"testnet": tn.TestNetID, "node": node.AbsoluteNum, "image": req.Image}).Info("upgrading a node")
+		err := upgradeNode(tn, node, req)
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": tn.TestNetID, "node": node.AbsoluteNum, "error": err}).Error("upgrade failed")
+			return fmt.Errorf("failed to upgrade node %d: %s", node.AbsoluteNum, err.Error())
+		}
+
+		upgradeMux.Lock()
+		upgradeStatus.Upgraded = append(upgradeStatus.Upgraded, node.AbsoluteNum)
+		upgradeMux.Unlock()
+	}
+	return nil
+}
+
+// upgradeNode replaces the container of a single node
+func upgradeNode(tn *testnet.TestNet, node db.Node, req UpgradeRequest) error {
+	client, ok := tn.Clients[node.Server]
+	if !ok {
+		return fmt.Errorf("missing the client for server %d", node.Server)
+	}
+	server := tn.GetServer(node.Server)
+	if server == nil {
+		return fmt.Errorf("missing server %d", node.Server)
+	}
+	preserve := req.Preserve
+	if conf.HandleNodeSSHKeys {
+		preserve = append([]string{"/root/.ssh"}, preserve...)
+	}
+
+	err := stopProcess(client, node, "TERM", req.lifecycle().stopTimeout())
+	if err != nil {
+		return err
+	}
+	tmpDir := fmt.Sprintf("/tmp/%s_upgrade", node.GetNodeName())
+	_, err = client.Run(fmt.Sprintf("rm -rf %s && mkdir -p %s", tmpDir, tmpDir))
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer client.Run(fmt.Sprintf("rm -rf %s", tmpDir))
+	for i, p := range preserve {
+		_, err = client.Run(fmt.Sprintf("docker cp %s:%s %s/%d", node.GetNodeName(), p, tmpDir, i))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+
+	err = docker.Kill(client, node.LocalID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	resources, env := getNodeBuildConfig(tn, node.AbsoluteNum)
+	node.Image = req.Image
+	err = docker.Run(tn, server.ID, docker.NewNodeContainer(&node, env, resources, server.SubnetID))
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	for i, p := range preserve {
+		_, err = client.DockerExec(node, fmt.Sprintf("bash -c 'rm -rf %s && mkdir -p %s'", p, path.Dir(p)))
+		if err != nil {
+			return util.LogError(err)
+		}
+		err = client.DockerCp(node, fmt.Sprintf("%s/%d", tmpDir, i), p)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	if conf.HandleNodeSSHKeys {
+		_, err = client.DockerExecd(node, "service ssh start")
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+
+	err = startProcess(client, node, tn.BuildState, req.lifecycle())
+	if err != nil {
+		return err
+	}
+	return storeNodeImage(tn, node.AbsoluteNum, req.Image)
+}
+
+// storeNodeImage records the new image of a node in the stored testnet
+func storeNodeImage(tn *testnet.TestNet, absNum int, image string) error {
+	//the snapshot taken when the upgrade started may be out of date by now
+	current, err := testnet.RestoreTestNet(tn.TestNetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	for i := range current.Nodes {
+		if current.Nodes[i].AbsoluteNum == absNum {
+			current.Nodes[i].Image = image
+			return current.Store()
+		}
+	}
+	return fmt.Errorf("node %d not found", absNum)
+}
@@ -0,0 +1,112 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+func TestUpgradeRequest_validate(t *testing.T) {
+	var test = []struct {
+		req   UpgradeRequest
+		valid bool
+	}{
+		{req: UpgradeRequest{Image: "ethereum/client-go:v1.9.0"}, valid: true},
+		{req: UpgradeRequest{Image: "gcr.io/whiteblock/geth:fork", Delay: 30, Preserve: []string{"/geth/keystore"}}, valid: true},
+		{req: UpgradeRequest{}, valid: false},
+		{req: UpgradeRequest{Image: "geth;rm -rf /"}, valid: false},
+		{req: UpgradeRequest{Image: "geth", Delay: -1}, valid: false},
+		{req: UpgradeRequest{Image: "geth", Preserve: []string{"geth/keystore"}}, valid: false},
+		{req: UpgradeRequest{Image: "geth", Preserve: []string{"/"}}, valid: false},
+		{req: UpgradeRequest{Image: "geth", Env: map[string]string{"A B": "c"}}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.req.validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("validate returned %v, expected valid to be %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func Test_getNodeBuildConfig(t *testing.T) {
+	small := util.Resources{Cpus: "1", Memory: "1gb", Volumes: []string{"data0:/geth"}}
+	large := util.Resources{Cpus: "4", Memory: "8gb", Volumes: []string{"data1:/geth"}}
+	tn := &testnet.TestNet{Details: []db.DeploymentDetails{
+		{Nodes: 2, Resources: []util.Resources{small, large},
+			Environments: []map[string]string{{"A": "0"}, {"A": "1"}}},
+		{Nodes: 3, Resources: []util.Resources{large}},
+	}}
+
+	var test = []struct {
+		absNum    int
+		resources util.Resources
+		env       map[string]string
+	}{
+		{absNum: 0, resources: small, env: map[string]string{"A": "0"}},
+		{absNum: 1, resources: large, env: map[string]string{"A": "1"}},
+		{absNum: 2, resources: large, env: nil},
+		{absNum: 4, resources: large, env: nil},
+		{absNum: 5, resources: util.Resources{}, env: nil},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			resources, env := getNodeBuildConfig(tn, tt.absNum)
+			if !reflect.DeepEqual(resources, tt.resources) {
+				t.Errorf("expected resources %+v, got %+v", tt.resources, resources)
+			}
+			if !reflect.DeepEqual(env, tt.env) {
+				t.Errorf("expected env %v, got %v", tt.env, env)
+			}
+		})
+	}
+}
+
+func Test_hasPersistentVolume(t *testing.T) {
+	var test = []struct {
+		volumes  []string
+		expected bool
+	}{
+		{volumes: nil, expected: false},
+		{volumes: []string{"/geth"}, expected: false},
+		{volumes: []string{"data0:/geth"}, expected: true},
+		{volumes: []string{"/mnt/data0:/geth:rw"}, expected: true},
+		{volumes: []string{"/var/log", "data0:/geth"}, expected: true},
+		{volumes: []string{"./data0:/geth"}, expected: false},
+		{volumes: []string{"data0:"}, expected: false},
+		{volumes: []string{":/geth"}, expected: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if hasPersistentVolume(tt.volumes) != tt.expected {
+				t.Errorf("hasPersistentVolume(%v) returned %v, expected %v", tt.volumes, !tt.expected, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics
+
+var (
+	// BuildsTotal counts the finished builds by blockchain and outcome
+	BuildsTotal = NewCounter("genesis_builds_total",
+		"Total number of finished builds", "blockchain", "outcome")
+
+	// BuildDuration tracks how long builds take, by blockchain and outcome
+	BuildDuration = NewHistogram("genesis_build_duration_seconds",
+		"Duration of builds in seconds", nil, "blockchain", "outcome")
+
+	// BuildStageDuration tracks how long each build stage takes
+	BuildStageDuration = NewHistogram("genesis_build_stage_duration_seconds",
+		"Duration of each build stage in seconds", nil, "stage")
+
+	// SSHCommandsTotal counts the commands run on each server
+	SSHCommandsTotal = NewCounter("genesis_ssh_commands_total",
+		"Total number of commands run over ssh", "server")
+
+	// SSHCommandErrors counts the commands which failed on each server
+	SSHCommandErrors = NewCounter("genesis_ssh_command_errors_total",
+		"Total number of commands run over ssh which failed", "server")
+
+	// SSHCommandDuration tracks the latency of commands run on each server
+	SSHCommandDuration = NewHistogram("genesis_ssh_command_duration_seconds",
+		"Duration of commands run over ssh in seconds", nil, "server")
+
+	// SSHSessionsActive is the number of ssh sessions currently held on each server
+	SSHSessionsActive = NewGauge("genesis_ssh_sessions_active",
+		"Number of ssh sessions currently in use", "server")
+
+	// SSHSessionsLimit is the maximum number of ssh sessions allowed on each server
+	SSHSessionsLimit = NewGauge("genesis_ssh_sessions_limit",
+		"Maximum number of concurrent ssh sessions (maxConnections)", "server")
+
+	// ActiveTestnets is the number of testnets which are currently deployed
+	ActiveTestnets = NewGauge("genesis_active_testnets",
+		"Number of testnets currently deployed")
+
+	// ActiveNodes is the number of nodes which are currently deployed
+	ActiveNodes = NewGauge("genesis_active_nodes",
+		"Number of nodes currently deployed")
+
+	// NetworkOperations counts the netem and outage operations, by operation and outcome
+	NetworkOperations = NewCounter("genesis_network_operations_total",
+		"Total number of network emulation and outage operations", "operation", "outcome")
+)
+
+// Outcome converts an error into the outcome label value
+func Outcome(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
@@ -0,0 +1,276 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package metrics keeps track of the internal metrics of genesis, and exposes them
+// in the prometheus text exposition format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	counterType   = "counter"
+	gaugeType     = "gauge"
+	histogramType = "histogram"
+)
+
+// DefaultBuckets are the default histogram buckets, in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+var (
+	families = []*family{}
+	regMux   = sync.Mutex{}
+)
+
+type series struct {
+	labels  []string
+	value   float64
+	buckets []uint64
+	count   uint64
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mux    sync.Mutex
+	series map[string]*series
+}
+
+func register(name string, help string, kind string, buckets []float64, labels []string) *family {
+	f := &family{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  map[string]*series{},
+	}
+	regMux.Lock()
+	defer regMux.Unlock()
+	families = append(families, f)
+	return f
+}
+
+// get fetches the series with the given label values, creating it if it doesn't exist.
+// f.mux must be held by the caller.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: append([]string{}, values...)}
+		if f.kind == histogramType {
+			s.buckets = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) add(v float64, values []string) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.get(values).value += v
+}
+
+func (f *family) set(v float64, values []string) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.get(values).value = v
+}
+
+func formatLabels(names []string, values []string, extra ...string) string {
+	pairs := []string{}
+	for i := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", names[i], strconv.Quote(values[i])))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", extra[i], strconv.Quote(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func (f *family) write(w io.Writer) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+	if err != nil {
+		return err
+	}
+	keys := []string{}
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != histogramType {
+			_, err = fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(f.labels, s.labels), formatFloat(s.value))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		for i, bound := range f.buckets {
+			_, err = fmt.Fprintf(w, "%s_bucket%s %d\n", f.name,
+				formatLabels(f.labels, s.labels, "le", formatFloat(bound)), s.buckets[i])
+			if err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			f.name, formatLabels(f.labels, s.labels, "le", "+Inf"), s.count,
+			f.name, formatLabels(f.labels, s.labels), formatFloat(s.value),
+			f.name, formatLabels(f.labels, s.labels), s.count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Counter is a metric which only ever increases, partitioned by its labels
+type Counter struct {
+	f *family
+}
+
+// NewCounter creates and registers a new counter with the given label names
+func NewCounter(name string, help string, labels ...string) *Counter {
+	return &Counter{f: register(name, help, counterType, nil, labels)}
+}
+
+// Inc increments the counter with the given label values by 1
+func (c *Counter) Inc(values ...string) {
+	c.f.add(1, values)
+}
+
+// Add increases the counter with the given label values by v, v must not be negative
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		panic("counters cannot decrease")
+	}
+	c.f.add(v, values)
+}
+
+// Gauge is a metric which can arbitrarily go up and down, partitioned by its labels
+type Gauge struct {
+	f *family
+}
+
+// NewGauge creates and registers a new gauge with the given label names
+func NewGauge(name string, help string, labels ...string) *Gauge {
+	return &Gauge{f: register(name, help, gaugeType, nil, labels)}
+}
+
+// Set sets the gauge with the given label values to v
+func (g *Gauge) Set(v float64, values ...string) {
+	g.f.set(v, values)
+}
+
+// Add adds v to the gauge with the given label values
+func (g *Gauge) Add(v float64, values ...string) {
+	g.f.add(v, values)
+}
+
+// Inc increments the gauge with the given label values by 1
+func (g *Gauge) Inc(values ...string) {
+	g.f.add(1, values)
+}
+
+// Dec decrements the gauge with the given label values by 1
+func (g *Gauge) Dec(values ...string) {
+	g.f.add(-1, values)
+}
+
+// Histogram counts observations into configurable buckets, partitioned by its labels
+type Histogram struct {
+	f *family
+}
+
+// NewHistogram creates and registers a new histogram with the given buckets and label names.
+// If buckets is nil, DefaultBuckets is used.
+func NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{f: register(name, help, histogramType, buckets, labels)}
+}
+
+// Observe records v in the histogram with the given label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.f.mux.Lock()
+	defer h.f.mux.Unlock()
+	s := h.f.get(values)
+	for i, bound := range h.f.buckets {
+		if v <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.value += v
+}
+
+// Write writes all of the registered metrics to w in the prometheus text format
+func Write(w io.Writer) error {
+	regMux.Lock()
+	fams := append([]*family{}, families...)
+	regMux.Unlock()
+
+	for _, f := range fams {
+		err := f.write(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves the registered metrics over http
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		Write(w)
+	})
+}
@@ -0,0 +1,163 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func Test_formatLabels(t *testing.T) {
+	var test = []struct {
+		names    []string
+		values   []string
+		extra    []string
+		expected string
+	}{
+		{names: nil, values: nil, expected: ""},
+		{names: []string{"server"}, values: []string{"1"}, expected: `{server="1"}`},
+		{
+			names:    []string{"blockchain", "outcome"},
+			values:   []string{"geth", "success"},
+			expected: `{blockchain="geth",outcome="success"}`,
+		},
+		{names: nil, values: nil, extra: []string{"le", "0.5"}, expected: `{le="0.5"}`},
+		{
+			names:    []string{"stage"},
+			values:   []string{"say \"hi\"\n\\"},
+			extra:    []string{"le", "+Inf"},
+			expected: `{stage="say \"hi\"\n\\",le="+Inf"}`,
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := formatLabels(tt.names, tt.values, tt.extra...)
+			if out != tt.expected {
+				t.Errorf("formatLabels returned %s, expected %s", out, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_formatFloat(t *testing.T) {
+	var test = []struct {
+		v        float64
+		expected string
+	}{
+		{v: 0, expected: "0"},
+		{v: 3, expected: "3"},
+		{v: 0.005, expected: "0.005"},
+		{v: -1.5, expected: "-1.5"},
+		{v: 1e21, expected: "1e+21"},
+		{v: math.Inf(1), expected: "+Inf"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := formatFloat(tt.v)
+			if out != tt.expected {
+				t.Errorf("formatFloat(%v) returned %s, expected %s", tt.v, out, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_family_write(t *testing.T) {
+	counter := NewCounter("test_counter_total", "A test counter", "server")
+	counter.Inc("2")
+	counter.Add(2.5, "1")
+	counter.Inc("2")
+
+	gauge := NewGauge("test_gauge", "A test gauge")
+	gauge.Set(5)
+	gauge.Dec()
+	gauge.Add(-1.5)
+
+	histogram := NewHistogram("test_duration_seconds", "A test histogram", []float64{1, 0.5}, "stage")
+	histogram.Observe(0.25, "a")
+	histogram.Observe(0.75, "a")
+	histogram.Observe(3, "a")
+
+	var test = []struct {
+		f        *family
+		expected string
+	}{
+		{
+			f: counter.f,
+			expected: "# HELP test_counter_total A test counter\n" +
+				"# TYPE test_counter_total counter\n" +
+				"test_counter_total{server=\"1\"} 2.5\n" +
+				"test_counter_total{server=\"2\"} 2\n",
+		},
+		{
+			f: gauge.f,
+			expected: "# HELP test_gauge A test gauge\n" +
+				"# TYPE test_gauge gauge\n" +
+				"test_gauge 2.5\n",
+		},
+		{
+			f: histogram.f,
+			expected: "# HELP test_duration_seconds A test histogram\n" +
+				"# TYPE test_duration_seconds histogram\n" +
+				"test_duration_seconds_bucket{stage=\"a\",le=\"0.5\"} 1\n" +
+				"test_duration_seconds_bucket{stage=\"a\",le=\"1\"} 2\n" +
+				"test_duration_seconds_bucket{stage=\"a\",le=\"+Inf\"} 3\n" +
+				"test_duration_seconds_sum{stage=\"a\"} 4\n" +
+				"test_duration_seconds_count{stage=\"a\"} 3\n",
+		},
+		{
+			f: NewGauge("test_empty", "A gauge with no series", "server").f,
+			expected: "# HELP test_empty A gauge with no series\n" +
+				"# TYPE test_empty gauge\n",
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tt.f.write(&buf)
+			if err != nil {
+				t.Error(err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("write returned\n%s\nexpected\n%s", buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	NewCounter("test_registered_total", "A registered counter").Inc()
+
+	var buf bytes.Buffer
+	err := Write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# TYPE test_registered_total counter\ntest_registered_total 1\n"
+	if !bytes.Contains(buf.Bytes(), []byte(expected)) {
+		t.Errorf("Write output does not contain %q", expected)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("# TYPE genesis_builds_total counter\n")) {
+		t.Error("Write output does not contain the genesis metrics")
+	}
+}
@@ -0,0 +1,236 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/docker"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ClockSkew is a representation of the skew applied to the clock of a node
+type ClockSkew struct {
+	Node     int     `json:"node"`
+	Offset   float64 `json:"offset"`             //Offset from the host clock in seconds, may be negative
+	Drift    float64 `json:"drift"`              //Drift is the rate of the clock, ie 1.001 runs 0.1% fast. 0 leaves it unchanged
+	Measured float64 `json:"measured,omitempty"` //Measured offset in seconds, only given when reporting
+	Selector string  `json:"selector,omitempty"` //Selector applies the skew to the nodes with matching labels, in place of Node
+}
+
+// Validate checks that the clock skew can be applied
+func (cs ClockSkew) Validate() error {
+	if cs.Node < 0 {
+		return fmt.Errorf("invalid node %d", cs.Node)
+	}
+	if cs.Drift < 0 {
+		return fmt.Errorf("drift cannot be negative")
+	}
+	return nil
+}
+
+// faketimeSpec gives the libfaketime representation of the clock skew
+func (cs ClockSkew) faketimeSpec() string {
+	out := strconv.FormatFloat(cs.Offset, 'f', -1, 64)
+	if cs.Offset >= 0 {
+		out = "+" + out
+	}
+	if cs.Drift != 0 && cs.Drift != 1 {
+		out += " x" + strconv.FormatFloat(cs.Drift, 'f', -1, 64)
+	}
+	return out
+}
+
+// parseFaketimeSpec parses a relative libfaketime specification, such as "+10 x1.5"
+func parseFaketimeSpec(spec string) (ClockSkew, error) {
+	out := ClockSkew{}
+	multipliers := map[byte]float64{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'y': 31536000}
+
+	for _, field := range strings.Fields(spec) {
+		switch field[0] {
+		case '+', '-':
+			mult := 1.0
+			if m, ok := multipliers[field[len(field)-1]]; ok {
+				mult = m
+				field = field[:len(field)-1]
+			}
+			offset, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return out, util.LogError(err)
+			}
+			out.Offset = offset * mult
+		case 'x':
+			drift, err := strconv.ParseFloat(field[1:], 64)
+			if err != nil {
+				return out, util.LogError(err)
+			}
+			out.Drift = drift
+		default:
+			return out, fmt.Errorf("unsupported faketime value \"%s\"", field)
+		}
+	}
+	return out, nil
+}
+
+func checkClockSupport() error {
+	if len(conf.FaketimeLib) == 0 {
+		return fmt.Errorf("clock skew is not enabled, faketimeLib must be set")
+	}
+	return nil
+}
+
+// ApplyClockSkews sets the clocks of the given nodes, which may be changed at any time
+func ApplyClockSkews(skews []ClockSkew, nodes []db.Node) error {
+	return recordOperation("clock_apply", applyClockSkews(skews, nodes))
+}
+
+// selectTargets gets the nodes with the given selector, or if the selector is empty, the node with the given absolute number
+func selectTargets(nodes []db.Node, absNum int, selector string) ([]db.Node, error) {
+	if len(selector) > 0 {
+		return db.ResolveNodes(nodes, selector)
+	}
+	node, err := db.GetNodeByAbsNum(nodes, absNum)
+	if err != nil {
+		return nil, err
+	}
+	return []db.Node{node}, nil
+}
+
+func applyClockSkews(skews []ClockSkew, nodes []db.Node) error {
+	err := checkClockSupport()
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, skew := range skews {
+		err := skew.Validate()
+		if err != nil {
+			return util.LogError(err)
+		}
+		targets, err := selectTargets(nodes, skew.Node, skew.Selector)
+		if err != nil {
+			return util.LogError(err)
+		}
+		for _, node := range targets {
+			client, err := status.GetClient(node.Server)
+			if err != nil {
+				return util.LogError(err)
+			}
+			_, err = client.DockerExec(node, fmt.Sprintf("bash -c 'echo \"%s\" > %s'",
+				skew.faketimeSpec(), docker.FaketimeFile))
+			if err != nil {
+				return util.LogError(err)
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveClockSkews restores the clocks of the given nodes to the host clock
+func RemoveClockSkews(nodes []db.Node) error {
+	return recordOperation("clock_remove", removeClockSkews(nodes))
+}
+
+func removeClockSkews(nodes []db.Node) error {
+	err := checkClockSupport()
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, node := range nodes {
+		client, err := status.GetClient(node.Server)
+		if err != nil {
+			return util.LogError(err)
+		}
+		_, err = client.DockerExec(node, fmt.Sprintf("rm -f %s", docker.FaketimeFile))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	return nil
+}
+
+// GetClockSkews reports the configured clock skew of each of the given nodes, along with
+// the offset measured between the node and its host
+func GetClockSkews(nodes []db.Node) ([]ClockSkew, error) {
+	err := checkClockSupport()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := make([]ClockSkew, len(nodes))
+	errs := make([]error, len(nodes))
+	wg := sync.WaitGroup{}
+	for i := range nodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out[i], errs[i] = getClockSkew(nodes[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func getClockSkew(node db.Node) (ClockSkew, error) {
+	client, err := status.GetClient(node.Server)
+	if err != nil {
+		return ClockSkew{}, util.LogError(err)
+	}
+	spec, err := client.DockerExec(node, fmt.Sprintf("bash -c 'cat %s 2>/dev/null || true'", docker.FaketimeFile))
+	if err != nil {
+		return ClockSkew{}, util.LogError(err)
+	}
+	out, err := parseFaketimeSpec(spec)
+	if err != nil {
+		return ClockSkew{}, util.LogError(err)
+	}
+	out.Node = node.AbsoluteNum
+
+	res, err := client.Run(fmt.Sprintf("date +%%s.%%N && docker exec %s date +%%s.%%N", node.GetNodeName()))
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	out.Measured, err = measureOffset(res)
+	return out, err
+}
+
+// measureOffset gives the difference between the two times, in seconds since the epoch, given on
+// the separate lines of res
+func measureOffset(res string) (float64, error) {
+	times := strings.Fields(res)
+	if len(times) != 2 {
+		return 0, fmt.Errorf("unexpected time output \"%s\"", res)
+	}
+	host, err := strconv.ParseFloat(times[0], 64)
+	if err != nil {
+		return 0, util.LogError(err)
+	}
+	node, err := strconv.ParseFloat(times[1], 64)
+	if err != nil {
+		return 0, util.LogError(err)
+	}
+	return node - host, nil
+}
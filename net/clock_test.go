@@ -0,0 +1,98 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestClockSkew_faketimeSpec(t *testing.T) {
+	var test = []struct {
+		skew     ClockSkew
+		expected string
+	}{
+		{skew: ClockSkew{Node: 1, Offset: 10}, expected: "+10"},
+		{skew: ClockSkew{Node: 1, Offset: -2.5, Drift: 1}, expected: "-2.5"},
+		{skew: ClockSkew{Node: 1, Offset: 0, Drift: 1.001}, expected: "+0 x1.001"},
+		{skew: ClockSkew{Node: 1, Offset: 300, Drift: 0.5}, expected: "+300 x0.5"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if tt.skew.faketimeSpec() != tt.expected {
+				t.Errorf("faketimeSpec() = %s, expected %s", tt.skew.faketimeSpec(), tt.expected)
+			}
+		})
+	}
+}
+
+func Test_parseFaketimeSpec(t *testing.T) {
+	var test = []struct {
+		spec     string
+		expected ClockSkew
+		err      bool
+	}{
+		{spec: "", expected: ClockSkew{}},
+		{spec: "+10\n", expected: ClockSkew{Offset: 10}},
+		{spec: "-2.5 x1.001", expected: ClockSkew{Offset: -2.5, Drift: 1.001}},
+		{spec: "+2m", expected: ClockSkew{Offset: 120}},
+		{spec: "+1d x2", expected: ClockSkew{Offset: 86400, Drift: 2}},
+		{spec: "@2019-01-01 00:00:00", err: true},
+		{spec: "+1q", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			skew, err := parseFaketimeSpec(tt.spec)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error state: %v", err)
+			}
+			if !tt.err && !reflect.DeepEqual(skew, tt.expected) {
+				t.Errorf("parseFaketimeSpec(%q) = %+v, expected %+v", tt.spec, skew, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_measureOffset(t *testing.T) {
+	var test = []struct {
+		res      string
+		expected float64
+		err      bool
+	}{
+		{res: "100.5\n110.5\n", expected: 10},
+		{res: "100.5\n90.25\n", expected: -10.25},
+		{res: "100.5\n", err: true},
+		{res: "100.5\nError: No such container\n", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			offset, err := measureOffset(tt.res)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error state: %v", err)
+			}
+			if !tt.err && offset != tt.expected {
+				t.Errorf("measureOffset(%q) = %f, expected %f", tt.res, offset, tt.expected)
+			}
+		})
+	}
+}
@@ -18,7 +18,10 @@
 
 package netconf
 
-import log "github.com/sirupsen/logrus"
+import (
+	log "github.com/sirupsen/logrus"
+	"sort"
+)
 
 //Connection represents a uni-directional connection
 type Connection struct {
@@ -55,14 +58,15 @@ func (mesh *Connections) RemoveAll(conns []Connection) {
 	}
 }
 
-func findPossiblePeers(cons [][]bool, node int) []int {
+// findReachablePeers gets the nodes which the given node has an open connection to
+func findReachablePeers(cons [][]bool, node int) []int {
 	var out []int
 
 	for i, con := range cons[node] {
 		if node == i {
 			continue
 		}
-		if con || cons[i][node] {
+		if con {
 			out = append(out, i)
 		}
 	}
@@ -109,44 +113,51 @@ func containsPeer(peers []int, peer int) bool {
 	return false
 }
 
-//Networks calculates the distinct, completely separate partitions in the network
+// Reachable gets the nodes which the given node is able to send traffic to, either directly or by
+// relaying it through other nodes. The given node is included in the result.
+func (mesh Connections) Reachable(node int) []int {
+	out := []int{node}
+	nodesToTry := []int{node}
+
+	for len(nodesToTry) > 0 {
+		newPeers := filterPeers(findReachablePeers(mesh.cons, nodesToTry[0]), out)
+		out = mergeUniquePeers(out, newPeers)
+		nodesToTry = append(nodesToTry[1:], newPeers...)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// Reachability gets the nodes which each node is able to reach, indexed by node
+func (mesh Connections) Reachability() [][]int {
+	out := make([][]int, len(mesh.cons))
+	for i := range mesh.cons {
+		out[i] = mesh.Reachable(i)
+	}
+	return out
+}
+
+// Networks calculates the distinct, completely separate partitions in the network. Nodes are in
+// the same partition only if they are able to reach each other, so a one-way cut which leaves
+// a node able to send to another node but not to receive from it places them in different partitions.
 func (mesh Connections) Networks() [][]int {
-	nodes := []int{}
+	reach := mesh.Reachability()
 	nodesFinalized := []int{}
-	nodesToTry := []int{}
-
 	out := [][]int{}
 
-	for len(nodesFinalized) < len(mesh.cons) {
-		log.WithFields(log.Fields{"nodes": nodes, "nodesFinalized": nodesFinalized,
-			"nodesToTry": nodesToTry}).Trace("calculating networks...")
-		if len(nodesToTry) == 0 {
-			if len(nodes) > 0 {
-				nodesFinalized = mergeUniquePeers(nodes, nodesFinalized)
-				out = append(out, nodes)
-				nodes = []int{}
-			}
-			for i := 0; i < len(mesh.cons); i++ {
-				if !containsPeer(nodesFinalized, i) {
-					nodesToTry = findPossiblePeers(mesh.cons, i)
-					nodes = []int{i}
-					nodes = mergeUniquePeers(nodes, nodesToTry)
-					break
-				}
-			}
-
-		} else {
-			newPeers := findPossiblePeers(mesh.cons, nodesToTry[0])
-			nodes = mergeUniquePeers(nodes, []int{nodesToTry[0]})
-			newPeers = filterPeers(newPeers, nodes)
-			if len(nodesToTry) > 1 {
-				nodesToTry = nodesToTry[1:]
-			} else {
-				nodesToTry = []int{}
+	for i := range reach {
+		if containsPeer(nodesFinalized, i) {
+			continue
+		}
+		nodes := []int{}
+		for _, peer := range reach[i] {
+			if containsPeer(reach[peer], i) {
+				nodes = append(nodes, peer)
 			}
-			nodesToTry = mergeUniquePeers(nodesToTry, newPeers)
-
 		}
+		log.WithFields(log.Fields{"node": i, "network": nodes}).Trace("calculated network")
+		nodesFinalized = mergeUniquePeers(nodesFinalized, nodes)
+		out = append(out, nodes)
 	}
 	return out
 }
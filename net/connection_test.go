@@ -58,21 +58,21 @@ func Test_RemoveAll(t *testing.T) {
 	}
 }
 
-func Test_findPossiblePeers(t *testing.T) {
+func Test_findReachablePeers(t *testing.T) {
 	var test = []struct {
 		cons     [][]bool
 		node     int
 		expected []int
 	}{
-		{[][]bool{{false, true, true}, {true, true, true}, {false, false, false}, {true, false, true}}, 1, []int{0, 2}},
-		{[][]bool{{false, true, true}, {false, true, true}, {true, true, true}, {true, false, true}}, 2, []int{0, 1}},
-		{[][]bool{{true, true, true}, {true, false, true}, {false, false, false}, {true, false, true}}, 0, []int{1, 2}},
+		{[][]bool{{false, true, true}, {true, true, true}, {false, false, false}}, 1, []int{0, 2}},
+		{[][]bool{{false, true, true}, {false, true, true}, {true, false, true}}, 2, []int{0}},
+		{[][]bool{{true, false, false}, {true, false, true}, {false, false, false}}, 0, nil},
 	}
 
 	for i, tt := range test {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
-			if !reflect.DeepEqual(findPossiblePeers(tt.cons, tt.node), tt.expected) {
-				t.Errorf("return value of findPossiblePeers did not match expected value")
+			if !reflect.DeepEqual(findReachablePeers(tt.cons, tt.node), tt.expected) {
+				t.Errorf("return value of findReachablePeers did not match expected value")
 			}
 		})
 	}
@@ -150,6 +150,9 @@ func Test_Networks(t *testing.T) {
 		{Connections{[][]bool{{true, true, true, true}, {true, true, true, true}, {true, true, true, true}, {true, true, true, true}}}, [][]int{{0, 1, 2, 3}}},
 		{Connections{[][]bool{{true, true}, {true, true}}}, [][]int{{0, 1}}},
 		{Connections{[][]bool{{true, true, true}, {true, true, true}, {true, true, true}}}, [][]int{{0, 1, 2}}},
+		{Connections{[][]bool{{true, false, true}, {false, true, true}, {true, true, true}}}, [][]int{{0, 1, 2}}},
+		{Connections{[][]bool{{true, true, false}, {false, true, false}, {false, false, true}}}, [][]int{{0}, {1}, {2}}},
+		{Connections{[][]bool{{true, true, false, false}, {true, true, false, false}, {false, false, true, true}, {false, false, true, true}}}, [][]int{{0, 1}, {2, 3}}},
 	}
 
 	for i, tt := range test {
@@ -160,3 +163,23 @@ func Test_Networks(t *testing.T) {
 		})
 	}
 }
+
+func Test_Reachability(t *testing.T) {
+	var test = []struct {
+		mesh     Connections
+		expected [][]int
+	}{
+		{Connections{[][]bool{{true, true}, {true, true}}}, [][]int{{0, 1}, {0, 1}}},
+		{Connections{[][]bool{{true, true}, {false, true}}}, [][]int{{0, 1}, {1}}},
+		{Connections{[][]bool{{true, true, false}, {false, true, true}, {false, false, true}}}, [][]int{{0, 1, 2}, {1, 2}, {2}}},
+		{Connections{[][]bool{{true, false, true}, {false, true, true}, {true, true, true}}}, [][]int{{0, 1, 2}, {0, 1, 2}, {0, 1, 2}}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if !reflect.DeepEqual(tt.mesh.Reachability(), tt.expected) {
+				t.Errorf("return value of Reachability does not match expected value")
+			}
+		})
+	}
+}
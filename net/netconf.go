@@ -23,6 +23,7 @@ import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/metrics"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/util"
@@ -47,6 +48,28 @@ import (
 
 var conf = util.GetConfig()
 
+// offset is the firewall mark given to the traffic which the network conditions apply to
+const offset int = 6
+
+// ifbPrefix is the prefix of the ifb devices which the traffic sent by the nodes is redirected to for shaping
+const ifbPrefix = "wb_ifb"
+
+// shapingBurst and shapingLatency are the bucket size and the maximum queueing time of the token bucket
+// filters which limit the bandwidth of a node
+const (
+	shapingBurst   = "64kb"
+	shapingLatency = "400ms"
+)
+
+// ratePattern matches a tc rate, which is a number followed by a unit such as kbit or mbps
+var ratePattern = regexp.MustCompile(`(?i)^[0-9]+(\.[0-9]+)?([kmgt]i?)?(bit|bps)$`)
+
+// recordOperation counts the given network operation in the metrics, passing err through
+func recordOperation(operation string, err error) error {
+	metrics.NetworkOperations.Inc(operation, metrics.Outcome(err))
+	return err
+}
+
 //Netconf is a representation of the impairments applied to a node
 type Netconf struct {
 	Node        int     `json:"node"`
@@ -57,12 +80,29 @@ type Netconf struct {
 	Duplication float64 `json:"duplicate"`
 	Corrupt     float64 `json:"corrupt"`
 	Reorder     float64 `json:"reorder"`
+	Selector    string  `json:"selector,omitempty"` //Selector applies the conditions to the nodes with matching labels, in place of Node
+	Uplink      string  `json:"uplink,omitempty"`   //Uplink is the bandwidth cap on the traffic sent by the node, such as 10mbit
+	Downlink    string  `json:"downlink,omitempty"` //Downlink is the bandwidth cap on the traffic received by the node
+}
+
+// Validate checks that the rates of the netconf are valid tc rates
+func (netconf Netconf) Validate() error {
+	rates := []struct {
+		name string
+		rate string
+	}{{"rate", netconf.Rate}, {"uplink", netconf.Uplink}, {"downlink", netconf.Downlink}}
+	for _, r := range rates {
+		if len(r.rate) > 0 && !ratePattern.MatchString(r.rate) {
+			return fmt.Errorf("invalid %s \"%s\", expected a number followed by a unit such as kbit or mbit",
+				r.name, r.rate)
+		}
+	}
+	return nil
 }
 
 // CreateCommands generates the commands needed to obtain the desired
 // network conditions
 func CreateCommands(netconf Netconf, serverID int) []string {
-	const offset int = 6
 	out := []string{
 		fmt.Sprintf("sudo -n tc qdisc del dev %s%d root", conf.BridgePrefix, netconf.Node),
 		fmt.Sprintf("sudo -n tc qdisc add dev %s%d root handle 1: prio", conf.BridgePrefix, netconf.Node),
@@ -101,9 +141,39 @@ func CreateCommands(netconf Netconf, serverID int) []string {
 		out[2] += fmt.Sprintf(" reorder %.4f", netconf.Reorder)
 	}
 
+	if len(netconf.Downlink) > 0 {
+		out = append(out, fmt.Sprintf("sudo -n tc qdisc add dev %s%d parent 2:1 handle 3: tbf rate %s burst %s latency %s",
+			conf.BridgePrefix, netconf.Node, netconf.Downlink, shapingBurst, shapingLatency))
+	}
+
 	return out
 }
 
+// CreateIngressCommands generates the commands needed to cap the bandwidth of the traffic sent by a
+// node. This traffic arrives on the ingress of the node's bridge, where it cannot be queued, so it is
+// redirected to an ifb device and shaped on the egress of that device instead. The commands which
+// clear the previous cap are always generated.
+func CreateIngressCommands(netconf Netconf) []string {
+	bridge := fmt.Sprintf("%s%d", conf.BridgePrefix, netconf.Node)
+	ifb := fmt.Sprintf("%s%d", ifbPrefix, netconf.Node)
+	out := []string{
+		fmt.Sprintf("sudo -n tc qdisc del dev %s ingress 2>/dev/null || true", bridge),
+		fmt.Sprintf("sudo -n ip link del %s 2>/dev/null || true", ifb),
+	}
+	if len(netconf.Uplink) == 0 {
+		return out
+	}
+	return append(out,
+		fmt.Sprintf("sudo -n ip link add %s type ifb", ifb),
+		fmt.Sprintf("sudo -n ip link set dev %s up", ifb),
+		fmt.Sprintf("sudo -n tc qdisc add dev %s handle ffff: ingress", bridge),
+		fmt.Sprintf("sudo -n tc filter add dev %s parent ffff: protocol ip u32 match u32 0 0 action mirred egress redirect dev %s",
+			bridge, ifb),
+		fmt.Sprintf("sudo -n tc qdisc add dev %s root handle 1: tbf rate %s burst %s latency %s",
+			ifb, netconf.Uplink, shapingBurst, shapingLatency),
+	)
+}
+
 //Apply applies the given network config.
 func Apply(client ssh.Client, netconf Netconf, serverID int) error {
 	cmds := CreateCommands(netconf, serverID)
@@ -117,12 +187,39 @@ func Apply(client ssh.Client, netconf Netconf, serverID int) error {
 			return util.LogError(err)
 		}
 	}
+	for _, cmd := range CreateIngressCommands(netconf) {
+		_, err := client.Run(cmd)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
 	return nil
 }
 
 //ApplyAll applies all of the given netconfs
 func ApplyAll(netconfs []Netconf, nodes []db.Node) error {
+	return recordOperation("netem_apply", applyAll(netconfs, nodes))
+}
+
+func applyAll(netconfs []Netconf, nodes []db.Node) error {
+	for _, netconf := range netconfs {
+		err := netconf.Validate()
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
 	for _, netconf := range netconfs {
+		if len(netconf.Selector) > 0 {
+			selected, err := db.ResolveNodes(nodes, netconf.Selector)
+			if err != nil {
+				return util.LogError(err)
+			}
+			err = applyToAll(netconf, selected)
+			if err != nil {
+				return util.LogError(err)
+			}
+			continue
+		}
 		node, err := db.GetNodeByLocalID(nodes, netconf.Node)
 		if err != nil {
 			return util.LogError(err)
@@ -141,23 +238,24 @@ func ApplyAll(netconfs []Netconf, nodes []db.Node) error {
 
 //ApplyToAll applies the given netconf to `nodes` nodes in the network on the given server
 func ApplyToAll(netconf Netconf, nodes []db.Node) error {
+	return recordOperation("netem_apply_all", applyToAll(netconf, nodes))
+}
+
+func applyToAll(netconf Netconf, nodes []db.Node) error {
+	err := netconf.Validate()
+	if err != nil {
+		return util.LogError(err)
+	}
 	for _, node := range nodes {
 		netconf.Node = node.LocalID
-		cmds := CreateCommands(netconf, node.Server)
-		for i, cmd := range cmds {
-			client, err := status.GetClient(node.Server)
-			if err != nil {
-				log.WithFields(log.Fields{"i": i, "cmd": cmd, "error": err}).Error("error running netem command")
-				return util.LogError(err)
-			}
-			_, err = client.Run(cmd)
-			if i == 0 {
-				//Don't check the success of the first command which clears
-				continue
-			}
-			if err != nil {
-				return util.LogError(err)
-			}
+		client, err := status.GetClient(node.Server)
+		if err != nil {
+			log.WithFields(log.Fields{"node": node.AbsoluteNum, "error": err}).Error("error running netem command")
+			return util.LogError(err)
+		}
+		err = Apply(client, netconf, node.Server)
+		if err != nil {
+			return util.LogError(err)
 		}
 	}
 	return nil
@@ -165,6 +263,10 @@ func ApplyToAll(netconf Netconf, nodes []db.Node) error {
 
 //RemoveAll removes network conditions from the given nodes
 func RemoveAll(nodes []db.Node) error {
+	return recordOperation("netem_remove", removeAll(nodes))
+}
+
+func removeAll(nodes []db.Node) error {
 	for _, node := range nodes {
 		client, err := status.GetClient(node.Server)
 		if err != nil {
@@ -175,6 +277,38 @@ func RemoveAll(nodes []db.Node) error {
 		if err != nil {
 			log.Error(err)
 		}
+		for _, cmd := range CreateIngressCommands(Netconf{Node: node.LocalID}) {
+			_, err = client.Run(cmd)
+			if err != nil {
+				log.Error(err)
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveNodes removes the network conditions of the given nodes, along with the rule which marks
+// their traffic for the conditions
+func RemoveNodes(nodes []db.Node) error {
+	return recordOperation("netem_remove_nodes", removeNodes(nodes))
+}
+
+func removeNodes(nodes []db.Node) error {
+	err := removeAll(nodes)
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, node := range nodes {
+		client, err := status.GetClient(node.Server)
+		if err != nil {
+			return util.LogError(err)
+		}
+		_, err = client.Run(fmt.Sprintf(
+			"while sudo -n iptables -t mangle -D PREROUTING ! -d %s -j MARK --set-mark %d 2>/dev/null; do :; done",
+			util.GetGateway(node.Server, node.LocalID), offset))
+		if err != nil {
+			return util.LogError(err)
+		}
 	}
 	return nil
 }
@@ -186,6 +320,35 @@ func RemoveAllOnServer(client ssh.Client, nodes int) {
 			fmt.Sprintf("sudo tc qdisc del dev %s%d root", conf.BridgePrefix, i))
 	}
 	RemoveAllOutages(client)
+	RemoveShapingDevices(client)
+}
+
+// RemoveShapingDevices removes the ifb devices used to cap the uplink of the nodes on a server. Unlike the
+// bridges of the nodes, these are not removed along with the docker networks.
+func RemoveShapingDevices(client ssh.Client) error {
+	return recordOperation("shaping_remove_all", removeShapingDevices(client))
+}
+
+func removeShapingDevices(client ssh.Client) error {
+	res, err := client.Run("ip -o link show type ifb || true")
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, line := range strings.Split(res, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		dev := strings.Split(strings.TrimSuffix(fields[1], ":"), "@")[0]
+		if !strings.HasPrefix(dev, ifbPrefix) {
+			continue
+		}
+		_, err = client.Run(fmt.Sprintf("sudo -n ip link del %s", dev))
+		if err != nil {
+			log.WithFields(log.Fields{"device": dev, "error": err}).Error("failed to remove a shaping device")
+		}
+	}
+	return nil
 }
 
 func parseItems(items []string, nconf *Netconf) error {
@@ -249,8 +412,68 @@ func parseItems(items []string, nconf *Netconf) error {
 	return nil
 }
 
+// parseShaping reads the bandwidth caps out of the token bucket filters on a server, setting them on
+// the netconfs of the nodes which they belong to, and adding a netconf for any node which does not have one
+func parseShaping(res string, netconfs []Netconf) ([]Netconf, error) {
+	for _, rawConfig := range strings.Split(res, "\n") {
+		rawItems := strings.Fields(rawConfig)
+		if len(rawItems) < 5 {
+			continue
+		}
+		rate := ""
+		for i := 5; i < len(rawItems)-1; i++ {
+			if rawItems[i] == "rate" {
+				rate = rawItems[i+1]
+				break
+			}
+		}
+		dev := rawItems[4]
+		uplink := strings.HasPrefix(dev, ifbPrefix)
+		prefix := conf.BridgePrefix
+		if uplink {
+			prefix = ifbPrefix
+		} else if !strings.HasPrefix(dev, conf.BridgePrefix) {
+			continue
+		}
+
+		num, err := strconv.Atoi(dev[len(prefix):])
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		index := -1
+		for i := range netconfs {
+			if netconfs[i].Node == num {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			netconfs = append(netconfs, Netconf{Node: num})
+			index = len(netconfs) - 1
+		}
+		if uplink {
+			netconfs[index].Uplink = rate
+		} else {
+			netconfs[index].Downlink = rate
+		}
+	}
+	return netconfs, nil
+}
+
 //GetConfigOnServer gets the network impairments present on a server
 func GetConfigOnServer(client ssh.Client) ([]Netconf, error) {
+	out, err := getNetemOnServer(client)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	res, err := client.Run("sudo -n tc qdisc show | grep tbf || true")
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return parseShaping(res, out)
+}
+
+func getNetemOnServer(client ssh.Client) ([]Netconf, error) {
 	res, err := client.Run(fmt.Sprintf("sudo -n tc qdisc show | grep %s | grep netem || true", conf.BridgePrefix))
 	if err != nil {
 		return nil, util.LogError(err)
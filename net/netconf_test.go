@@ -55,6 +55,17 @@ func TestCreateCommands(t *testing.T) {
 				"sudo -n iptables -t mangle -A PREROUTING  ! -d 10.3.0.49 -j MARK --set-mark 6",
 			},
 		},
+		{netconf: Netconf{Node: 1, Delay: 50000, Uplink: "1mbit", Downlink: "10mbit"},
+			serverID: 1,
+			expected: []string{
+				"sudo -n tc qdisc del dev wb_bridge1 root",
+				"sudo -n tc qdisc add dev wb_bridge1 root handle 1: prio",
+				"sudo -n tc qdisc add dev wb_bridge1 parent 1:1 handle 2: netem delay 50000us",
+				"sudo -n tc filter add dev wb_bridge1 parent 1:0 protocol ip pref 55 handle 6 fw flowid 2:1",
+				"sudo -n iptables -t mangle -A PREROUTING  ! -d 10.1.0.17 -j MARK --set-mark 6",
+				"sudo -n tc qdisc add dev wb_bridge1 parent 2:1 handle 3: tbf rate 10mbit burst 64kb latency 400ms",
+			},
+		},
 	}
 
 	for i, tt := range test {
@@ -80,6 +91,8 @@ func TestApply(t *testing.T) {
 		"sudo -n tc qdisc add dev wb_bridge3 parent 1:1 handle 2: netem",
 		"sudo -n tc filter add dev wb_bridge3 parent 1:0 protocol ip pref 55 handle 6 fw flowid 2:1",
 		"sudo -n iptables -t mangle -A PREROUTING  ! -d 10.1.0.49 -j MARK --set-mark 6",
+		"sudo -n tc qdisc del dev wb_bridge3 ingress 2>/dev/null || true",
+		"sudo -n ip link del wb_ifb3 2>/dev/null || true",
 	}
 
 	var previous *gomock.Call
@@ -107,10 +120,89 @@ func TestRemoveAllOnServer(t *testing.T) {
 	}
 
 	RemoveAllOutagesExpectations(client)
+	client.EXPECT().Run("ip -o link show type ifb || true").Return("", nil)
 
 	RemoveAllOnServer(client, nodes)
 }
 
+func TestRemoveShapingDevices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Run("ip -o link show type ifb || true").Return(
+		"7: ifb0: <BROADCAST,NOARP> mtu 1500 qdisc noop state DOWN mode DEFAULT group default qlen 32\n"+
+			"12: wb_ifb1: <BROADCAST,NOARP,UP,LOWER_UP> mtu 1500 qdisc tbf state UNKNOWN mode DEFAULT group default qlen 32\n"+
+			"15: wb_ifb5: <BROADCAST,NOARP,UP,LOWER_UP> mtu 1500 qdisc tbf state UNKNOWN mode DEFAULT group default qlen 32\n", nil)
+	client.EXPECT().Run("sudo -n ip link del wb_ifb1")
+	client.EXPECT().Run("sudo -n ip link del wb_ifb5")
+
+	err := RemoveShapingDevices(client)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetconf_Validate(t *testing.T) {
+	var test = []struct {
+		netconf Netconf
+		valid   bool
+	}{
+		{netconf: Netconf{}, valid: true},
+		{netconf: Netconf{Rate: "1Mbit", Uplink: "512kbit", Downlink: "10mbit"}, valid: true},
+		{netconf: Netconf{Uplink: "1.5gbit", Downlink: "100kbps"}, valid: true},
+		{netconf: Netconf{Downlink: "1mibit"}, valid: true},
+		{netconf: Netconf{Uplink: "10"}, valid: false},
+		{netconf: Netconf{Uplink: "mbit"}, valid: false},
+		{netconf: Netconf{Downlink: "10mbit; reboot"}, valid: false},
+		{netconf: Netconf{Rate: "10 mbit"}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.netconf.Validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate returned %v, expected valid to be %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestCreateIngressCommands(t *testing.T) {
+	var test = []struct {
+		netconf  Netconf
+		expected []string
+	}{
+		{
+			netconf: Netconf{Node: 2, Downlink: "10mbit"},
+			expected: []string{
+				"sudo -n tc qdisc del dev wb_bridge2 ingress 2>/dev/null || true",
+				"sudo -n ip link del wb_ifb2 2>/dev/null || true",
+			},
+		},
+		{
+			netconf: Netconf{Node: 4, Uplink: "512kbit"},
+			expected: []string{
+				"sudo -n tc qdisc del dev wb_bridge4 ingress 2>/dev/null || true",
+				"sudo -n ip link del wb_ifb4 2>/dev/null || true",
+				"sudo -n ip link add wb_ifb4 type ifb",
+				"sudo -n ip link set dev wb_ifb4 up",
+				"sudo -n tc qdisc add dev wb_bridge4 handle ffff: ingress",
+				"sudo -n tc filter add dev wb_bridge4 parent ffff: protocol ip u32 match u32 0 0 action mirred egress redirect dev wb_ifb4",
+				"sudo -n tc qdisc add dev wb_ifb4 root handle 1: tbf rate 512kbit burst 64kb latency 400ms",
+			},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if !reflect.DeepEqual(CreateIngressCommands(tt.netconf), tt.expected) {
+				t.Errorf("return value of CreateIngressCommands does not match expected value")
+			}
+		})
+	}
+}
+
 func RemoveAllOutagesExpectations(client *mocks.MockClient) {
 	client.
 		EXPECT().
@@ -173,6 +265,11 @@ func TestGetConfigOnServer_Successful(t *testing.T) {
 		Run("sudo -n tc qdisc show | grep wb_bridge | grep netem || true").
 		Return("some random words testing wb_bridge3 test test limit 2 loss 0.5%\nsome random words testing wb_bridge4 test test limit 2 delay 415.9s loss 1.3% corrupt 0.4% rate 1 reorder 0.7% duplication 0", nil)
 
+	client.
+		EXPECT().
+		Run("sudo -n tc qdisc show | grep tbf || true").
+		Return("", nil)
+
 	netconf, _ := GetConfigOnServer(client)
 
 	if !reflect.DeepEqual(netconf, out) {
@@ -194,6 +291,11 @@ func TestGetConfigOnServer_Unsuccessful1(t *testing.T) {
 		Run("sudo -n tc qdisc show | grep wb_bridge | grep netem || true").
 		Return("some random words testing wb_bridge3\nsome words random test\n", nil)
 
+	client.
+		EXPECT().
+		Run("sudo -n tc qdisc show | grep tbf || true").
+		Return("", nil)
+
 	netconf, _ := GetConfigOnServer(client)
 	if !reflect.DeepEqual(netconf, out) {
 		t.Errorf("return value of GetConfigOnServer does not match expected value")
@@ -212,8 +314,43 @@ func TestGetConfigOnServer_Unsuccessful2(t *testing.T) {
 		Run("sudo -n tc qdisc show | grep wb_bridge | grep netem || true").
 		Return("", nil)
 
+	client.
+		EXPECT().
+		Run("sudo -n tc qdisc show | grep tbf || true").
+		Return("", nil)
+
 	netconf, _ := GetConfigOnServer(client)
 	if !reflect.DeepEqual(netconf, out) {
 		t.Errorf("return value of GetConfigOnServer does not match expected value")
 	}
 }
+
+func Test_parseShaping(t *testing.T) {
+	var test = []struct {
+		res      string
+		netconfs []Netconf
+		expected []Netconf
+	}{
+		{res: "", netconfs: []Netconf{{Node: 1, Limit: 2}}, expected: []Netconf{{Node: 1, Limit: 2}}},
+		{
+			res: "qdisc tbf 3: dev wb_bridge1 parent 2:1 rate 10Mbit burst 64Kb lat 400.0ms\n" +
+				"qdisc tbf 1: dev wb_ifb1 root refcnt 2 rate 1Mbit burst 64Kb lat 400.0ms\n" +
+				"qdisc tbf 1: dev wb_ifb5 root refcnt 2 rate 512Kbit burst 64Kb lat 400.0ms\n" +
+				"qdisc tbf 1: dev eth0 root refcnt 2 rate 1Gbit burst 64Kb lat 400.0ms\n",
+			netconfs: []Netconf{{Node: 1, Limit: 2}},
+			expected: []Netconf{{Node: 1, Limit: 2, Uplink: "1Mbit", Downlink: "10Mbit"}, {Node: 5, Uplink: "512Kbit"}},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := parseShaping(tt.res, tt.netconfs)
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("parseShaping returned %v, expected %v", out, tt.expected)
+			}
+		})
+	}
+}
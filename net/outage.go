@@ -25,13 +25,17 @@ import (
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/util"
-	"strconv"
+	"sort"
 	"strings"
 	"sync"
 )
 
 //RemoveAllOutages removes all blocked connections on a server via the given client
 func RemoveAllOutages(client ssh.Client) error {
+	return recordOperation("outage_remove_all", removeAllOutages(client))
+}
+
+func removeAllOutages(client ssh.Client) error {
 	res, err := client.Run("sudo iptables --list-rules | grep wb_bridge | grep DROP | grep FORWARD || true")
 	if err != nil {
 		return util.LogError(err)
@@ -61,59 +65,168 @@ func RemoveAllOutages(client ssh.Client) error {
 	return nil
 }
 
-func makeOutageCommands(node1 db.Node, node2 db.Node) []string {
-	return []string{
-		fmt.Sprintf("FORWARD -i %s%d -d %s -j DROP", conf.BridgePrefix, node1.AbsoluteNum, node2.IP),
-		fmt.Sprintf("FORWARD -i %s%d -d %s -j DROP", conf.BridgePrefix, node2.AbsoluteNum, node1.IP),
-	}
+// outageBridge gets the interface which the outage rules match the traffic of the given node on
+func outageBridge(node db.Node) string {
+	return fmt.Sprintf("%s%d", conf.BridgePrefix, node.LocalID)
 }
 
-func mkrmOutage(node1 db.Node, node2 db.Node, create bool) error {
-	flag := "-I"
-	if !create {
-		flag = "-D"
-	}
-	cmds := makeOutageCommands(node1, node2)
+// outageRule gets the rule which drops the traffic from one node to another. It belongs on the
+// server of the from node, as that is where the traffic of that node enters the FORWARD chain
+// through its bridge, whether it is destined for a node on the same server or routed through
+// the host to a node on another server.
+func outageRule(from db.Node, to db.Node) string {
+	return fmt.Sprintf("FORWARD -i %s -d %s -j DROP", outageBridge(from), to.IP)
+}
 
-	client, err := status.GetClient(node1.Server)
+// setOutageRule adds or removes the rule which drops the traffic from one node to another.
+// Adding a rule which is already present or removing one which is not does nothing.
+func setOutageRule(from db.Node, to db.Node, create bool) error {
+	client, err := status.GetClient(from.Server)
 	if err != nil {
 		return util.LogError(err)
 	}
-	_, err = client.Run(fmt.Sprintf("sudo iptables %s %s", flag, cmds[0]))
-	if err != nil {
-		return util.LogError(err)
+	rule := outageRule(from, to)
+	cmd := fmt.Sprintf("sudo iptables -C %s 2>/dev/null || sudo iptables -I %s", rule, rule)
+	if !create {
+		cmd = fmt.Sprintf("while sudo iptables -D %s 2>/dev/null; do :; done", rule)
+	}
+	_, err = client.Run(cmd)
+	return util.LogError(err)
+}
+
+// setOutage stores the intent for traffic from one node to another to be dropped or not, and
+// then applies it
+func setOutage(from db.Node, to db.Node, create bool) error {
+	outage := db.Outage{TestNetID: from.TestNetID, From: from.AbsoluteNum, To: to.AbsoluteNum}
+	var err error
+	if create {
+		err = db.InsertOutage(outage)
+	} else {
+		err = db.DeleteOutage(outage)
 	}
-	client, err = status.GetClient(node2.Server)
 	if err != nil {
 		return util.LogError(err)
 	}
-	_, err = client.Run(fmt.Sprintf("sudo iptables %s %s", flag, cmds[1]))
+	return setOutageRule(from, to, create)
+}
+
+func mkrmOutage(node1 db.Node, node2 db.Node, create bool) error {
+	err := setOutage(node1, node2, create)
 	if err != nil {
 		return util.LogError(err)
 	}
-
-	return nil
+	return setOutage(node2, node1, create)
 }
 
 //MakeOutage removes the ability for the given nodes to connect
 func MakeOutage(node1 db.Node, node2 db.Node) error {
-	return mkrmOutage(node1, node2, true)
+	return recordOperation("outage_create", mkrmOutage(node1, node2, true))
 }
 
 //RemoveOutage returns the ability for the given nodes to connect
 func RemoveOutage(node1 db.Node, node2 db.Node) error {
-	return mkrmOutage(node1, node2, false)
+	return recordOperation("outage_remove", mkrmOutage(node1, node2, false))
+}
+
+// MakeDirectedOutage drops the traffic sent from one node to another, while still allowing the
+// traffic sent the other way
+func MakeDirectedOutage(from db.Node, to db.Node) error {
+	return recordOperation("outage_create", setOutage(from, to, true))
+}
+
+// RemoveDirectedOutage allows the traffic sent from one node to another again, leaving any outage
+// in the other direction in place
+func RemoveDirectedOutage(from db.Node, to db.Node) error {
+	return recordOperation("outage_remove", setOutage(from, to, false))
+}
+
+// isNodeOutageRule checks whether the given outage rule blocks traffic either coming from one of the
+// given bridges or going to one of the given ips
+func isNodeOutageRule(rule string, bridges []string, ips []string) bool {
+	fields := strings.Fields(rule)
+	for i := 0; i < len(fields)-1; i++ {
+		switch fields[i] {
+		case "-i":
+			for _, bridge := range bridges {
+				if fields[i+1] == bridge {
+					return true
+				}
+			}
+		case "-d":
+			for _, ip := range ips {
+				if strings.TrimSuffix(fields[i+1], "/32") == ip {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// RemoveNodeOutages removes all of the outages involving the given nodes, on all of the servers
+// of the given nodes
+func RemoveNodeOutages(removed []db.Node, nodes []db.Node) error {
+	return recordOperation("outage_remove_nodes", removeNodeOutages(removed, nodes))
+}
+
+func removeNodeOutages(removed []db.Node, nodes []db.Node) error {
+	for _, node := range removed {
+		err := db.DeleteNodeOutages(node.TestNetID, node.AbsoluteNum)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	ips := []string{}
+	for _, node := range removed {
+		ips = append(ips, node.IP)
+	}
+	for _, serverID := range db.GetUniqueServerIDs(append(nodes, removed...)) {
+		bridges := []string{}
+		for _, node := range removed {
+			if node.Server == serverID {
+				bridges = append(bridges, outageBridge(node))
+			}
+		}
+		client, err := status.GetClient(serverID)
+		if err != nil {
+			return util.LogError(err)
+		}
+		res, err := client.Run("sudo iptables --list-rules | grep wb_bridge | grep DROP | grep FORWARD || true")
+		if err != nil {
+			return util.LogError(err)
+		}
+		for _, rule := range strings.Split(res, "\n") {
+			if len(rule) == 0 || !isNodeOutageRule(rule, bridges, ips) {
+				continue
+			}
+			_, err = client.Run(fmt.Sprintf("sudo iptables -D %s", strings.Replace(rule, "-A ", "", 1)))
+			if err != nil {
+				return util.LogError(err)
+			}
+		}
+	}
+	return nil
 }
 
 //CreatePartitionOutage causes the two sides to be unable to communicate with one and the other
 func CreatePartitionOutage(side1 []db.Node, side2 []db.Node) { //Doesn't report errors yet
+	createPartition(side1, side2, MakeOutage)
+}
+
+// CreateDirectedPartitionOutage drops all of the traffic sent from the nodes on one side to the nodes
+// on the other side, while still allowing the traffic sent the other way
+func CreateDirectedPartitionOutage(from []db.Node, to []db.Node) { //Doesn't report errors yet
+	createPartition(from, to, MakeDirectedOutage)
+}
+
+func createPartition(side1 []db.Node, side2 []db.Node, cut func(db.Node, db.Node) error) {
 	wg := sync.WaitGroup{}
 	for _, node1 := range side1 {
 		for _, node2 := range side2 {
 			wg.Add(1)
 			go func(node1 db.Node, node2 db.Node) {
 				defer wg.Done()
-				err := MakeOutage(node1, node2)
+				err := cut(node1, node2)
 				if err != nil {
 					log.Error(err)
 				}
@@ -123,61 +236,262 @@ func CreatePartitionOutage(side1 []db.Node, side2 []db.Node) { //Doesn't report
 	wg.Wait()
 }
 
-//GetCutConnections fetches the cut connections on a server
-//TODO: Naive Implementation, does not yet take multiple servers into account
-func GetCutConnections(client ssh.Client) ([]Connection, error) {
-	res, err := client.Run("sudo iptables --list-rules | grep wb_bridge | grep DROP | grep FORWARD | awk '{print $4,$6}' | sed -e 's/\\/32//g' || true")
-	if err != nil {
-		return nil, util.LogError(err)
+// RemoveTestNetOutages removes all of the outages of a testnet, on all of the servers of the given
+// nodes, along with their stored intent
+func RemoveTestNetOutages(testnetID string, nodes []db.Node) error {
+	err := removeNodeOutages(nodes, nodes)
+	if err == nil {
+		err = db.DeleteOutages(testnetID)
 	}
-	out := []Connection{}
-	if len(res) == 0 { //No cut connections on this server
-		return out, nil
+	return recordOperation("outage_remove_testnet", util.LogError(err))
+}
+
+// parseOutageRule gets the bridge and the destination ip which the given outage rule matches on
+func parseOutageRule(rule string) (bridge string, ip string, ok bool) {
+	fields := strings.Fields(rule)
+	for i := 0; i < len(fields)-1; i++ {
+		switch fields[i] {
+		case "-i":
+			bridge = fields[i+1]
+		case "-d":
+			ip = strings.TrimSuffix(fields[i+1], "/32")
+		}
 	}
+	return bridge, ip, len(bridge) > 0 && len(ip) > 0
+}
 
-	cuts := strings.Split(res, "\n")
+// parseCutConnections converts the outage rules found on a server into connections between the
+// given nodes. Rules which do not involve the given nodes, such as those of another testnet,
+// are skipped.
+func parseCutConnections(rules string, serverID int, nodes []db.Node) []Connection {
+	bridges := map[string]int{}
+	ips := map[string]int{}
+	for _, node := range nodes {
+		if node.Server == serverID {
+			bridges[outageBridge(node)] = node.AbsoluteNum
+		}
+		ips[node.IP] = node.AbsoluteNum
+	}
 
-	for _, cut := range cuts {
-		if len(cut) == 0 {
+	out := []Connection{}
+	for _, rule := range strings.Split(rules, "\n") {
+		if len(rule) == 0 {
 			continue
 		}
-		cutPair := strings.Split(cut, " ")
-		if len(cutPair) != 2 {
-			return nil, fmt.Errorf("unexpected result \"%s\" for cut pair", cut)
+		bridge, ip, ok := parseOutageRule(rule)
+		from, fromOk := bridges[bridge]
+		to, toOk := ips[ip]
+		if !ok || !fromOk || !toOk {
+			log.WithFields(log.Fields{"rule": rule, "server": serverID}).Debug("skipping unknown outage rule")
+			continue
 		}
-		_, toNode, _ := util.GetInfoFromIP(cutPair[0])
+		out = append(out, Connection{To: to, From: from})
+	}
+	return out
+}
 
-		if len(cutPair[1]) <= len(conf.BridgePrefix) {
-			return nil, fmt.Errorf("unexpected source interface, found \"%s\"", cutPair[1])
-		}
+// GetCutConnections fetches the connections between the given nodes which are cut on a server
+func GetCutConnections(client ssh.Client, serverID int, nodes []db.Node) ([]Connection, error) {
+	res, err := client.Run("sudo iptables --list-rules | grep wb_bridge | grep DROP | grep FORWARD || true")
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return parseCutConnections(res, serverID, nodes), nil
+}
 
-		fromNode, err := strconv.Atoi(cutPair[1][len(conf.BridgePrefix):])
+// GetActualOutages fetches the connections between the given nodes which are cut, on all of
+// the servers of the given nodes
+func GetActualOutages(nodes []db.Node) ([]Connection, error) {
+	out := []Connection{}
+	for _, serverID := range db.GetUniqueServerIDs(nodes) {
+		client, err := status.GetClient(serverID)
 		if err != nil {
 			return nil, util.LogError(err)
 		}
-		out = append(out, Connection{To: toNode, From: fromNode})
+		conns, err := GetCutConnections(client, serverID, nodes)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, conns...)
 	}
 	return out, nil
 }
 
-//CalculatePartitions calculates the current partitions in the network
-func CalculatePartitions(nodes []db.Node) ([][]int, error) {
-	clients, err := status.GetClientsFromNodes(nodes)
+// GetOutages fetches the connections which are intended to be cut in a testnet
+func GetOutages(testnetID string) ([]Connection, error) {
+	outages, err := db.GetOutages(testnetID)
 	if err != nil {
 		return nil, util.LogError(err)
 	}
-	cutConnections := []Connection{}
-	for _, client := range clients {
-		conns, err := GetCutConnections(client)
-		if err != nil {
-			return nil, util.LogError(err)
+	out := []Connection{}
+	for _, outage := range outages {
+		out = append(out, Connection{To: outage.To, From: outage.From})
+	}
+	return out, nil
+}
+
+// OutageDrift is the difference between the intended and the actual outages of a testnet
+type OutageDrift struct {
+	// Missing are the connections which should be cut, but are not
+	Missing []Connection `json:"missing"`
+
+	// Extra are the connections which are cut, but should not be
+	Extra []Connection `json:"extra"`
+}
+
+// compareOutages calculates the drift of the actual outages from the intended outages
+func compareOutages(intended []Connection, actual []Connection) OutageDrift {
+	intendedSet := map[Connection]bool{}
+	for _, conn := range intended {
+		intendedSet[conn] = true
+	}
+	actualSet := map[Connection]bool{}
+	for _, conn := range actual {
+		actualSet[conn] = true
+	}
+
+	out := OutageDrift{Missing: []Connection{}, Extra: []Connection{}}
+	for _, conn := range intended {
+		if !actualSet[conn] {
+			out.Missing = append(out.Missing, conn)
+			actualSet[conn] = true
+		}
+	}
+	for _, conn := range actual {
+		if !intendedSet[conn] {
+			out.Extra = append(out.Extra, conn)
+			intendedSet[conn] = true
+		}
+	}
+	return out
+}
+
+// GetOutageDrift calculates the drift of the outages applied to the given nodes of a testnet
+// from the stored intent
+func GetOutageDrift(testnetID string, nodes []db.Node) (OutageDrift, error) {
+	intended, err := GetOutages(testnetID)
+	if err != nil {
+		return OutageDrift{}, util.LogError(err)
+	}
+	actual, err := GetActualOutages(nodes)
+	if err != nil {
+		return OutageDrift{}, util.LogError(err)
+	}
+	return compareOutages(intended, actual), nil
+}
+
+// ReconcileOutages applies the missing outages and removes the extra outages of a testnet, so
+// that they match the stored intent. The drift which was corrected is returned.
+func ReconcileOutages(testnetID string, nodes []db.Node) (OutageDrift, error) {
+	drift, err := reconcileOutages(testnetID, nodes)
+	return drift, recordOperation("outage_reconcile", err)
+}
+
+func reconcileOutages(testnetID string, nodes []db.Node) (OutageDrift, error) {
+	drift, err := GetOutageDrift(testnetID, nodes)
+	if err != nil {
+		return drift, util.LogError(err)
+	}
+	byNum := map[int]db.Node{}
+	for _, node := range nodes {
+		byNum[node.AbsoluteNum] = node
+	}
+	for _, changes := range []struct {
+		conns  []Connection
+		create bool
+	}{{drift.Missing, true}, {drift.Extra, false}} {
+		for _, conn := range changes.conns {
+			from, fromOk := byNum[conn.From]
+			to, toOk := byNum[conn.To]
+			if !fromOk || !toOk {
+				log.WithFields(log.Fields{"testnet": testnetID, "from": conn.From,
+					"to": conn.To}).Warn("skipping outage involving a node which does not exist")
+				continue
+			}
+			err = setOutageRule(from, to, changes.create)
+			if err != nil {
+				return drift, util.LogError(err)
+			}
+		}
+	}
+	return drift, nil
+}
+
+// buildConnections creates the graph of the connections between the given nodes when the given
+// connections are cut, along with the absolute numbers of the nodes, in the order they are indexed in the graph
+func buildConnections(nodes []db.Node, cuts []Connection) (*Connections, []int) {
+	nums := []int{}
+	for _, node := range nodes {
+		nums = append(nums, node.AbsoluteNum)
+	}
+	sort.Ints(nums)
+	index := map[int]int{}
+	for i, num := range nums {
+		index[num] = i
+	}
+
+	dense := []Connection{}
+	for _, cut := range cuts {
+		from, fromOk := index[cut.From]
+		to, toOk := index[cut.To]
+		if fromOk && toOk {
+			dense = append(dense, Connection{To: to, From: from})
 		}
-		cutConnections = append(cutConnections, conns...)
 	}
 
-	conns := NewConnections(len(nodes))
+	conns := NewConnections(len(nums))
+	conns.RemoveAll(dense)
+	return conns, nums
+}
 
-	conns.RemoveAll(cutConnections)
+// toAbsoluteNums converts the indexes of nodes in a graph back into their absolute numbers
+func toAbsoluteNums(indexes []int, nums []int) []int {
+	out := []int{}
+	for _, i := range indexes {
+		out = append(out, nums[i])
+	}
+	sort.Ints(out)
+	return out
+}
+
+// partitionNodes calculates the partitions of the given nodes when the given connections are
+// cut, giving each partition as the absolute numbers of the nodes in it
+func partitionNodes(nodes []db.Node, cuts []Connection) [][]int {
+	conns, nums := buildConnections(nodes, cuts)
+	out := [][]int{}
+	for _, network := range conns.Networks() {
+		out = append(out, toAbsoluteNums(network, nums))
+	}
+	return out
+}
+
+// reachableNodes calculates the nodes which each of the given nodes is able to reach when the
+// given connections are cut, keyed by absolute number
+func reachableNodes(nodes []db.Node, cuts []Connection) map[int][]int {
+	conns, nums := buildConnections(nodes, cuts)
+	out := map[int][]int{}
+	for i, reachable := range conns.Reachability() {
+		out[nums[i]] = toAbsoluteNums(reachable, nums)
+	}
+	return out
+}
 
-	return conns.Networks(), nil
+// CalculatePartitions calculates the partitions of the given nodes of a testnet, from the stored outages.
+// Nodes are only in the same partition if they are able to reach each other, directly or through other nodes.
+func CalculatePartitions(testnetID string, nodes []db.Node) ([][]int, error) {
+	cuts, err := GetOutages(testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return partitionNodes(nodes, cuts), nil
+}
+
+// CalculateReachability calculates the nodes which each of the given nodes of a testnet is able to
+// send traffic to, directly or through other nodes, from the stored outages
+func CalculateReachability(testnetID string, nodes []db.Node) (map[int][]int, error) {
+	cuts, err := GetOutages(testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return reachableNodes(nodes, cuts), nil
 }
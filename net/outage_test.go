@@ -19,9 +19,12 @@
 package netconf
 
 import (
+	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/ssh/mocks"
 )
 
@@ -35,3 +38,157 @@ func TestRemoveAllOutages(t *testing.T) {
 
 	RemoveAllOutages(client)
 }
+
+func TestIsNodeOutageRule(t *testing.T) {
+	var test = []struct {
+		rule     string
+		bridges  []string
+		ips      []string
+		expected bool
+	}{
+		{rule: "-A FORWARD -d 10.1.0.6/32 -i wb_bridge1 -j DROP", bridges: []string{"wb_bridge1"}, expected: true},
+		{rule: "-A FORWARD -d 10.1.0.6/32 -i wb_bridge1 -j DROP", ips: []string{"10.1.0.6"}, expected: true},
+		{rule: "-A FORWARD -d 10.1.0.6/32 -i wb_bridge1 -j DROP", bridges: []string{"wb_bridge10"},
+			ips: []string{"10.1.0.60"}, expected: false},
+		{rule: "-A FORWARD -d 10.1.0.6/32 -i wb_bridge12 -j DROP", bridges: []string{"wb_bridge1"}, expected: false},
+		{rule: "-A FORWARD -d 10.1.0.6/32 -i wb_bridge1 -j DROP", expected: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if isNodeOutageRule(tt.rule, tt.bridges, tt.ips) != tt.expected {
+				t.Errorf("isNodeOutageRule(%q, %v, %v) != %v", tt.rule, tt.bridges, tt.ips, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCutConnections(t *testing.T) {
+	nodes := []db.Node{
+		{AbsoluteNum: 0, Server: 1, LocalID: 0, IP: "10.1.0.2"},
+		{AbsoluteNum: 1, Server: 1, LocalID: 1, IP: "10.1.0.6"},
+		{AbsoluteNum: 2, Server: 2, LocalID: 0, IP: "10.2.0.2"},
+	}
+	var test = []struct {
+		rules    string
+		serverID int
+		expected []Connection
+	}{
+		{rules: "", serverID: 1, expected: []Connection{}},
+		{
+			rules:    "-A FORWARD -d 10.1.0.6/32 -i wb_bridge0 -j DROP\n-A FORWARD -d 10.2.0.2/32 -i wb_bridge1 -j DROP\n",
+			serverID: 1,
+			expected: []Connection{{From: 0, To: 1}, {From: 1, To: 2}},
+		},
+		{
+			rules:    "-A FORWARD -d 10.1.0.2/32 -i wb_bridge0 -j DROP\n",
+			serverID: 2,
+			expected: []Connection{{From: 2, To: 0}},
+		},
+		{
+			rules:    "-A FORWARD -d 10.3.0.2/32 -i wb_bridge0 -j DROP\n-A FORWARD -d 10.1.0.2/32 -i wb_bridge5 -j DROP\n",
+			serverID: 1,
+			expected: []Connection{},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := parseCutConnections(tt.rules, tt.serverID, nodes)
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("parseCutConnections returned %v, expected %v", out, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompareOutages(t *testing.T) {
+	var test = []struct {
+		intended []Connection
+		actual   []Connection
+		expected OutageDrift
+	}{
+		{
+			intended: []Connection{},
+			actual:   []Connection{},
+			expected: OutageDrift{Missing: []Connection{}, Extra: []Connection{}},
+		},
+		{
+			intended: []Connection{{From: 0, To: 1}, {From: 1, To: 0}},
+			actual:   []Connection{{From: 1, To: 0}},
+			expected: OutageDrift{Missing: []Connection{{From: 0, To: 1}}, Extra: []Connection{}},
+		},
+		{
+			intended: []Connection{{From: 0, To: 1}},
+			actual:   []Connection{{From: 0, To: 1}, {From: 2, To: 3}, {From: 2, To: 3}},
+			expected: OutageDrift{Missing: []Connection{}, Extra: []Connection{{From: 2, To: 3}}},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := compareOutages(tt.intended, tt.actual)
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("compareOutages returned %v, expected %v", out, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPartitionNodes(t *testing.T) {
+	nodes := []db.Node{{AbsoluteNum: 0}, {AbsoluteNum: 2}, {AbsoluteNum: 5}, {AbsoluteNum: 7}}
+	var test = []struct {
+		cuts     []Connection
+		expected [][]int
+	}{
+		{cuts: []Connection{}, expected: [][]int{{0, 2, 5, 7}}},
+		{
+			cuts: []Connection{{From: 0, To: 5}, {From: 5, To: 0}, {From: 0, To: 7}, {From: 7, To: 0},
+				{From: 2, To: 5}, {From: 5, To: 2}, {From: 2, To: 7}, {From: 7, To: 2}},
+			expected: [][]int{{0, 2}, {5, 7}},
+		},
+		{cuts: []Connection{{From: 0, To: 2}}, expected: [][]int{{0, 2, 5, 7}}},
+		{cuts: []Connection{{From: 0, To: 2}, {From: 0, To: 5}, {From: 0, To: 7}}, expected: [][]int{{0}, {2, 5, 7}}},
+		{
+			cuts:     []Connection{{From: 0, To: 7}, {From: 7, To: 0}, {From: 2, To: 7}, {From: 7, To: 2}},
+			expected: [][]int{{0, 2, 5, 7}},
+		},
+		{cuts: []Connection{{From: 3, To: 9}}, expected: [][]int{{0, 2, 5, 7}}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := partitionNodes(nodes, tt.cuts)
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("partitionNodes returned %v, expected %v", out, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReachableNodes(t *testing.T) {
+	nodes := []db.Node{{AbsoluteNum: 1}, {AbsoluteNum: 4}, {AbsoluteNum: 6}}
+	var test = []struct {
+		cuts     []Connection
+		expected map[int][]int
+	}{
+		{cuts: []Connection{}, expected: map[int][]int{1: {1, 4, 6}, 4: {1, 4, 6}, 6: {1, 4, 6}}},
+		{
+			cuts:     []Connection{{From: 1, To: 4}, {From: 1, To: 6}},
+			expected: map[int][]int{1: {1}, 4: {1, 4, 6}, 6: {1, 4, 6}},
+		},
+		{
+			cuts:     []Connection{{From: 1, To: 6}, {From: 6, To: 1}},
+			expected: map[int][]int{1: {1, 4, 6}, 4: {1, 4, 6}, 6: {1, 4, 6}},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := reachableNodes(nodes, tt.cuts)
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("reachableNodes returned %v, expected %v", out, tt.expected)
+			}
+		})
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -53,54 +54,32 @@ func build(tn *testnet.TestNet) error {
 	if err != nil {
 		return util.LogError(err)
 	}
-	fetchedConfChan := make(chan string)
-
-	go func(aconf artemisConf) {
-		res, err := util.HTTPRequest("GET", aconf["constantsSource"].(string), "")
-		if err != nil {
-			tn.BuildState.ReportError(err)
-			return
-		}
-		fetchedConfChan <- string(res)
-
-	}(aconf)
+	fetchedConfChan := fetchConstants(tn, aconf)
 
 	tn.BuildState.SetBuildSteps(0 + (tn.LDD.Nodes * 4))
 
-	port := 9000
-	peers := "["
-	var peer string
-	for i, node := range tn.Nodes {
-		peer = fmt.Sprintf("%s://whiteblock-node%d@%s:%d",
-			aconf["networkMode"],
-			node.LocalID,
-			node.IP,
-			port,
-		)
-		if i != len(tn.Nodes)-1 {
-			peers = peers + "\"" + peer + "\"" + ","
-		} else {
-			peers = peers + "\"" + peer + "\""
-		}
-		tn.BuildState.IncrementBuildProgress()
-	}
-
-	peers = peers + "]"
-	log.WithFields(log.Fields{"peers": peers}).Trace("generated the peers")
+	peers := getPeers(tn, aconf)
+	tn.BuildState.IncrementBuildProgress()
 
 	tn.BuildState.SetBuildStage("Creating node configuration files")
 	/**Create node config files**/
-	fetchedConf := <-fetchedConfChan
-
-	constantsIndex := strings.Index(fetchedConf, "[constants]")
-	if constantsIndex == -1 {
-		return util.LogError(fmt.Errorf("couldn't find \"[constants]\" in file fetched from given source"))
+	validators, perNode, err := getValidators(aconf, tn.LDD.Nodes)
+	if err != nil {
+		return util.LogError(err)
+	}
+	rawConstants, err := getConstants(<-fetchedConfChan)
+	if err != nil {
+		return util.LogError(err)
 	}
-	rawConstants := fetchedConf[constantsIndex:]
 	err = helpers.CreateConfigs(tn, "/artemis/config/config.toml", func(node ssh.Node) ([]byte, error) {
 		defer tn.BuildState.IncrementBuildProgress()
+		first, last, err := helpers.GenesisValidatorRange(node, perNode, validators)
+		if err != nil {
+			return nil, err
+		}
 		identity := fmt.Sprintf("0x%.8x", node.GetAbsoluteNumber())
-		artemisNodeConfig, err := makeNodeConfig(aconf, identity, peers, node.GetAbsoluteNumber(), tn.LDD, rawConstants)
+		artemisNodeConfig, err := makeNodeConfig(aconf, identity, peers, node.GetAbsoluteNumber(), tn.LDD.Nodes,
+			first, last-first, tn.LDD, rawConstants)
 		return []byte(artemisNodeConfig), err
 	})
 	if err != nil {
@@ -110,28 +89,138 @@ func build(tn *testnet.TestNet) error {
 	tn.BuildState.SetBuildStage("Starting Artemis")
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
 		defer tn.BuildState.IncrementBuildProgress()
-		var logFolder string
-		obj := tn.CombinedDetails.Params["logFolder"]
-		if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
-			logFolder = obj.(string)
-		} else {
-			logFolder = ""
-		}
-		artemisCmd := fmt.Sprintf("artemis -c /artemis/config/config.toml 2>&1 | tee %s/output%d.log", logFolder, node.GetAbsoluteNumber())
+		return startNode(tn, client, node)
+	})
+	return util.LogError(err)
+}
+
+// add handles adding nodes to the artemis testnet. The new nodes are given the same genesis as the original
+// nodes, and each runs its own range of the validators in the genesis.
+func add(tn *testnet.TestNet) error {
+	aconf, err := newConf(tn.Details[0].Params)
+	if err != nil {
+		return util.LogError(err)
+	}
+	fetchedConfChan := fetchConstants(tn, aconf)
+
+	tn.BuildState.SetBuildSteps(1 + (len(tn.NewlyBuiltNodes) * 2))
+
+	peers := getPeers(tn, aconf)
+	tn.BuildState.IncrementBuildProgress()
+
+	//the genesis must match that of the original nodes
+	originalNodes := tn.Details[0].Nodes
+	validators, perNode, err := getValidators(aconf, originalNodes)
+	if err != nil {
+		return util.LogError(err)
+	}
 
-		_, err := client.DockerExecd(node, "tmux new -s whiteblock -d")
+	tn.BuildState.SetBuildStage("Creating the configuration files of the new nodes")
+	rawConstants, err := getConstants(<-fetchedConfChan)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = helpers.CreateConfigsNewNodes(tn, "/artemis/config/config.toml", func(node ssh.Node) ([]byte, error) {
+		defer tn.BuildState.IncrementBuildProgress()
+		first, last, err := helpers.GenesisValidatorRange(node, perNode, validators)
 		if err != nil {
-			return util.LogError(err)
+			return nil, err
 		}
-
-		_, err = client.DockerExecd(node, fmt.Sprintf("tmux send-keys -t whiteblock '%s' C-m", artemisCmd))
+		identity := fmt.Sprintf("0x%.8x", node.GetAbsoluteNumber())
+		artemisNodeConfig, err := makeNodeConfig(aconf, identity, peers, node.GetAbsoluteNumber(), originalNodes,
+			first, last-first, &tn.CombinedDetails, rawConstants)
+		return []byte(artemisNodeConfig), err
+	})
+	if err != nil {
 		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Starting the new Artemis nodes")
+	err = helpers.AllNewNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		return startNode(tn, client, node)
 	})
 	return util.LogError(err)
 }
 
-// Add handles adding a node to the artemis testnet
-// TODO
-func add(tn *testnet.TestNet) error {
-	return nil
+// getValidators gives the number of validators in the genesis, and the number of those validators run by each node.
+// Unless validatorsPerNode is given, the validators are divided evenly among the nodes of the original build.
+func getValidators(aconf artemisConf, originalNodes int) (int, int, error) {
+	//the values are either a float64 from the defaults or a json.Number from the given params
+	validators, err := strconv.Atoi(fmt.Sprint(aconf["validators"]))
+	if err != nil {
+		return -1, -1, fmt.Errorf("invalid validators: %s", err.Error())
+	}
+	perNode := 0
+	if aconf["validatorsPerNode"] != nil {
+		perNode, err = strconv.Atoi(fmt.Sprint(aconf["validatorsPerNode"]))
+		if err != nil {
+			return -1, -1, fmt.Errorf("invalid validatorsPerNode: %s", err.Error())
+		}
+	}
+	if perNode <= 0 && originalNodes > 0 {
+		perNode = validators / originalNodes
+	}
+	return validators, perNode, nil
+}
+
+// fetchConstants fetches the constants from the configured source in the background
+func fetchConstants(tn *testnet.TestNet, aconf artemisConf) chan string {
+	fetchedConfChan := make(chan string)
+
+	go func(aconf artemisConf) {
+		res, err := util.HTTPRequest("GET", aconf["constantsSource"].(string), "")
+		if err != nil {
+			tn.BuildState.ReportError(err)
+			return
+		}
+		fetchedConfChan <- string(res)
+
+	}(aconf)
+	return fetchedConfChan
+}
+
+// getConstants extracts the constants section from the fetched configuration
+func getConstants(fetchedConf string) (string, error) {
+	constantsIndex := strings.Index(fetchedConf, "[constants]")
+	if constantsIndex == -1 {
+		return "", fmt.Errorf("couldn't find \"[constants]\" in file fetched from given source")
+	}
+	return fetchedConf[constantsIndex:], nil
+}
+
+// getPeers creates the list of peers from all of the nodes
+func getPeers(tn *testnet.TestNet, aconf artemisConf) string {
+	port := 9000
+	peers := []string{}
+	for _, node := range tn.Nodes {
+		peers = append(peers, fmt.Sprintf("\"%s://whiteblock-node%d@%s:%d\"",
+			aconf["networkMode"],
+			node.LocalID,
+			node.IP,
+			port,
+		))
+	}
+	out := "[" + strings.Join(peers, ",") + "]"
+	log.WithFields(log.Fields{"peers": out}).Trace("generated the peers")
+	return out
+}
+
+func startNode(tn *testnet.TestNet, client ssh.Client, node ssh.Node) error {
+	var logFolder string
+	obj := tn.CombinedDetails.Params["logFolder"]
+	if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
+		logFolder = obj.(string)
+	} else {
+		logFolder = ""
+	}
+	artemisCmd := fmt.Sprintf("artemis -c /artemis/config/config.toml 2>&1 | tee %s/output%d.log", logFolder, node.GetAbsoluteNumber())
+
+	_, err := client.DockerExecd(node, "tmux new -s whiteblock -d")
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	_, err = client.DockerExecd(node, fmt.Sprintf("tmux send-keys -t whiteblock '%s' C-m", artemisCmd))
+	return util.LogError(err)
 }
@@ -59,7 +59,8 @@ func GetServices() []helpers.Service {
 	}
 }
 
-func makeNodeConfig(aconf artemisConf, identity string, peers string, node int, details *db.DeploymentDetails, constantsRaw string) (string, error) {
+func makeNodeConfig(aconf artemisConf, identity string, peers string, node int, numNodes int, firstValidator int,
+	ownedValidators int, details *db.DeploymentDetails, constantsRaw string) (string, error) {
 
 	artConf, err := util.CopyMap(aconf)
 	if err != nil {
@@ -68,7 +69,9 @@ func makeNodeConfig(aconf artemisConf, identity string, peers string, node int,
 	artConf["identity"] = identity
 	filler := util.ConvertToStringMap(artConf)
 	filler["peers"] = peers
-	filler["numNodes"] = fmt.Sprintf("%d", details.Nodes)
+	filler["numNodes"] = fmt.Sprintf("%d", numNodes)
+	filler["ownedValidatorStartIndex"] = fmt.Sprintf("%d", firstValidator)
+	filler["ownedValidatorCount"] = fmt.Sprintf("%d", ownedValidators)
 	var outputFile string
 	obj := details.Params["outputFile"]
 	if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
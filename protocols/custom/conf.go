@@ -0,0 +1,75 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package custom
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/util"
+	"strings"
+)
+
+// manifest describes how to bring up a network of an arbitrary client
+type manifest struct {
+	// Templates maps the destination path in the node to a mustache template for that file
+	Templates map[string]string `json:"templates"`
+	// Init is a list of commands run in each node before the templates are rendered
+	Init []string `json:"init"`
+	// KeyCommand is run in each node after init, its trimmed output is available as the node's key
+	KeyCommand string `json:"keyCommand"`
+	// PeerFormat is the mustache template for a single peer
+	PeerFormat string `json:"peerFormat"`
+	// PeerSeparator joins the formatted peers together
+	PeerSeparator string `json:"peerSeparator"`
+	// Start is the mustache template for the command which starts the node
+	Start string `json:"start"`
+}
+
+func newConf(data map[string]interface{}) (*manifest, error) {
+	out := new(manifest)
+	err := helpers.HandleBlockchainConfig(blockchain, data, out)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return out, out.validate()
+}
+
+// validate checks that the manifest can be safely used to build a network
+func (mf manifest) validate() error {
+	if len(mf.Start) == 0 {
+		return fmt.Errorf("the manifest must have a start command")
+	}
+	//unlike init and keyCommand, which are wrapped by inShell, the start command is logged and cannot contain quotes
+	if strings.Contains(mf.Start, "'") {
+		return fmt.Errorf("the start command cannot contain ' characters")
+	}
+	//the templates are copied into the nodes by a command run on the host
+	for path := range mf.Templates {
+		err := util.ValidateNodePath(path)
+		if err != nil {
+			return fmt.Errorf("invalid template destination: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// GetServices returns the services which are used by the custom blockchain
+func GetServices() []helpers.Service {
+	return nil
+}
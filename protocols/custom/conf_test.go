@@ -0,0 +1,49 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package custom
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_manifest_validate(t *testing.T) {
+	var test = []struct {
+		mf    manifest
+		valid bool
+	}{
+		{mf: manifest{Start: "node --config /etc/node.toml"}, valid: true},
+		{mf: manifest{Start: "node", Templates: map[string]string{"/etc/node.toml": "id = {{index}}"}}, valid: true},
+		{mf: manifest{}, valid: false},
+		{mf: manifest{Start: "sh -c 'node'"}, valid: false},
+		{mf: manifest{Start: "node", Templates: map[string]string{"etc/node.toml": ""}}, valid: false},
+		{mf: manifest{Start: "node", Templates: map[string]string{"/x;rm -rf ~": ""}}, valid: false},
+		{mf: manifest{Start: "node", Templates: map[string]string{"/etc/$(whoami)": ""}}, valid: false},
+		{mf: manifest{Start: "node", Templates: map[string]string{"/../etc/passwd": ""}}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.mf.validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("validate returned %v, expected valid to be %v", err, tt.valid)
+			}
+		})
+	}
+}
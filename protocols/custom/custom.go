@@ -0,0 +1,200 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package custom handles blockchains which are described entirely by a manifest in the build params
+package custom
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"github.com/whiteblock/mustache"
+)
+
+var conf *util.Config
+
+const blockchain = "custom"
+
+func init() {
+	conf = util.GetConfig()
+	registrar.RegisterBuild(blockchain, build)
+	registrar.RegisterAddNodes(blockchain, add)
+	registrar.RegisterServices(blockchain, GetServices)
+	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
+	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+}
+
+// build builds out a fresh new network from the manifest
+func build(tn *testnet.TestNet) error {
+	mf, err := newConf(tn.LDD.Params)
+	if err != nil {
+		return util.LogError(err)
+	}
+	tn.BuildState.SetBuildSteps(tn.LDD.Nodes * (3 + len(mf.Templates)))
+	return deploy(tn, mf, false)
+}
+
+// add handles adding nodes to a network built from a manifest
+func add(tn *testnet.TestNet) error {
+	mf, err := newConf(tn.CombinedDetails.Params)
+	if err != nil {
+		return util.LogError(err)
+	}
+	tn.BuildState.SetBuildSteps(len(tn.NewlyBuiltNodes)*(2+len(mf.Templates)) + len(tn.Nodes))
+	return deploy(tn, mf, true)
+}
+
+// inShell wraps a command from the manifest so that it is run by a shell inside the node
+func inShell(cmd string) string {
+	return fmt.Sprintf("bash -c '%s'", strings.Replace(cmd, "'", `'"'"'`, -1))
+}
+
+// execOn runs fn on either all of the nodes, or only the new nodes
+func execOn(tn *testnet.TestNet, newOnly bool, fn func(ssh.Client, *db.Server, ssh.Node) error) error {
+	if newOnly {
+		return helpers.AllNewNodeExecCon(tn, fn)
+	}
+	return helpers.AllNodeExecCon(tn, fn)
+}
+
+// deploy runs the manifest on the nodes, or only the new nodes if newOnly is set. Existing nodes
+// still have their key command run, so that the new nodes can peer with them.
+func deploy(tn *testnet.TestNet, mf *manifest, newOnly bool) error {
+	tn.BuildState.SetBuildStage("Running the init commands")
+	err := execOn(tn, newOnly, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		for _, cmd := range mf.Init {
+			_, err := client.DockerExec(node, inShell(cmd))
+			if err != nil {
+				return util.LogError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Gathering the node keys")
+	keys := map[int]string{} //absolute numbers can have gaps after nodes have been removed
+	mux := sync.Mutex{}
+	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		if len(mf.KeyCommand) == 0 {
+			return nil
+		}
+		res, err := client.DockerExec(node, inShell(mf.KeyCommand))
+		if err != nil {
+			return util.LogError(err)
+		}
+		mux.Lock()
+		defer mux.Unlock()
+		keys[node.GetAbsoluteNumber()] = strings.TrimSpace(res)
+		return nil
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	vars, err := nodeVariables(tn, mf, keys)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Creating the configuration files")
+	for dest, tmpl := range mf.Templates {
+		createConfigs := helpers.CreateConfigs
+		if newOnly {
+			createConfigs = helpers.CreateConfigsNewNodes
+		}
+		err = createConfigs(tn, dest, func(node ssh.Node) ([]byte, error) {
+			defer tn.BuildState.IncrementBuildProgress()
+			data, err := mustache.Render(tmpl, vars[node.GetAbsoluteNumber()])
+			return []byte(data), err
+		})
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+
+	tn.BuildState.SetBuildStage("Starting the nodes")
+	return util.LogError(execOn(tn, newOnly, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		cmd, err := mustache.Render(mf.Start, vars[node.GetAbsoluteNumber()])
+		if err != nil {
+			return util.LogError(err)
+		}
+		if strings.Contains(cmd, "'") { //the node variables, such as the key, could still add a quote
+			return fmt.Errorf("the start command of node %d cannot contain ' characters", node.GetAbsoluteNumber())
+		}
+		return client.DockerExecdLog(node, cmd)
+	}))
+}
+
+// nodeVariables creates the template variables for each node, keyed by absolute node number.
+// Each node has ip, index, name, key, numNodes, peers, the formatted peers joined by the peer separator,
+// and peerList, the variables of each peer with the formatted peer as peer.
+func nodeVariables(tn *testnet.TestNet, mf *manifest, keys map[int]string) (map[int]map[string]interface{}, error) {
+	vars := map[int]map[string]interface{}{}
+	for _, node := range tn.Nodes {
+		vars[node.AbsoluteNum] = map[string]interface{}{
+			"ip":       node.IP,
+			"index":    node.AbsoluteNum,
+			"name":     node.GetNodeName(),
+			"key":      keys[node.AbsoluteNum],
+			"numNodes": len(tn.Nodes),
+		}
+	}
+	formatted := map[int]string{}
+	for i, v := range vars {
+		peer, err := mustache.Render(mf.PeerFormat, v)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		formatted[i] = peer
+	}
+	for _, node := range tn.Nodes {
+		i := node.AbsoluteNum
+		peers := []string{}
+		peerList := []map[string]interface{}{}
+		for _, peer := range tn.Nodes {
+			j := peer.AbsoluteNum
+			if i == j {
+				continue
+			}
+			peers = append(peers, formatted[j])
+			peerList = append(peerList, map[string]interface{}{
+				"ip":    vars[j]["ip"],
+				"index": vars[j]["index"],
+				"name":  vars[j]["name"],
+				"key":   vars[j]["key"],
+				"peer":  formatted[j],
+			})
+		}
+		vars[i]["peers"] = strings.Join(peers, mf.PeerSeparator)
+		vars[i]["peerList"] = peerList
+	}
+	return vars, nil
+}
@@ -0,0 +1,80 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package custom
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/testnet"
+)
+
+func Test_nodeVariables(t *testing.T) {
+	//node 1 was removed, and node 3 was added afterwards
+	tn := &testnet.TestNet{Nodes: []db.Node{
+		{AbsoluteNum: 0, LocalID: 0, IP: "10.0.0.2"},
+		{AbsoluteNum: 2, LocalID: 2, IP: "10.0.0.10"},
+		{AbsoluteNum: 3, LocalID: 3, IP: "10.0.0.14"},
+	}}
+	keys := map[int]string{0: "a", 2: "c", 3: "d"}
+	mf := &manifest{PeerFormat: "{{key}}@{{ip}}", PeerSeparator: ","}
+
+	vars, err := nodeVariables(tn, mf, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var test = []struct {
+		absNum int
+		key    string
+		peers  string
+	}{
+		{absNum: 0, key: "a", peers: "c@10.0.0.10,d@10.0.0.14"},
+		{absNum: 2, key: "c", peers: "a@10.0.0.2,d@10.0.0.14"},
+		{absNum: 3, key: "d", peers: "a@10.0.0.2,c@10.0.0.10"},
+	}
+
+	if len(vars) != len(test) {
+		t.Fatalf("expected variables for %d nodes, got %d", len(test), len(vars))
+	}
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			v, ok := vars[tt.absNum]
+			if !ok {
+				t.Fatalf("missing the variables of node %d", tt.absNum)
+			}
+			if v["key"] != tt.key {
+				t.Errorf("expected key \"%s\", got \"%v\"", tt.key, v["key"])
+			}
+			if v["index"] != tt.absNum {
+				t.Errorf("expected index %d, got %v", tt.absNum, v["index"])
+			}
+			if v["numNodes"] != len(tn.Nodes) {
+				t.Errorf("expected numNodes %d, got %v", len(tn.Nodes), v["numNodes"])
+			}
+			if v["peers"] != tt.peers {
+				t.Errorf("expected peers \"%s\", got \"%v\"", tt.peers, v["peers"])
+			}
+			if len(v["peerList"].([]map[string]interface{})) != len(tn.Nodes)-1 {
+				t.Errorf("expected %d peers in the peer list, got %v", len(tn.Nodes)-1, v["peerList"])
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ethereum
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	genesisNonce    = "0x0000000000000042"
+	zeroHash        = "0x0000000000000000000000000000000000000000000000000000000000000000"
+	zeroAddress     = "0x0000000000000000000000000000000000000000"
+	genesisExtra    = "0x3535353535353535353535353535353535353535353535353535353535353535"
+	genesisTime     = "0x00"
+	builtinBalance  = "1"
+	maxCodeSize     = 24576
+	minGasLimit     = 5000
+	gasLimitDivisor = 1024
+	neverActive     = "0x7fffffffffffffff"
+)
+
+const (
+	// ForkDisabled is the block of a fork which never takes effect
+	ForkDisabled int64 = -1
+
+	// MinimumDifficulty is the lowest difficulty which every client enforces the same way. Geth and
+	// pantheon never go below it, so a lower difficulty would cause parity to fork from them.
+	MinimumDifficulty int64 = 131072
+)
+
+// ChainSpec is a client independent description of an ethash chain. It can be translated into
+// the genesis formats of geth, parity and pantheon, which all produce the same genesis block.
+type ChainSpec struct {
+	NetworkID  int64
+	Difficulty int64
+	GasLimit   int64
+
+	HomesteadBlock      int64
+	EIP150Block         int64
+	EIP155Block         int64
+	EIP158Block         int64
+	ByzantiumBlock      int64
+	ConstantinopleBlock int64
+
+	// FixedDifficulty gives every block the same difficulty, when greater than 0. Only pantheon supports it.
+	FixedDifficulty int64
+
+	// Alloc maps the addresses to fund to their initial balance, in wei
+	Alloc map[string]string
+}
+
+// NewChainSpec creates a chain spec with all of the forks active from the genesis block,
+// which funds the given accounts with the given balance
+func NewChainSpec(networkID int64, difficulty int64, gasLimit int64, accounts []*Account, balance string) *ChainSpec {
+	out := &ChainSpec{
+		NetworkID:  networkID,
+		Difficulty: difficulty,
+		GasLimit:   gasLimit,
+		Alloc:      map[string]string{},
+	}
+	for _, acc := range accounts {
+		out.Alloc[acc.HexAddress()] = balance
+	}
+	return out
+}
+
+// builtin describes a precompiled contract for parity, which, unlike geth and pantheon, requires
+// them to be declared in the chain spec
+type builtin struct {
+	address    int
+	name       string
+	activateAt int64
+	pricing    map[string]interface{}
+}
+
+func linearPricing(base int, word int) map[string]interface{} {
+	return map[string]interface{}{"linear": map[string]int{"base": base, "word": word}}
+}
+
+func (cs ChainSpec) builtins() []builtin {
+	return []builtin{
+		{1, "ecrecover", 0, linearPricing(3000, 0)},
+		{2, "sha256", 0, linearPricing(60, 12)},
+		{3, "ripemd160", 0, linearPricing(600, 120)},
+		{4, "identity", 0, linearPricing(15, 3)},
+		{5, "modexp", cs.ByzantiumBlock, map[string]interface{}{"modexp": map[string]int{"divisor": 20}}},
+		{6, "alt_bn128_add", cs.ByzantiumBlock, linearPricing(500, 0)},
+		{7, "alt_bn128_mul", cs.ByzantiumBlock, linearPricing(40000, 0)},
+		{8, "alt_bn128_pairing", cs.ByzantiumBlock, map[string]interface{}{
+			"alt_bn128_pairing": map[string]int{"base": 100000, "pair": 80000}}},
+	}
+}
+
+func builtinAddress(num int) string {
+	return fmt.Sprintf("0x%040x", num)
+}
+
+// alloc gives the full allocation, including the precompiles, which are funded so that they
+// exist in the genesis state of every client
+func (cs ChainSpec) alloc() map[string]map[string]string {
+	out := map[string]map[string]string{}
+	for _, bi := range cs.builtins() {
+		out[builtinAddress(bi.address)] = map[string]string{"balance": builtinBalance}
+	}
+	for addr, balance := range cs.Alloc {
+		if !strings.HasPrefix(addr, "0x") {
+			addr = "0x" + addr
+		}
+		out[strings.ToLower(addr)] = map[string]string{"balance": balance}
+	}
+	return out
+}
+
+// bombDelays gives the difficulty bomb delays for parity, delays which activate at the same
+// block are added together, as they are cumulative
+func (cs ChainSpec) bombDelays() map[string]string {
+	delays := map[int64]int64{}
+	if cs.ByzantiumBlock != ForkDisabled {
+		delays[cs.ByzantiumBlock] += 3000000
+	}
+	if cs.ConstantinopleBlock != ForkDisabled {
+		delays[cs.ConstantinopleBlock] += 2000000
+	}
+	out := map[string]string{}
+	for block, delay := range delays {
+		out[hexInt(block)] = hexInt(delay)
+	}
+	return out
+}
+
+// blockRewards gives the block rewards for parity, later forks replace the reward of earlier
+// forks which activate at the same block
+func (cs ChainSpec) blockRewards() map[string]string {
+	out := map[string]string{hexInt(0): "0x4563918244f40000"}
+	if cs.ByzantiumBlock != ForkDisabled {
+		out[hexInt(cs.ByzantiumBlock)] = "0x29a2241af62c0000"
+	}
+	if cs.ConstantinopleBlock != ForkDisabled {
+		out[hexInt(cs.ConstantinopleBlock)] = "0x1bc16d674ec80000"
+	}
+	return out
+}
+
+func hexInt(i int64) string {
+	return fmt.Sprintf("0x%x", i)
+}
+
+// transition gives the parity transition of a fork, which is never reached for a disabled fork
+func transition(block int64) string {
+	if block == ForkDisabled {
+		return neverActive
+	}
+	return hexInt(block)
+}
+
+// setFork sets the fork block in a geth style config, a disabled fork is left out as it then never takes effect
+func setFork(config map[string]interface{}, name string, block int64) {
+	if block != ForkDisabled {
+		config[name] = block
+	}
+}
+
+// gethStyleGenesis creates the genesis format shared by geth and pantheon
+func (cs ChainSpec) gethStyleGenesis(config map[string]interface{}) ([]byte, error) {
+	config["chainId"] = cs.NetworkID
+	setFork(config, "homesteadBlock", cs.HomesteadBlock)
+	setFork(config, "eip150Block", cs.EIP150Block)
+	setFork(config, "eip155Block", cs.EIP155Block)
+	setFork(config, "eip158Block", cs.EIP158Block)
+	setFork(config, "byzantiumBlock", cs.ByzantiumBlock)
+	setFork(config, "constantinopleBlock", cs.ConstantinopleBlock)
+	if _, ok := config["ethash"]; !ok {
+		config["ethash"] = map[string]interface{}{}
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"config":     config,
+		"nonce":      genesisNonce,
+		"timestamp":  genesisTime,
+		"extraData":  genesisExtra,
+		"gasLimit":   hexInt(cs.GasLimit),
+		"difficulty": hexInt(cs.Difficulty),
+		"mixHash":    zeroHash,
+		"coinbase":   zeroAddress,
+		"parentHash": zeroHash,
+		"alloc":      cs.alloc(),
+	}, "", "    ")
+}
+
+// GethGenesis translates the chain spec into a geth genesis file
+func (cs ChainSpec) GethGenesis() ([]byte, error) {
+	config := map[string]interface{}{}
+	setFork(config, "petersburgBlock", cs.ConstantinopleBlock)
+	return cs.gethStyleGenesis(config)
+}
+
+// PantheonGenesis translates the chain spec into a pantheon genesis file
+func (cs ChainSpec) PantheonGenesis() ([]byte, error) {
+	config := map[string]interface{}{}
+	setFork(config, "constantinopleFixBlock", cs.ConstantinopleBlock)
+	if cs.FixedDifficulty > 0 {
+		config["ethash"] = map[string]interface{}{"fixeddifficulty": cs.FixedDifficulty}
+	}
+	return cs.gethStyleGenesis(config)
+}
+
+// ParityChainSpec translates the chain spec into a parity chain spec file
+func (cs ChainSpec) ParityChainSpec() ([]byte, error) {
+	accounts := map[string]interface{}{}
+	for addr, acc := range cs.alloc() {
+		accounts[addr] = acc
+	}
+	for _, bi := range cs.builtins() {
+		accounts[builtinAddress(bi.address)] = map[string]interface{}{
+			"balance": builtinBalance,
+			"builtin": map[string]interface{}{
+				"name":        bi.name,
+				"activate_at": transition(bi.activateAt),
+				"pricing":     bi.pricing,
+			},
+		}
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"name": "whiteblock",
+		"engine": map[string]interface{}{
+			"Ethash": map[string]interface{}{
+				"params": map[string]interface{}{
+					"minimumDifficulty":      hexInt(cs.Difficulty),
+					"difficultyBoundDivisor": "0x800",
+					"durationLimit":          "0xd",
+					"blockReward":            cs.blockRewards(),
+					"homesteadTransition":    transition(cs.HomesteadBlock),
+					"eip100bTransition":      transition(cs.ByzantiumBlock),
+					"difficultyBombDelays":   cs.bombDelays(),
+				},
+			},
+		},
+		"params": map[string]interface{}{
+			"networkID":             hexInt(cs.NetworkID),
+			"chainID":               hexInt(cs.NetworkID),
+			"accountStartNonce":     "0x0",
+			"maximumExtraDataSize":  "0x20",
+			"minGasLimit":           hexInt(minGasLimit),
+			"gasLimitBoundDivisor":  hexInt(gasLimitDivisor),
+			"maxCodeSize":           hexInt(maxCodeSize),
+			"maxCodeSizeTransition": transition(cs.EIP158Block),
+			"eip150Transition":      transition(cs.EIP150Block),
+			"eip155Transition":      transition(cs.EIP155Block),
+			"eip160Transition":      transition(cs.EIP158Block),
+			"eip161abcTransition":   transition(cs.EIP158Block),
+			"eip161dTransition":     transition(cs.EIP158Block),
+			"eip140Transition":      transition(cs.ByzantiumBlock),
+			"eip211Transition":      transition(cs.ByzantiumBlock),
+			"eip214Transition":      transition(cs.ByzantiumBlock),
+			"eip658Transition":      transition(cs.ByzantiumBlock),
+			"eip145Transition":      transition(cs.ConstantinopleBlock),
+			"eip1014Transition":     transition(cs.ConstantinopleBlock),
+			"eip1052Transition":     transition(cs.ConstantinopleBlock),
+			"eip98Transition":       neverActive,
+		},
+		"genesis": map[string]interface{}{
+			"seal": map[string]interface{}{
+				"ethereum": map[string]string{
+					"nonce":   genesisNonce,
+					"mixHash": zeroHash,
+				},
+			},
+			"author":     zeroAddress,
+			"timestamp":  genesisTime,
+			"parentHash": zeroHash,
+			"extraData":  genesisExtra,
+			"difficulty": hexInt(cs.Difficulty),
+			"gasLimit":   hexInt(cs.GasLimit),
+		},
+		"accounts": accounts,
+	}, "", "    ")
+}
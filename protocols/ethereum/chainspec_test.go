@@ -0,0 +1,112 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ethereum
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestChainSpec_golden(t *testing.T) {
+	var test = []struct {
+		name string
+		spec ChainSpec
+	}{
+		{
+			name: "genesis_forks",
+			spec: ChainSpec{
+				NetworkID:  15468,
+				Difficulty: 131072,
+				GasLimit:   4000000,
+				Alloc:      map[string]string{"0x8D12A197cB00D4747a1fe03395095ce2A5CC6819": "100000000000000000000"},
+			},
+		},
+		{
+			name: "staggered_forks",
+			spec: ChainSpec{
+				NetworkID:           1337,
+				Difficulty:          262144,
+				GasLimit:            8000000,
+				HomesteadBlock:      1,
+				EIP150Block:         2,
+				EIP155Block:         3,
+				EIP158Block:         3,
+				ByzantiumBlock:      5,
+				ConstantinopleBlock: 5,
+				FixedDifficulty:     100,
+				Alloc: map[string]string{
+					"8d12a197cb00d4747a1fe03395095ce2a5cc6819":   "1",
+					"0x0000000000000000000000000000000000000064": "2",
+				},
+			},
+		},
+		{
+			name: "disabled_forks",
+			spec: ChainSpec{
+				NetworkID:           15468,
+				Difficulty:          131072,
+				GasLimit:            4000000,
+				EIP155Block:         10,
+				EIP158Block:         10,
+				ByzantiumBlock:      ForkDisabled,
+				ConstantinopleBlock: ForkDisabled,
+				Alloc:               map[string]string{},
+			},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			outputs := []struct {
+				client string
+				fn     func() ([]byte, error)
+			}{
+				{client: "geth", fn: tt.spec.GethGenesis},
+				{client: "parity", fn: tt.spec.ParityChainSpec},
+				{client: "pantheon", fn: tt.spec.PantheonGenesis},
+			}
+			for _, out := range outputs {
+				res, err := out.fn()
+				if err != nil {
+					t.Fatal(err)
+				}
+				golden := filepath.Join("testdata", tt.name+"."+out.client+".json")
+				if *update {
+					err = ioutil.WriteFile(golden, res, 0644)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				expected, err := ioutil.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(res, expected) {
+					t.Errorf("the %s output for %s does not match %s:\n%s", out.client, tt.name, golden, string(res))
+				}
+			}
+		})
+	}
+}
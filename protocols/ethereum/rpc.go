@@ -0,0 +1,72 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ethereum
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/util"
+)
+
+// RPCCall is a single call in a batch of json rpc calls
+type RPCCall struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// RPCResult is the response to a single json rpc call
+type RPCResult struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// BatchRPC makes a batch of json rpc calls to the client running in the given node, from within that node.
+// The results are keyed by the index of their call.
+func BatchRPC(client ssh.Client, node ssh.Node, port int, calls []RPCCall) (map[int]RPCResult, error) {
+	for i := range calls {
+		calls[i].JSONRPC = "2.0"
+		calls[i].ID = i
+	}
+	data, err := json.Marshal(calls)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	res, err := client.DockerExec(node, fmt.Sprintf(
+		`curl -sS -X POST -H "Content-Type: application/json" --data '%s' http://%s:%d`, data, node.GetIP(), port))
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	var results []RPCResult
+	err = json.Unmarshal([]byte(res), &results)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected response from the node: %s", res)
+	}
+	out := map[int]RPCResult{}
+	for _, result := range results {
+		out[result.ID] = result
+	}
+	return out, nil
+}
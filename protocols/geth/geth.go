@@ -67,6 +67,10 @@ func build(tn *testnet.TestNet) error {
 	if err != nil {
 		return util.LogError(err)
 	}
+	peers, err := helpers.GetPeers(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
 
 	tn.BuildState.SetBuildSteps(8 + (5 * tn.LDD.Nodes))
 
@@ -182,15 +186,12 @@ func build(tn *testnet.TestNet) error {
 		return util.LogError(err)
 	}
 
-	out, err := json.Marshal(staticNodes)
-	if err != nil {
-		return util.LogError(err)
-	}
-
 	tn.BuildState.IncrementBuildProgress()
 	tn.BuildState.SetBuildStage("Starting geth")
-	//Copy static-nodes to every server
-	err = helpers.CopyBytesToAllNodes(tn, string(out), "/geth/static-nodes.json")
+	//Give each node the static-nodes of its peers
+	err = helpers.CreateConfigs(tn, "/geth/static-nodes.json", func(node ssh.Node) ([]byte, error) {
+		return json.Marshal(helpers.SelectPeers(peers[node.GetAbsoluteNumber()], staticNodes))
+	})
 	if err != nil {
 		return util.LogError(err)
 	}
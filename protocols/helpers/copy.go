@@ -199,6 +199,29 @@ func SingleCp(client ssh.Client, buildState *state.BuildState, node ssh.Node, da
 	return client.DockerCp(node, intermediateDst, dest)
 }
 
+// SingleCpArchive copies over the tar archive data and extracts it into the directory dest on the given node.
+func SingleCpArchive(client ssh.Client, buildState *state.BuildState, node ssh.Node, data []byte, dest string) error {
+	tmpFilename, err := util.GetUUIDString()
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = buildState.Write(tmpFilename, string(data))
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	intermediateDst := "/tmp/" + tmpFilename
+	buildState.Defer(func() { client.Run("rm " + intermediateDst) })
+	err = client.Scp(tmpFilename, intermediateDst)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	_, err = client.Run(fmt.Sprintf("docker cp - %s:%s < %s", node.GetNodeName(), dest, intermediateDst))
+	return util.LogError(err)
+}
+
 /*
 	fn func(serverid int, localNodeNum int, absoluteNodeNum int) ([]byte, error)
 */
@@ -0,0 +1,55 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"time"
+)
+
+// GetPeers generates the peers of each node of the testnet, indexed by absolute number, from the topology
+// in the deployment details. Without a topology, every node is given every other node as a peer. The
+// peers and the topology, with the seed it was generated with, are recorded on the testnet so that the
+// applied graph can be retrieved afterwards.
+func GetPeers(tn *testnet.TestNet) ([][]int, error) {
+	topology := util.Topology{Type: util.TopologyFullMesh}
+	if tn.LDD.Topology != nil {
+		topology = *tn.LDD.Topology
+	}
+	if topology.Seed == 0 {
+		topology.Seed = time.Now().UnixNano()
+	}
+	peers, err := util.GenerateTopology(topology, tn.LDD.Nodes)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	tn.SetPeers(topology, peers)
+	return peers, nil
+}
+
+// SelectPeers picks the addresses of the given peers out of the addresses of all of the nodes,
+// which are indexed by absolute number
+func SelectPeers(peers []int, addresses []string) []string {
+	out := []string{}
+	for _, peer := range peers {
+		out = append(out, addresses[peer])
+	}
+	return out
+}
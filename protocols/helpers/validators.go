@@ -0,0 +1,53 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/ssh"
+)
+
+// ValidatorRange gives the range of the validator indexes which belong to the given node, when each
+// node runs perNode validators. The start is inclusive and the end is exclusive. Since the range
+// is derived from the absolute node number, the ranges of nodes added after the build never overlap
+// with those of the existing nodes.
+func ValidatorRange(node ssh.Node, perNode int) (int, int) {
+	start := node.GetAbsoluteNumber() * perNode
+	return start, start + perNode
+}
+
+// GenesisValidators gets the number of validators to create in the genesis. If none are given, there are
+// enough for each of the given number of nodes to run perNode validators.
+func GenesisValidators(given int, perNode int, nodes int) int {
+	if given > 0 {
+		return given
+	}
+	return perNode * nodes
+}
+
+// GenesisValidatorRange is ValidatorRange for networks whose validators are all created in the genesis.
+// Fails if the range of the node goes past the given total number of validators in the genesis.
+func GenesisValidatorRange(node ssh.Node, perNode int, total int) (int, int, error) {
+	first, last := ValidatorRange(node, perNode)
+	if last > total {
+		return -1, -1, fmt.Errorf("node %d needs validators %d to %d, but the genesis only has %d validators. "+
+			"Give more validators when building to leave room for new nodes", node.GetAbsoluteNumber(), first, last-1, total)
+	}
+	return first, last, nil
+}
@@ -23,11 +23,22 @@ import (
 )
 
 type lighthouse struct {
+	// Validators is the number of validators in the genesis, which should leave room for any nodes added later.
+	// Defaults to enough for the nodes of the build to each run ValidatorsPerNode validators.
+	Validators int `json:"validators"`
+	// ValidatorsPerNode is the number of validators run by each node, none are run if it is 0
+	ValidatorsPerNode int `json:"validatorsPerNode"`
 }
 
-func newConf(data map[string]interface{}) (*lighthouse, error) {
+// newConf creates the configuration of a network which was built with the given number of nodes
+func newConf(data map[string]interface{}, nodes int) (*lighthouse, error) {
 	out := new(lighthouse)
-	return out, helpers.HandleBlockchainConfig(blockchain, data, out)
+	err := helpers.HandleBlockchainConfig(blockchain, data, out)
+	if err != nil {
+		return nil, err
+	}
+	out.Validators = helpers.GenesisValidators(out.Validators, out.ValidatorsPerNode, nodes)
+	return out, nil
 }
 
 // GetServices returns the services which are used by lighthouse
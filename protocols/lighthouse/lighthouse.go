@@ -28,6 +28,7 @@ import (
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 	"strings"
+	"time"
 )
 
 var conf *util.Config
@@ -35,6 +36,9 @@ var conf *util.Config
 const (
 	blockchain = "lighthouse"
 	p2pPort    = 9000
+
+	//genesisTimeKey is the build state key of the genesis time, which nodes added later must share
+	genesisTimeKey = "genesisTime"
 )
 
 func init() {
@@ -49,30 +53,75 @@ func init() {
 
 // build builds out a fresh new lighthouse test network
 func build(tn *testnet.TestNet) error {
-	_, err := newConf(tn.LDD.Params)
+	lconf, err := newConf(tn.LDD.Params, tn.LDD.Nodes)
 	if err != nil {
 		return util.LogError(err)
 	}
 	tn.BuildState.SetBuildSteps(1 + (tn.LDD.Nodes * 3))
+	genesisTime := time.Now().Unix()
+	tn.BuildState.Set(genesisTimeKey, genesisTime)
 
-	var bootNodes []string
-	for _, node := range tn.Nodes {
-		bootNodes = append(bootNodes, fmt.Sprintf("/dns4/whiteblock-node%d@%s/tcp/%d", node.LocalID, node.IP, p2pPort))
-	}
-	peers := fmt.Sprintf("--boot-nodes=%s", strings.Join(bootNodes, ","))
+	peers := getBootNodes(tn)
 	tn.BuildState.IncrementBuildProgress()
 
 	tn.BuildState.SetBuildStage("Starting lighthouse")
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		defer tn.BuildState.IncrementBuildProgress()
-
-		lighthouseCmd := "RUST_LOG=libp2p=debug beacon_node --listen-address 0.0.0.0 --port 9000 " + peers + " 2>&1 | tee /output.log"
-		return client.DockerExecdLog(node, lighthouseCmd)
+		return startNode(client, node, peers, lconf, genesisTime)
 	})
 	return util.LogError(err)
 }
 
-// add handles adding nodes to the testnet
+// add handles adding nodes to the testnet, the new nodes use all of the nodes as their boot nodes.
+// The new nodes share the genesis of the original nodes, and each runs its own range of its validators.
 func add(tn *testnet.TestNet) error {
-	return nil
+	lconf, err := newConf(tn.Details[0].Params, tn.Details[0].Nodes)
+	if err != nil {
+		return util.LogError(err)
+	}
+	var genesisTime int64
+	if !tn.BuildState.GetP(genesisTimeKey, &genesisTime) {
+		return fmt.Errorf("the genesis time of the network was not recorded")
+	}
+	tn.BuildState.SetBuildSteps(1 + len(tn.NewlyBuiltNodes))
+
+	peers := getBootNodes(tn)
+	tn.BuildState.IncrementBuildProgress()
+
+	tn.BuildState.SetBuildStage("Starting the new lighthouse nodes")
+	err = helpers.AllNewNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		return startNode(client, node, peers, lconf, genesisTime)
+	})
+	return util.LogError(err)
+}
+
+// getBootNodes creates the boot nodes flag from the multiaddrs of all of the nodes
+func getBootNodes(tn *testnet.TestNet) string {
+	var bootNodes []string
+	for _, node := range tn.Nodes {
+		bootNodes = append(bootNodes, fmt.Sprintf("/dns4/whiteblock-node%d@%s/tcp/%d", node.LocalID, node.IP, p2pPort))
+	}
+	return fmt.Sprintf("--boot-nodes=%s", strings.Join(bootNodes, ","))
+}
+
+// startNode starts the beacon node, along with a validator client running the validators of the node if there are any
+func startNode(client ssh.Client, node ssh.Node, peers string, lconf *lighthouse, genesisTime int64) error {
+	if lconf.ValidatorsPerNode <= 0 {
+		lighthouseCmd := "RUST_LOG=libp2p=debug beacon_node --listen-address 0.0.0.0 --port 9000 " + peers + " 2>&1 | tee /output.log"
+		return client.DockerExecdLog(node, lighthouseCmd)
+	}
+	first, last, err := helpers.GenesisValidatorRange(node, lconf.ValidatorsPerNode, lconf.Validators)
+	if err != nil {
+		return util.LogError(err)
+	}
+	lighthouseCmd := fmt.Sprintf("RUST_LOG=libp2p=debug beacon_node --listen-address 0.0.0.0 --port 9000 %s testnet -f quick %d %d 2>&1 | tee /output.log",
+		peers, lconf.Validators, genesisTime)
+	err = client.DockerExecdLog(node, lighthouseCmd)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = client.DockerExecd(node, fmt.Sprintf("bash -c 'validator_client testnet -b insecure %d %d 2>&1 | tee /validator.log'",
+		first, last-first))
+	return util.LogError(err)
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 	"reflect"
+	"time"
 )
 
 var conf *util.Config
@@ -35,6 +36,9 @@ var conf *util.Config
 const (
 	blockchain = "lodestar"
 	p2pPort    = 9000
+
+	//genesisTimeKey is the build state key of the genesis time, which nodes added later must share
+	genesisTimeKey = "genesisTime"
 )
 
 func init() {
@@ -48,33 +52,72 @@ func init() {
 
 // build builds out a fresh new lighthouse test network
 func build(tn *testnet.TestNet) error {
-	_, err := newConf(tn.LDD.Params)
+	lconf, err := newConf(tn.LDD.Params, tn.LDD.Nodes)
 	if err != nil {
 		return util.LogError(err)
 	}
 	tn.BuildState.SetBuildSteps(1 + (tn.LDD.Nodes * 3))
+	genesisTime := time.Now().Unix()
+	tn.BuildState.Set(genesisTimeKey, genesisTime)
 
-	peers := ""
-	for _, node := range tn.Nodes {
-		peers += fmt.Sprintf(" --peer=/dns4/whiteblock-node%d@%s/tcp/%d ", node.LocalID, node.IP, p2pPort)
-	}
+	peers := getPeers(tn)
 	tn.BuildState.IncrementBuildProgress()
 
 	tn.BuildState.SetBuildStage("Starting lodestar")
 	return helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		defer tn.BuildState.IncrementBuildProgress()
-		var logFolder string
-		obj := tn.CombinedDetails.Params["logFolder"]
-		if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
-			logFolder = obj.(string)
-		} else {
-			logFolder = ""
-		}
-		return client.DockerExecdLog(node, fmt.Sprintf("lodestar --listen-address 0.0.0.0 --port 9000 %s | tee %s/output%d.log", peers, logFolder, node.GetAbsoluteNumber()))
+		return startNode(tn, client, node, peers, lconf, genesisTime)
 	})
 }
 
-// add handles adding nodes to the testnet
+// add handles adding nodes to the testnet, the new nodes are peered with all of the nodes.
+// The new nodes share the genesis of the original nodes, and each runs its own range of its validators.
 func add(tn *testnet.TestNet) error {
-	return nil
+	lconf, err := newConf(tn.Details[0].Params, tn.Details[0].Nodes)
+	if err != nil {
+		return util.LogError(err)
+	}
+	var genesisTime int64
+	if !tn.BuildState.GetP(genesisTimeKey, &genesisTime) {
+		return fmt.Errorf("the genesis time of the network was not recorded")
+	}
+	tn.BuildState.SetBuildSteps(1 + len(tn.NewlyBuiltNodes))
+
+	peers := getPeers(tn)
+	tn.BuildState.IncrementBuildProgress()
+
+	tn.BuildState.SetBuildStage("Starting the new lodestar nodes")
+	return helpers.AllNewNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		return startNode(tn, client, node, peers, lconf, genesisTime)
+	})
+}
+
+// getPeers creates the peer flags from the multiaddrs of all of the nodes
+func getPeers(tn *testnet.TestNet) string {
+	peers := ""
+	for _, node := range tn.Nodes {
+		peers += fmt.Sprintf(" --peer=/dns4/whiteblock-node%d@%s/tcp/%d ", node.LocalID, node.IP, p2pPort)
+	}
+	return peers
+}
+
+// startNode starts lodestar, running the validators of the node if there are any
+func startNode(tn *testnet.TestNet, client ssh.Client, node ssh.Node, peers string, lconf *lodestar, genesisTime int64) error {
+	var logFolder string
+	obj := tn.CombinedDetails.Params["logFolder"]
+	if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
+		logFolder = obj.(string)
+	} else {
+		logFolder = ""
+	}
+	if lconf.ValidatorsPerNode <= 0 {
+		return client.DockerExecdLog(node, fmt.Sprintf("lodestar --listen-address 0.0.0.0 --port 9000 %s | tee %s/output%d.log", peers, logFolder, node.GetAbsoluteNumber()))
+	}
+	first, last, err := helpers.GenesisValidatorRange(node, lconf.ValidatorsPerNode, lconf.Validators)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return client.DockerExecdLog(node, fmt.Sprintf("lodestar interop --quickstart %d,%d --validators %d,%d --listen-address 0.0.0.0 --port 9000 %s | tee %s/output%d.log",
+		genesisTime, lconf.Validators, first, last, peers, logFolder, node.GetAbsoluteNumber()))
 }
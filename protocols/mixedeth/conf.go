@@ -0,0 +1,117 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package mixedeth
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/protocols/ethereum"
+	"github.com/whiteblock/genesis/protocols/helpers"
+)
+
+const (
+	clientGeth     = "geth"
+	clientParity   = "parity"
+	clientPantheon = "pantheon"
+)
+
+// group is a set of consecutive nodes which run the same client, from the given image if there is one
+type group struct {
+	Client string `json:"client"`
+	Nodes  int    `json:"nodes"`
+	Image  string `json:"image"`
+}
+
+type mixedConf struct {
+	Groups              []group `json:"groups"`
+	NetworkID           int64   `json:"networkId"`
+	Difficulty          int64   `json:"difficulty"`
+	GasLimit            int64   `json:"gasLimit"`
+	InitBalance         string  `json:"initBalance"`
+	ExtraAccounts       int64   `json:"extraAccounts"`
+	MaxPeers            int64   `json:"maxPeers"`
+	HomesteadBlock      int64   `json:"homesteadBlock"`
+	EIP150Block         int64   `json:"eip150Block"`
+	EIP155Block         int64   `json:"eip155Block"`
+	EIP158Block         int64   `json:"eip158Block"`
+	ByzantiumBlock      int64   `json:"byzantiumBlock"`
+	ConstantinopleBlock int64   `json:"constantinopleBlock"`
+}
+
+func newConf(data map[string]interface{}) (*mixedConf, error) {
+	out := new(mixedConf)
+	err := helpers.HandleBlockchainConfig(blockchain, data, out)
+	if err != nil {
+		return nil, err
+	}
+	if out.Difficulty < ethereum.MinimumDifficulty {
+		return nil, fmt.Errorf("the difficulty must be at least %d, as the clients would otherwise fork",
+			ethereum.MinimumDifficulty)
+	}
+	return out, nil
+}
+
+// getClients gives the client of each node, checking that the groups cover exactly the given number of nodes
+func (mconf mixedConf) getClients(nodes int) ([]string, error) {
+	out := []string{}
+	for _, grp := range mconf.Groups {
+		switch grp.Client {
+		case clientGeth, clientParity, clientPantheon:
+		default:
+			return nil, fmt.Errorf("unsupported client \"%s\"", grp.Client)
+		}
+		if grp.Nodes < 1 {
+			return nil, fmt.Errorf("the %s group must have at least 1 node", grp.Client)
+		}
+		for i := 0; i < grp.Nodes; i++ {
+			out = append(out, grp.Client)
+		}
+	}
+	if len(out) != nodes {
+		return nil, fmt.Errorf("the groups contain %d nodes, but %d nodes are being built", len(out), nodes)
+	}
+	return out, nil
+}
+
+// checkImages checks that the nodes of each group which gives an image are built from that image.
+// A node uses the image at its index in the given images, or the first image if there isn't one.
+func (mconf mixedConf) checkImages(images []string) error {
+	if len(images) == 0 {
+		return fmt.Errorf("no images were given")
+	}
+	node := 0
+	for _, grp := range mconf.Groups {
+		for i := 0; i < grp.Nodes; i++ {
+			image := images[0]
+			if len(images) > node {
+				image = images[node]
+			}
+			if len(grp.Image) != 0 && image != grp.Image {
+				return fmt.Errorf("node %d is in the %s group, which uses the image \"%s\", but it is built from \"%s\"",
+					node, grp.Client, grp.Image, image)
+			}
+			node++
+		}
+	}
+	return nil
+}
+
+// GetServices returns the services which are used by mixedeth
+func GetServices() []helpers.Service {
+	return nil
+}
@@ -0,0 +1,240 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package mixedeth handles ethereum networks made up of multiple clients
+package mixedeth
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/ethereum"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"strings"
+)
+
+var conf *util.Config
+
+const (
+	blockchain = "mixedeth"
+	p2pPort    = 30303
+	rpcPort    = 8545
+)
+
+func init() {
+	conf = util.GetConfig()
+	registrar.RegisterBuild(blockchain, build)
+	registrar.RegisterAddNodes(blockchain, add)
+	registrar.RegisterServices(blockchain, GetServices)
+	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
+	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+}
+
+// build builds out a fresh new ethereum test network, where each group of nodes runs its own client.
+// All of the clients share the same genesis, accounts, network id and static peers.
+func build(tn *testnet.TestNet) error {
+	mconf, err := newConf(tn.LDD.Params)
+	if err != nil {
+		return util.LogError(err)
+	}
+	clients, err := mconf.getClients(tn.LDD.Nodes)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = mconf.checkImages(tn.LDD.Images)
+	if err != nil {
+		return util.LogError(err)
+	}
+	tn.BuildState.SetBuildSteps(2 + (3 * tn.LDD.Nodes))
+
+	tn.BuildState.SetBuildStage("Creating the accounts")
+	accounts, err := ethereum.GenerateAccounts(tn.LDD.Nodes + int(mconf.ExtraAccounts))
+	if err != nil {
+		return util.LogError(err)
+	}
+	//The node keys are generated up front, so that the static peers are known before any client starts
+	nodeKeys, err := ethereum.GenerateAccounts(tn.LDD.Nodes)
+	if err != nil {
+		return util.LogError(err)
+	}
+	enodes := make([]string, tn.LDD.Nodes)
+	for _, node := range tn.Nodes {
+		enodes[node.AbsoluteNum] = fmt.Sprintf("enode://%s@%s:%d", nodeKeys[node.AbsoluteNum].HexPublicKey(), node.IP, p2pPort)
+	}
+	tn.BuildState.IncrementBuildProgress()
+
+	tn.BuildState.SetBuildStage("Creating the genesis files")
+	spec := ethereum.NewChainSpec(mconf.NetworkID, mconf.Difficulty, mconf.GasLimit, accounts, mconf.InitBalance)
+	spec.HomesteadBlock = mconf.HomesteadBlock
+	spec.EIP150Block = mconf.EIP150Block
+	spec.EIP155Block = mconf.EIP155Block
+	spec.EIP158Block = mconf.EIP158Block
+	spec.ByzantiumBlock = mconf.ByzantiumBlock
+	spec.ConstantinopleBlock = mconf.ConstantinopleBlock
+
+	genesis := map[string][]byte{}
+	genesis[clientGeth], err = spec.GethGenesis()
+	if err != nil {
+		return util.LogError(err)
+	}
+	genesis[clientParity], err = spec.ParityChainSpec()
+	if err != nil {
+		return util.LogError(err)
+	}
+	genesis[clientPantheon], err = spec.PantheonGenesis()
+	if err != nil {
+		return util.LogError(err)
+	}
+	tn.BuildState.IncrementBuildProgress()
+
+	peers, err := helpers.GetPeers(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Configuring the clients")
+	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		return configureNode(tn, client, node, clients[node.GetAbsoluteNumber()], mconf,
+			genesis[clients[node.GetAbsoluteNumber()]], nodeKeys[node.GetAbsoluteNumber()],
+			helpers.SelectPeers(peers[node.GetAbsoluteNumber()], enodes))
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Starting the clients")
+	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		cmd := getStartCommand(clients[node.GetAbsoluteNumber()], mconf, node,
+			nodeKeys[node.GetAbsoluteNumber()], accounts[node.GetAbsoluteNumber()])
+		log.WithFields(log.Fields{"node": node.GetAbsoluteNumber(), "command": cmd}).Trace("starting the client")
+		return client.DockerExecdLog(node, cmd)
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetExt("networkID", mconf.NetworkID)
+	tn.BuildState.SetExt("accounts", ethereum.ExtractAddresses(accounts))
+	tn.BuildState.SetExt("port", rpcPort)
+	tn.BuildState.SetExt("clients", clients)
+	for _, account := range accounts {
+		tn.BuildState.SetExt(account.HexAddress(), map[string]string{
+			"privateKey": account.HexPrivateKey(),
+			"publicKey":  account.HexPublicKey(),
+		})
+	}
+	tn.BuildState.Set("networkID", mconf.NetworkID)
+	tn.BuildState.Set("peers", enodes)
+	return nil
+}
+
+// add handles adding nodes to the testnet, which is not yet supported for mixed networks
+func add(tn *testnet.TestNet) error {
+	return fmt.Errorf("adding nodes is not supported by %s", blockchain)
+}
+
+// configureNode places the genesis file, the node key and the static peers where the given client expects them
+func configureNode(tn *testnet.TestNet, client ssh.Client, node ssh.Node, ethClient string, mconf *mixedConf,
+	genesis []byte, nodeKey *ethereum.Account, peers []string) error {
+
+	_, err := client.DockerExec(node, "which "+ethClient)
+	if err != nil {
+		return fmt.Errorf("the image of node %d does not contain %s", node.GetAbsoluteNumber(), ethClient)
+	}
+	rawPeers, err := json.Marshal(peers)
+	if err != nil {
+		return util.LogError(err)
+	}
+	switch ethClient {
+	case clientGeth:
+		_, err = client.DockerExec(node, "mkdir -p /geth")
+		if err != nil {
+			return util.LogError(err)
+		}
+		err = helpers.SingleCp(client, tn.BuildState, node, genesis, "/geth/genesis.json")
+		if err != nil {
+			return util.LogError(err)
+		}
+		err = helpers.SingleCp(client, tn.BuildState, node, rawPeers, "/geth/static-nodes.json")
+		if err != nil {
+			return util.LogError(err)
+		}
+		_, err = client.DockerExec(node, fmt.Sprintf("geth --datadir /geth/ --networkid %d init /geth/genesis.json", mconf.NetworkID))
+		return util.LogError(err)
+
+	case clientParity:
+		_, err = client.DockerExec(node, "mkdir -p /parity")
+		if err != nil {
+			return util.LogError(err)
+		}
+		err = helpers.SingleCp(client, tn.BuildState, node, genesis, "/parity/spec.json")
+		if err != nil {
+			return util.LogError(err)
+		}
+		return helpers.SingleCp(client, tn.BuildState, node, []byte(strings.Join(peers, "\n")), "/parity/peers.txt")
+
+	case clientPantheon:
+		_, err = client.DockerExec(node, "mkdir -p /pantheon/data")
+		if err != nil {
+			return util.LogError(err)
+		}
+		err = helpers.SingleCp(client, tn.BuildState, node, genesis, "/pantheon/genesis.json")
+		if err != nil {
+			return util.LogError(err)
+		}
+		err = helpers.SingleCp(client, tn.BuildState, node, []byte("0x"+nodeKey.HexPrivateKey()), "/pantheon/data/key")
+		if err != nil {
+			return util.LogError(err)
+		}
+		return helpers.SingleCp(client, tn.BuildState, node, rawPeers, "/pantheon/data/static-nodes.json")
+	}
+	return fmt.Errorf("unsupported client \"%s\"", ethClient)
+}
+
+// getStartCommand gives the command to start the given client, mining to the given account.
+// Parity has no built in ethash miner, so the parity nodes only validate and relay blocks.
+func getStartCommand(ethClient string, mconf *mixedConf, node ssh.Node, nodeKey *ethereum.Account, account *ethereum.Account) string {
+	switch ethClient {
+	case clientGeth:
+		return fmt.Sprintf(`geth --datadir /geth/ --networkid %d --nodekeyhex %s --port %d --nodiscover --maxpeers %d`+
+			` --rpc --rpcaddr 0.0.0.0 --rpcport %d --rpcapi "admin,web3,db,eth,net,personal,miner,txpool"`+
+			` --rpccorsdomain "*" --rpcvhosts "*" --mine --miner.threads 1 --etherbase %s`,
+			mconf.NetworkID, nodeKey.HexPrivateKey(), p2pPort, mconf.MaxPeers, rpcPort, account.HexAddress())
+
+	case clientParity:
+		return fmt.Sprintf(`parity --base-path /parity --chain /parity/spec.json --network-id %d --node-key %s`+
+			` --port %d --no-discovery --reserved-peers /parity/peers.txt --max-peers %d`+
+			` --jsonrpc-interface all --jsonrpc-port %d --jsonrpc-apis all --jsonrpc-hosts all --author %s`,
+			mconf.NetworkID, nodeKey.HexPrivateKey(), p2pPort, mconf.MaxPeers, rpcPort, account.HexAddress())
+
+	case clientPantheon:
+		return fmt.Sprintf(`pantheon --data-path=/pantheon/data --genesis-file=/pantheon/genesis.json --network-id=%d`+
+			` --p2p-port=%d --discovery-enabled=false --max-peers=%d --rpc-http-enabled --rpc-http-host=0.0.0.0`+
+			` --rpc-http-port=%d --rpc-http-api="ADMIN,DEBUG,ETH,MINER,NET,TXPOOL,WEB3" --host-whitelist=all`+
+			` --miner-enabled --miner-coinbase=%s`,
+			mconf.NetworkID, p2pPort, mconf.MaxPeers, rpcPort, account.HexAddress())
+	}
+	return ""
+}
@@ -110,26 +110,25 @@ func build(tn *testnet.TestNet) error {
 		return util.LogError(err)
 	}
 
-	enodes := "["
+	enodes := make([]string, len(tn.Nodes))
 	for i, node := range tn.Nodes {
-		enodeAddress := fmt.Sprintf("enode://%s@%s:%d",
+		enodes[i] = fmt.Sprintf("enode://%s@%s:%d",
 			accounts[i].HexPublicKey(),
 			node.IP,
 			p2pPort)
-		if i != 0 {
-			enodes = enodes + ",\"" + enodeAddress + "\""
-		} else {
-			enodes = enodes + "\"" + enodeAddress + "\""
-		}
 		tn.BuildState.IncrementBuildProgress()
 	}
 
-	enodes = enodes + "]"
-
 	/* Create Static Nodes File */
 	tn.BuildState.SetBuildStage("Setting Up Static Peers")
 	tn.BuildState.IncrementBuildProgress()
-	err = helpers.CopyBytesToAllNodes(tn, enodes, "/pantheon/data/static-nodes.json")
+	peers, err := helpers.GetPeers(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = helpers.CreateConfigs(tn, "/pantheon/data/static-nodes.json", func(node ssh.Node) ([]byte, error) {
+		return json.Marshal(helpers.SelectPeers(peers[node.GetAbsoluteNumber()], enodes))
+	})
 	if err != nil {
 		return util.LogError(err)
 	}
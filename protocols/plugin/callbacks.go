@@ -0,0 +1,230 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package plugin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+// execParams are the params of the exec callback
+type execParams struct {
+	Node    int    `json:"node"`
+	Command string `json:"command"`
+	// Mode is empty to wait for the output, "daemon" to run it in the background or
+	// "log" to run it in the background with its output sent to the docker output file, in which case the
+	// command cannot contain ' characters
+	Mode string `json:"mode"`
+}
+
+// copyParams are the params of the copy callback
+type copyParams struct {
+	// Nodes are the absolute numbers of the nodes to copy to, if empty it is copied to the nodes being built
+	Nodes []int  `json:"nodes"`
+	Dest  string `json:"dest"`
+	// Data is the base64 encoded content of the file
+	Data string `json:"data"`
+}
+
+// createConfigsParams are the params of the createConfigs callback
+type createConfigsParams struct {
+	Dest string `json:"dest"`
+	// Files maps the absolute node number to the base64 encoded content of its file
+	Files map[int]string `json:"files"`
+}
+
+// progressParams are the params of the progress callback, only the fields which are set are applied
+type progressParams struct {
+	Stage     string `json:"stage"`
+	Steps     int    `json:"steps"`
+	Increment int    `json:"increment"`
+}
+
+// callbacks provides the helpers primitives to a plugin while it builds or adds to a testnet
+type callbacks struct {
+	tn      *testnet.TestNet
+	newOnly bool
+}
+
+func (cb *callbacks) handle(method string, params json.RawMessage) (interface{}, error) {
+	var err error
+	decode := func(out interface{}) bool {
+		err = json.Unmarshal(params, out)
+		if err != nil {
+			err = rpcError{Code: errInvalidParams, Message: err.Error()}
+		}
+		return err == nil
+	}
+	switch method {
+	case "exec":
+		var p execParams
+		if decode(&p) {
+			return cb.exec(p)
+		}
+	case "copy":
+		var p copyParams
+		if decode(&p) {
+			return nil, cb.copy(p)
+		}
+	case "createConfigs":
+		var p createConfigsParams
+		if decode(&p) {
+			return nil, cb.createConfigs(p)
+		}
+	case "progress":
+		var p progressParams
+		if decode(&p) {
+			return nil, cb.progress(p)
+		}
+	default:
+		return nil, rpcError{Code: errMethodNotFound, Message: fmt.Sprintf("unknown method \"%s\"", method)}
+	}
+	return nil, err
+}
+
+// getNode gets the node with the given absolute number along with the client of its server
+func (cb *callbacks) getNode(absNum int) (ssh.Client, ssh.Node, error) {
+	for _, node := range cb.tn.Nodes {
+		if node.AbsoluteNum != absNum {
+			continue
+		}
+		client, ok := cb.tn.Clients[node.Server]
+		if !ok {
+			return nil, nil, fmt.Errorf("no client for server %d", node.Server)
+		}
+		return client, node, nil
+	}
+	return nil, nil, fmt.Errorf("node %d does not exist", absNum)
+}
+
+// targets gets the absolute numbers of the nodes which are being built
+func (cb *callbacks) targets() []int {
+	nodes := cb.tn.Nodes
+	if cb.newOnly {
+		nodes = cb.tn.NewlyBuiltNodes
+	}
+	out := []int{}
+	for _, node := range nodes {
+		out = append(out, node.AbsoluteNum)
+	}
+	return out
+}
+
+// forEach concurrently runs fn on each of the given nodes
+func (cb *callbacks) forEach(absNums []int, fn func(ssh.Client, ssh.Node) error) error {
+	wg := sync.WaitGroup{}
+	errMux := sync.Mutex{}
+	var firstErr error
+	for _, absNum := range absNums {
+		client, node, err := cb.getNode(absNum)
+		if err != nil {
+			return util.LogError(err)
+		}
+		wg.Add(1)
+		go func(client ssh.Client, node ssh.Node) {
+			defer wg.Done()
+			err := fn(client, node)
+			if err != nil {
+				errMux.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errMux.Unlock()
+			}
+		}(client, node)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func (cb *callbacks) exec(p execParams) (interface{}, error) {
+	client, node, err := cb.getNode(p.Node)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	var res string
+	switch p.Mode {
+	case "":
+		res, err = client.DockerExec(node, p.Command)
+	case "daemon":
+		res, err = client.DockerExecd(node, p.Command)
+	case "log":
+		if strings.Contains(p.Command, "'") {
+			return nil, rpcError{Code: errInvalidParams, Message: "a command run in log mode cannot contain ' characters"}
+		}
+		err = client.DockerExecdLog(node, p.Command)
+	default:
+		return nil, rpcError{Code: errInvalidParams, Message: fmt.Sprintf("unknown exec mode \"%s\"", p.Mode)}
+	}
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return map[string]string{"output": res}, nil
+}
+
+func (cb *callbacks) copy(p copyParams) error {
+	data, err := base64.StdEncoding.DecodeString(p.Data)
+	if err != nil {
+		return rpcError{Code: errInvalidParams, Message: err.Error()}
+	}
+	nodes := p.Nodes
+	if len(nodes) == 0 {
+		nodes = cb.targets()
+	}
+	return cb.forEach(nodes, func(client ssh.Client, node ssh.Node) error {
+		return helpers.SingleCp(client, cb.tn.BuildState, node, data, p.Dest)
+	})
+}
+
+func (cb *callbacks) createConfigs(p createConfigsParams) error {
+	files := map[int][]byte{}
+	nodes := []int{}
+	for absNum, encoded := range p.Files {
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return rpcError{Code: errInvalidParams, Message: fmt.Sprintf("file for node %d: %s", absNum, err.Error())}
+		}
+		files[absNum] = data
+		nodes = append(nodes, absNum)
+	}
+	return cb.forEach(nodes, func(client ssh.Client, node ssh.Node) error {
+		return helpers.SingleCp(client, cb.tn.BuildState, node, files[node.GetAbsoluteNumber()], p.Dest)
+	})
+}
+
+func (cb *callbacks) progress(p progressParams) error {
+	if len(p.Stage) > 0 {
+		cb.tn.BuildState.SetBuildStage(p.Stage)
+	}
+	if p.Steps > 0 {
+		cb.tn.BuildState.SetBuildSteps(p.Steps)
+	}
+	for i := 0; i < p.Increment; i++ {
+		cb.tn.BuildState.IncrementBuildProgress()
+	}
+	return nil
+}
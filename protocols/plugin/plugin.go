@@ -0,0 +1,183 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package plugin loads blockchain implementations from external executables. A plugin speaks
+// JSON-RPC 2.0 over its stdin and stdout, one message per line.
+package plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+var conf *util.Config
+
+func init() {
+	conf = util.GetConfig()
+}
+
+// description is the result of the describe method, which every plugin must implement
+type description struct {
+	// Params is the parameter schema, in the same format as params.json
+	Params json.RawMessage `json:"params"`
+	// Defaults are the default parameter values, in the same format as defaults.json
+	Defaults json.RawMessage `json:"defaults"`
+	// Services are the services required by the blockchain
+	Services []helpers.SimpleService `json:"services"`
+	// Logs are the additional log files of the nodes
+	Logs map[string]string `json:"logs"`
+}
+
+// buildRequest is the params of the build and add methods
+type buildRequest struct {
+	Details  *db.DeploymentDetails `json:"details"`
+	Nodes    []db.Node             `json:"nodes"`
+	NewNodes []db.Node             `json:"newNodes"`
+}
+
+var (
+	mux     = sync.RWMutex{}
+	plugins = map[string]description{}
+)
+
+// LoadPlugins loads every executable in the configured plugin directory as a blockchain, named after
+// the file without its extension. It must be called after all of the built in blockchains have been registered.
+func LoadPlugins() {
+	files, err := ioutil.ReadDir(conf.PluginDir)
+	if err != nil {
+		log.WithFields(log.Fields{"dir": conf.PluginDir, "error": err}).Debug("not loading any plugins")
+		return
+	}
+	for _, file := range files {
+		if file.IsDir() || file.Mode()&0111 == 0 {
+			continue
+		}
+		name := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+		if _, err := registrar.GetBuildFunc(name); err == nil {
+			log.WithFields(log.Fields{"plugin": name, "file": file.Name()}).Error(
+				"not loading the plugin, as a blockchain with the same name is already registered")
+			continue
+		}
+		err = load(name, filepath.Join(conf.PluginDir, file.Name()))
+		if err != nil {
+			log.WithFields(log.Fields{"plugin": name, "error": err}).Error("failed to load the plugin")
+			continue
+		}
+		log.WithFields(log.Fields{"plugin": name}).Info("loaded plugin")
+	}
+}
+
+func load(name string, path string) error {
+	s, err := startSession(name, path, func(method string, _ json.RawMessage) (interface{}, error) {
+		return nil, rpcError{Code: errMethodNotFound, Message: "no callbacks are available during describe"}
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+	var desc description
+	err = s.call("describe", nil, &desc, time.Duration(conf.PluginDescribeTimeout)*time.Second)
+	s.close()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if len(desc.Params) == 0 {
+		desc.Params = json.RawMessage("[]")
+	}
+	if len(desc.Defaults) == 0 {
+		desc.Defaults = json.RawMessage("{}")
+	}
+
+	mux.Lock()
+	plugins[name] = desc
+	mux.Unlock()
+
+	registrar.RegisterBuild(name, func(tn *testnet.TestNet) error {
+		return run(name, path, "build", tn, false)
+	})
+	registrar.RegisterAddNodes(name, func(tn *testnet.TestNet) error {
+		return run(name, path, "add", tn, true)
+	})
+	registrar.RegisterServices(name, func() []helpers.Service {
+		out := []helpers.Service{}
+		for _, service := range desc.Services {
+			out = append(out, service)
+		}
+		return out
+	})
+	registrar.RegisterParams(name, func() string { return string(desc.Params) })
+	registrar.RegisterDefaults(name, func() string { return string(desc.Defaults) })
+	if desc.Logs != nil {
+		registrar.RegisterAdditionalLogs(name, desc.Logs)
+	}
+	return nil
+}
+
+// run starts the plugin and calls either build or add on it, serving its callbacks until it is done
+func run(name string, path string, method string, tn *testnet.TestNet, newOnly bool) error {
+	cb := &callbacks{tn: tn, newOnly: newOnly}
+	s, err := startSession(name, path, cb.handle)
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer s.close()
+
+	details := tn.LDD
+	if newOnly {
+		details = &tn.CombinedDetails
+	}
+	return util.LogError(s.call(method, buildRequest{
+		Details:  details,
+		Nodes:    tn.Nodes,
+		NewNodes: tn.NewlyBuiltNodes,
+	}, nil, time.Duration(conf.PluginCallTimeout)*time.Second))
+}
+
+// IsPlugin checks whether the given blockchain is provided by a plugin
+func IsPlugin(blockchain string) bool {
+	mux.RLock()
+	defer mux.RUnlock()
+	_, ok := plugins[blockchain]
+	return ok
+}
+
+// GetParams gets the parameter schema of a plugin, the bool is false if there is no such plugin
+func GetParams(blockchain string) ([]byte, bool) {
+	mux.RLock()
+	defer mux.RUnlock()
+	desc, ok := plugins[blockchain]
+	return desc.Params, ok
+}
+
+// GetDefaults gets the default parameter values of a plugin, the bool is false if there is no such plugin
+func GetDefaults(blockchain string) ([]byte, bool) {
+	mux.RLock()
+	defer mux.RUnlock()
+	desc, ok := plugins[blockchain]
+	return desc.Defaults, ok
+}
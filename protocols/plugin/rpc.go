@@ -0,0 +1,225 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/util"
+)
+
+// message is a JSON-RPC 2.0 request or response, each message is a single line on stdin or stdout
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is the error object of a JSON-RPC 2.0 response
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (re rpcError) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", re.Code, re.Message)
+}
+
+const (
+	errMethodNotFound = -32601
+	errInvalidParams  = -32602
+	errInternal       = -32603
+)
+
+// output collects what a plugin writes to stderr
+type output struct {
+	buf bytes.Buffer
+	mux sync.Mutex
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *output) String() string {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	return o.buf.String()
+}
+
+// handler handles a callback request made by a plugin
+type handler func(method string, params json.RawMessage) (interface{}, error)
+
+// session is a single running instance of a plugin. Genesis makes one call at a time, and
+// the plugin may make callbacks, which are handled concurrently, until it responds to that call.
+type session struct {
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	pipe   io.ReadCloser
+	stderr *output
+	handle handler
+
+	writeMux sync.Mutex
+	nextID   int64
+}
+
+// startSession starts the plugin at the given path, handling any callbacks with the given handler
+func startSession(name string, path string, handle handler) (*session, error) {
+	out := &session{
+		name:   name,
+		cmd:    exec.Command(path),
+		stderr: new(output),
+		handle: handle,
+	}
+	out.cmd.Stderr = out.stderr
+	var err error
+	out.stdin, err = out.cmd.StdinPipe()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out.pipe, err = out.cmd.StdoutPipe()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out.stdout = bufio.NewReader(out.pipe)
+	return out, util.LogError(out.cmd.Start())
+}
+
+func (s *session) write(msg message) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return util.LogError(err)
+	}
+	s.writeMux.Lock()
+	defer s.writeMux.Unlock()
+	_, err = s.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// respond runs the handler for a callback and sends back its result
+func (s *session) respond(req message) {
+	if req.ID == nil {
+		return //notifications are not supported
+	}
+	res := message{ID: req.ID}
+	result, err := s.handle(req.Method, req.Params)
+	if err != nil {
+		code := errInternal
+		if re, ok := err.(rpcError); ok {
+			code = re.Code
+		}
+		res.Error = &rpcError{Code: code, Message: err.Error()}
+	} else {
+		res.Result, err = json.Marshal(result)
+		if err != nil {
+			res.Error = &rpcError{Code: errInternal, Message: err.Error()}
+		}
+	}
+	err = s.write(res)
+	if err != nil {
+		log.WithFields(log.Fields{"plugin": s.name, "method": req.Method, "error": err}).Error(
+			"failed to respond to a plugin callback")
+	}
+}
+
+// call calls the given method on the plugin, and decodes its result into out, if out is not nil.
+// The plugin is killed if it does not respond within the given timeout.
+func (s *session) call(method string, params interface{}, out interface{}, timeout time.Duration) error {
+	var timedOut int32
+	timer := time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&timedOut, 1)
+		log.WithFields(log.Fields{"plugin": s.name, "method": method, "timeout": timeout}).Error(
+			"plugin did not respond in time, killing it")
+		s.cmd.Process.Kill()
+		s.pipe.Close() //a child of the plugin could still be holding stdout open
+	})
+	defer timer.Stop()
+
+	s.nextID++
+	id := s.nextID
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = s.write(message{ID: &id, Method: method, Params: rawParams})
+	if err != nil {
+		return util.LogError(err)
+	}
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	for {
+		line, err := s.stdout.ReadBytes('\n')
+		if err != nil && atomic.LoadInt32(&timedOut) == 1 {
+			return fmt.Errorf("plugin \"%s\" did not respond to %s within %s", s.name, method, timeout)
+		}
+		if err != nil {
+			return fmt.Errorf("plugin \"%s\" stopped before responding to %s: %s", s.name, method, s.stderr.String())
+		}
+		var msg message
+		err = json.Unmarshal(line, &msg)
+		if err != nil {
+			log.WithFields(log.Fields{"plugin": s.name, "line": string(line)}).Warn("ignoring invalid output from plugin")
+			continue
+		}
+		if len(msg.Method) > 0 {
+			wg.Add(1)
+			go func(msg message) {
+				defer wg.Done()
+				s.respond(msg)
+			}(msg)
+			continue
+		}
+		if msg.ID == nil || *msg.ID != id {
+			continue
+		}
+		wg.Wait() //The callbacks should be finished before the plugin responds, but don't leave them dangling
+		if msg.Error != nil {
+			return *msg.Error
+		}
+		if out == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return util.LogError(json.Unmarshal(msg.Result, out))
+	}
+}
+
+// close closes the plugins stdin and waits for it to exit
+func (s *session) close() error {
+	s.stdin.Close()
+	err := s.cmd.Wait()
+	log.WithFields(log.Fields{"plugin": s.name, "stderr": s.stderr.String()}).Debug("plugin exited")
+	return err
+}
@@ -32,6 +32,8 @@ import (
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 	"reflect"
+	"strings"
+	"sync"
 )
 
 var conf *util.Config
@@ -39,6 +41,8 @@ var conf *util.Config
 const (
 	blockchain = "prysm"
 	p2pPort    = 12000
+	// validatorsPerNode is the number of validators run by each node
+	validatorsPerNode = 8
 )
 
 func init() {
@@ -64,88 +68,148 @@ func build(tn *testnet.TestNet) error {
 		prvKey, _, _ := crypto.GenerateKeyPairWithReader(crypto.RSA, 2048, rand.Reader)
 		nodeKeyPairs[node.ID] = prvKey
 	}
+	peerIDs := map[string]string{}
+	for id, key := range nodeKeyPairs {
+		peerIDs[id] = idString(key)
+	}
 
 	tn.BuildState.SetBuildStage("Starting prysm")
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
-		peers := ""
-
-		for _, peerNode := range tn.Nodes {
-			if node == peerNode {
-				continue
-			}
-			peers += fmt.Sprintf(" --peer=/ip4/%s/tcp/%d/p2p/%s", peerNode.IP, p2pPort, idString(nodeKeyPairs[peerNode.GetID()]))
-			tn.BuildState.IncrementBuildProgress()
-		}
+		return startNode(tn, client, node, nodeKeyPairs[node.GetID()], peerIDs)
+	})
+	return util.LogError(err)
+}
 
-		marshaled, err := crypto.MarshalPrivateKey(nodeKeyPairs[node.GetID()])
-		if err != nil {
-			log.WithError(err).Error("Could not marshal key")
-			return err
-		}
-		keyStr := crypto.ConfigEncodeKey(marshaled)
+// add handles adding nodes to the testnet. The peer ids of the existing nodes are derived from
+// their identity keys, and the new nodes are given validator keys which follow on from those of the existing nodes.
+func add(tn *testnet.TestNet) error {
+	_, err := newConf(tn.CombinedDetails.Params)
+	if err != nil {
+		return util.LogError(err)
+	}
+	tn.BuildState.SetBuildSteps(len(tn.Nodes) + (len(tn.NewlyBuiltNodes) * len(tn.Nodes)))
 
-		err = helpers.SingleCp(client, tn.BuildState, node, []byte(keyStr), "/etc/identity.key")
-		if err != nil {
-			log.WithError(err).Error("Could not marshal key")
-			return err
-		}
-		defer tn.BuildState.IncrementBuildProgress()
-		var prometheusInstrumentationPort string
-		obj := tn.CombinedDetails.Params["prometheusInstrumentationPort"]
-		if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
-			prometheusInstrumentationPort = obj.(string)
-		}
-		if prometheusInstrumentationPort == "" {
-			prometheusInstrumentationPort = "8088"
-		}
+	isNew := map[string]bool{}
+	nodeKeyPairs := map[string]crypto.PrivKey{}
+	for _, node := range tn.NewlyBuiltNodes {
+		prvKey, _, _ := crypto.GenerateKeyPairWithReader(crypto.RSA, 2048, rand.Reader)
+		nodeKeyPairs[node.ID] = prvKey
+		isNew[node.ID] = true
+	}
+	peerIDs := map[string]string{}
+	for id, key := range nodeKeyPairs {
+		peerIDs[id] = idString(key)
+	}
 
-		var contract string
-		obj = tn.CombinedDetails.Params["contract"]
-		if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
-			contract = obj.(string)
+	tn.BuildState.SetBuildStage("Fetching the peer ids of the existing nodes")
+	mux := sync.Mutex{}
+	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		if isNew[node.GetID()] {
+			return nil
 		}
-
-		var validatorsPassword string
-		obj = tn.CombinedDetails.Params["validatorsPassword"]
-		if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
-			validatorsPassword = obj.(string)
+		res, err := client.DockerExec(node, "cat /etc/identity.key")
+		if err != nil {
+			return util.LogError(err)
 		}
-
-		var logFolder string
-		obj = tn.CombinedDetails.Params["logFolder"]
-		if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
-			logFolder = obj.(string)
-		} else {
-			logFolder = ""
+		marshaled, err := crypto.ConfigDecodeKey(strings.TrimSpace(res))
+		if err != nil {
+			return util.LogError(err)
 		}
-
-		_, err = client.DockerExecd(node, fmt.Sprintf("/beacon-chain --monitoring-port=%s --no-discovery %s --log-file %s/output-%d.log  --deposit-contract %s --p2p-priv-key /etc/identity.key", prometheusInstrumentationPort, peers, logFolder, node.GetAbsoluteNumber(), contract))
+		key, err := crypto.UnmarshalPrivateKey(marshaled)
 		if err != nil {
 			return util.LogError(err)
 		}
+		mux.Lock()
+		defer mux.Unlock()
+		peerIDs[node.GetID()] = idString(key)
+		return nil
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
 
-		for i := 1; i <= 8; i++ {
-			_, err = client.DockerExecd(node, fmt.Sprintf("/validator accounts create --password %s --keystore-path %s/key%d-%d", validatorsPassword, logFolder, node.GetRelativeNumber(), i))
-			if err != nil {
-				return util.LogError(err)
-			}
-		}
+	tn.BuildState.SetBuildStage("Starting the new prysm nodes")
+	err = helpers.AllNewNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		return startNode(tn, client, node, nodeKeyPairs[node.GetID()], peerIDs)
+	})
+	return util.LogError(err)
+}
 
-		for i := 1; i <= 8; i++ {
-			_, err = client.DockerExecd(node, fmt.Sprintf("bash -c \"/validator --password %s --keystore-path %s/key%d-%d 2>&1 --monitoring-port 10%d%d| tee %s/validator%d-%d.log\"", validatorsPassword, logFolder, node.GetRelativeNumber(), i, node.GetRelativeNumber(), i, logFolder, node.GetRelativeNumber(), i))
-			if err != nil {
-				return util.LogError(err)
-			}
+// startNode starts the beacon chain and the validators of a node, peering it with all of
+// the other nodes
+func startNode(tn *testnet.TestNet, client ssh.Client, node ssh.Node, key crypto.PrivKey, peerIDs map[string]string) error {
+	peers := ""
+	for _, peerNode := range tn.Nodes {
+		if node.GetID() == peerNode.GetID() {
+			continue
 		}
+		peers += fmt.Sprintf(" --peer=/ip4/%s/tcp/%d/p2p/%s", peerNode.IP, p2pPort, peerIDs[peerNode.GetID()])
+		tn.BuildState.IncrementBuildProgress()
+	}
 
+	marshaled, err := crypto.MarshalPrivateKey(key)
+	if err != nil {
+		log.WithError(err).Error("Could not marshal key")
+		return err
+	}
+	keyStr := crypto.ConfigEncodeKey(marshaled)
 
+	err = helpers.SingleCp(client, tn.BuildState, node, []byte(keyStr), "/etc/identity.key")
+	if err != nil {
+		log.WithError(err).Error("Could not marshal key")
 		return err
-	})
-	return util.LogError(err)
-}
+	}
+	defer tn.BuildState.IncrementBuildProgress()
+	var prometheusInstrumentationPort string
+	obj := tn.CombinedDetails.Params["prometheusInstrumentationPort"]
+	if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
+		prometheusInstrumentationPort = obj.(string)
+	}
+	if prometheusInstrumentationPort == "" {
+		prometheusInstrumentationPort = "8088"
+	}
 
-// add handles adding nodes to the testnet
-func add(tn *testnet.TestNet) error {
+	var contract string
+	obj = tn.CombinedDetails.Params["contract"]
+	if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
+		contract = obj.(string)
+	}
+
+	var validatorsPassword string
+	obj = tn.CombinedDetails.Params["validatorsPassword"]
+	if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
+		validatorsPassword = obj.(string)
+	}
+
+	var logFolder string
+	obj = tn.CombinedDetails.Params["logFolder"]
+	if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
+		logFolder = obj.(string)
+	} else {
+		logFolder = ""
+	}
+
+	_, err = client.DockerExecd(node, fmt.Sprintf("/beacon-chain --monitoring-port=%s --no-discovery %s --log-file %s/output-%d.log  --deposit-contract %s --p2p-priv-key /etc/identity.key", prometheusInstrumentationPort, peers, logFolder, node.GetAbsoluteNumber(), contract))
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	//The keystores are numbered by validator index, so that they are unique across the whole network
+	first, last := helpers.ValidatorRange(node, validatorsPerNode)
+	for i := first; i < last; i++ {
+		_, err = client.DockerExecd(node, fmt.Sprintf("/validator accounts create --password %s --keystore-path %s/key%d", validatorsPassword, logFolder, i))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+
+	for i := first; i < last; i++ {
+		_, err = client.DockerExecd(node, fmt.Sprintf("bash -c \"/validator --password %s --keystore-path %s/key%d 2>&1 --monitoring-port 10%d%d| tee %s/validator%d.log\"", validatorsPassword, logFolder, i, node.GetRelativeNumber(), i-first+1, logFolder, i))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
 	return nil
 }
 
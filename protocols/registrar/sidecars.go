@@ -20,6 +20,7 @@ package registrar
 
 import (
 	"fmt"
+	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/testnet"
 )
 
@@ -109,3 +110,37 @@ func GetSideCar(sideCarName string) (*SideCar, error) {
 	}
 	return &out, nil
 }
+
+// GetDeploymentSideCars gets the sidecars of a deployment, which are the sidecars registered for its
+// blockchain followed by the sidecars given in the deployment details. The position of a sidecar in
+// this list is its index in the testnet.
+func GetDeploymentSideCars(details *db.DeploymentDetails) []db.SideCarSpec {
+	out := []db.SideCarSpec{}
+	names, err := GetBlockchainSideCars(details.Blockchain)
+	if err == nil {
+		for _, name := range names {
+			out = append(out, db.SideCarSpec{Name: name})
+		}
+	}
+	return append(out, details.SideCars...)
+}
+
+// IsRegisteredSideCar checks if a sidecar with the given name has been registered
+func IsRegisteredSideCar(sideCarName string) bool {
+	mux.RLock()
+	defer mux.RUnlock()
+	_, ok := sideCars[sideCarName]
+	return ok
+}
+
+// GetSideCarImage gets the image to build the given sidecar from, preferring the image given in the spec
+func GetSideCarImage(spec db.SideCarSpec) (string, error) {
+	if len(spec.Image) > 0 {
+		return spec.Image, nil
+	}
+	sc, err := GetSideCar(spec.Name)
+	if err != nil {
+		return "", fmt.Errorf("side car \"%s\" is not registered and has no image", spec.Name)
+	}
+	return sc.Image, nil
+}
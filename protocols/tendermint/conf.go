@@ -19,10 +19,31 @@
 package tendermint
 
 import (
-	//"github.com/whiteblock/genesis/protocols/helpers"
+	"fmt"
 	"github.com/whiteblock/genesis/protocols/helpers"
 )
 
+type tmConf struct {
+	// ProxyApp is the abci application the nodes run
+	ProxyApp string `json:"proxyApp"`
+	// AddValidators causes nodes added to a running network to be registered as validators
+	AddValidators bool `json:"addValidators"`
+	// ValidatorPower is the voting power given to the added validators
+	ValidatorPower int64 `json:"validatorPower"`
+}
+
+func newConf(data map[string]interface{}) (*tmConf, error) {
+	out := new(tmConf)
+	err := helpers.HandleBlockchainConfig(blockchain, data, out)
+	if err != nil {
+		return nil, err
+	}
+	if out.AddValidators && out.ProxyApp != "persistent_kvstore" {
+		return nil, fmt.Errorf("addValidators requires the persistent_kvstore proxyApp")
+	}
+	return out, nil
+}
+
 // GetServices returns the services which are used by tendermint
 func GetServices() []helpers.Service {
 	return nil
@@ -62,14 +62,18 @@ func init() {
 
 //Build builds out a fresh new tendermint test network
 func Build(tn *testnet.TestNet) error {
+	tmConf, err := newConf(tn.LDD.Params)
+	if err != nil {
+		return util.LogError(err)
+	}
 	//Ensure that genesis file has same chain_id
-	peers := []string{}
+	peers := map[int]string{}
 	validators := []validator{}
 	tn.BuildState.SetBuildSteps(1 + (tn.LDD.Nodes * 4))
 	tn.BuildState.SetBuildStage("Initializing the nodes")
 
 	mux := sync.Mutex{}
-	err := helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
+	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
 		//init everything
 		_, err := client.DockerExec(node, "tendermint init")
 		if err != nil {
@@ -84,7 +88,7 @@ func Build(tn *testnet.TestNet) error {
 		nodeID := res[:len(res)-1]
 
 		mux.Lock()
-		peers = append(peers, fmt.Sprintf("%s@%s:26656", nodeID, node.GetIP()))
+		peers[node.GetAbsoluteNumber()] = fmt.Sprintf("%s@%s:26656", nodeID, node.GetIP())
 		mux.Unlock()
 
 		//Get the validators
@@ -98,42 +102,13 @@ func Build(tn *testnet.TestNet) error {
 		if err != nil {
 			return util.LogError(err)
 		}
-		validatorsRaw := genesis["validators"].([]interface{})
-		for _, validatorRaw := range validatorsRaw {
-			vdtr := validator{}
-
-			validatorData := validatorRaw.(map[string]interface{})
-
-			err = util.GetJSONString(validatorData, "address", &vdtr.Address)
-			if err != nil {
-				return util.LogError(err)
-			}
-
-			validatorPubKeyData := validatorData["pub_key"].(map[string]interface{})
-
-			err = util.GetJSONString(validatorPubKeyData, "type", &vdtr.PubKey.Type)
-			if err != nil {
-				return util.LogError(err)
-			}
-
-			err = util.GetJSONString(validatorPubKeyData, "value", &vdtr.PubKey.Value)
-			if err != nil {
-				return util.LogError(err)
-			}
-
-			err = util.GetJSONString(validatorData, "power", &vdtr.Power)
-			if err != nil {
-				return util.LogError(err)
-			}
-
-			err = util.GetJSONString(validatorData, "name", &vdtr.Name)
-			if err != nil {
-				return util.LogError(err)
-			}
-			mux.Lock()
-			validators = append(validators, vdtr)
-			mux.Unlock()
+		vdtrs, err := getValidators(genesis)
+		if err != nil {
+			return util.LogError(err)
 		}
+		mux.Lock()
+		validators = append(validators, vdtrs...)
+		mux.Unlock()
 		tn.BuildState.IncrementBuildProgress()
 		return nil
 	})
@@ -151,20 +126,172 @@ func Build(tn *testnet.TestNet) error {
 	tn.BuildState.SetBuildStage("Starting tendermint")
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
 		defer tn.BuildState.IncrementBuildProgress()
-		peersCpy := make([]string, len(peers))
-		copy(peersCpy, peers)
-		return client.DockerExecdLog(node, fmt.Sprintf("tendermint node --proxy_app=kvstore --p2p.persistent_peers=%s",
-			strings.Join(append(peersCpy[:node.GetAbsoluteNumber()], peersCpy[node.GetAbsoluteNumber()+1:]...), ",")))
+		return client.DockerExecdLog(node, fmt.Sprintf("tendermint node --proxy_app=%s --p2p.persistent_peers=%s",
+			tmConf.ProxyApp, persistentPeers(tn.Nodes, peers, node.GetAbsoluteNumber())))
 	})
 	return util.LogError(err)
 }
 
-// Add handles adding a node to the tendermint testnet
-// TODO
+// Add handles adding nodes to the tendermint testnet. The new nodes are given the genesis file of
+// the running network and are peered with all of the other nodes. If addValidators is set, each new node
+// is registered as a validator through a validator set change transaction.
 func Add(tn *testnet.TestNet) error {
+	tmConf, err := newConf(tn.CombinedDetails.Params)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if len(tn.NewlyBuiltNodes) == len(tn.Nodes) {
+		return fmt.Errorf("there are no running nodes to join")
+	}
+	tn.BuildState.SetBuildSteps(2 + len(tn.Nodes) + (len(tn.NewlyBuiltNodes) * 2))
+	isNew := map[int]bool{}
+	for _, node := range tn.NewlyBuiltNodes {
+		isNew[node.AbsoluteNum] = true
+	}
+
+	tn.BuildState.SetBuildStage("Initializing the new nodes")
+	newValidators := map[int]validator{}
+	mux := sync.Mutex{}
+	err = helpers.AllNewNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		_, err := client.DockerExec(node, "tendermint init")
+		if err != nil {
+			return util.LogError(err)
+		}
+		//The generated genesis file contains the validator key of the node
+		res, err := client.DockerExec(node, "cat /root/.tendermint/config/genesis.json")
+		if err != nil {
+			return util.LogError(err)
+		}
+		var genesis map[string]interface{}
+		err = json.Unmarshal([]byte(res), &genesis)
+		if err != nil {
+			return util.LogError(err)
+		}
+		vdtrs, err := getValidators(genesis)
+		if err != nil {
+			return util.LogError(err)
+		}
+		if len(vdtrs) != 1 {
+			return fmt.Errorf("expected node %d to have 1 validator key, found %d", node.GetAbsoluteNumber(), len(vdtrs))
+		}
+		mux.Lock()
+		newValidators[node.GetAbsoluteNumber()] = vdtrs[0]
+		mux.Unlock()
+		return nil
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Collecting the node ids")
+	peers := map[int]string{} //absolute numbers can have gaps after nodes have been removed
+	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		res, err := client.DockerExec(node, "tendermint show_node_id")
+		if err != nil {
+			return util.LogError(err)
+		}
+		mux.Lock()
+		defer mux.Unlock()
+		peers[node.GetAbsoluteNumber()] = fmt.Sprintf("%s@%s:26656", strings.TrimSpace(res), node.GetIP())
+		return nil
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Fetching the genesis file")
+	var runningNode ssh.Node
+	for i := range tn.Nodes {
+		if !isNew[tn.Nodes[i].AbsoluteNum] {
+			runningNode = tn.Nodes[i]
+			break
+		}
+	}
+	genesis, err := tn.Clients[runningNode.GetServerID()].DockerExec(runningNode, "cat /root/.tendermint/config/genesis.json")
+	if err != nil {
+		return util.LogError(err)
+	}
+	tn.BuildState.IncrementBuildProgress()
+
+	err = helpers.CopyBytesToAllNewNodes(tn, genesis, "/root/.tendermint/config/genesis.json")
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Starting the new nodes")
+	err = helpers.AllNewNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
+		defer tn.BuildState.IncrementBuildProgress()
+		return client.DockerExecdLog(node, fmt.Sprintf("tendermint node --proxy_app=%s --p2p.persistent_peers=%s",
+			tmConf.ProxyApp, persistentPeers(tn.Nodes, peers, node.GetAbsoluteNumber())))
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+	if !tmConf.AddValidators {
+		tn.BuildState.IncrementBuildProgress()
+		return nil
+	}
+
+	tn.BuildState.SetBuildStage("Registering the new validators")
+	client := tn.Clients[runningNode.GetServerID()]
+	for _, node := range tn.NewlyBuiltNodes {
+		vdtr := newValidators[node.AbsoluteNum]
+		_, err = client.DockerExec(runningNode, fmt.Sprintf(
+			`curl -sS -G http://localhost:26657/broadcast_tx_commit --data-urlencode 'tx="val:%s!%d"'`,
+			vdtr.PubKey.Value, tmConf.ValidatorPower))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	tn.BuildState.IncrementBuildProgress()
 	return nil
 }
 
+// getValidators extracts the validators from a genesis file
+func getValidators(genesis map[string]interface{}) ([]validator, error) {
+	validators := []validator{}
+	validatorsRaw, ok := genesis["validators"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("genesis file is missing validators")
+	}
+	for _, validatorRaw := range validatorsRaw {
+		vdtr := validator{}
+
+		validatorData := validatorRaw.(map[string]interface{})
+
+		err := util.GetJSONString(validatorData, "address", &vdtr.Address)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+
+		validatorPubKeyData := validatorData["pub_key"].(map[string]interface{})
+
+		err = util.GetJSONString(validatorPubKeyData, "type", &vdtr.PubKey.Type)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+
+		err = util.GetJSONString(validatorPubKeyData, "value", &vdtr.PubKey.Value)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+
+		err = util.GetJSONString(validatorData, "power", &vdtr.Power)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+
+		err = util.GetJSONString(validatorData, "name", &vdtr.Name)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		validators = append(validators, vdtr)
+	}
+	return validators, nil
+}
+
 func getGenesisFile(vdtrs []validator) string {
 	validatorsStr, _ := json.Marshal(vdtrs)
 	return fmt.Sprintf(`{
@@ -189,3 +316,16 @@ func getGenesisFile(vdtrs []validator) string {
     }`, time.Now().Format("2006-01-02T15:04:05.000000000Z"),
 		validatorsStr)
 }
+
+// persistentPeers joins the peers of every node other than the one with the given absolute number,
+// in the order of the nodes
+func persistentPeers(nodes []db.Node, peers map[int]string, self int) string {
+	out := []string{}
+	for _, node := range nodes {
+		if node.AbsoluteNum == self {
+			continue
+		}
+		out = append(out, peers[node.AbsoluteNum])
+	}
+	return strings.Join(out, ",")
+}
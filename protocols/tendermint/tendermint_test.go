@@ -0,0 +1,50 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tendermint
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+)
+
+func Test_persistentPeers(t *testing.T) {
+	//node 1 was removed, and node 3 was added afterwards
+	nodes := []db.Node{{AbsoluteNum: 0}, {AbsoluteNum: 2}, {AbsoluteNum: 3}}
+	peers := map[int]string{0: "a@10.0.0.2:26656", 2: "c@10.0.0.10:26656", 3: "d@10.0.0.14:26656"}
+
+	var test = []struct {
+		self     int
+		expected string
+	}{
+		{self: 0, expected: "c@10.0.0.10:26656,d@10.0.0.14:26656"},
+		{self: 2, expected: "a@10.0.0.2:26656,d@10.0.0.14:26656"},
+		{self: 3, expected: "a@10.0.0.2:26656,c@10.0.0.10:26656"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := persistentPeers(nodes, peers, tt.self)
+			if out != tt.expected {
+				t.Errorf("expected \"%s\", got \"%s\"", tt.expected, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package report
+
+import (
+	"fmt"
+
+	"github.com/whiteblock/genesis/ssh"
+)
+
+// Header is the part of a block which is needed for the report
+type Header struct {
+	Number int64  `json:"number"`
+	Hash   string `json:"hash"`
+	Parent string `json:"parent"`
+	// Time is the timestamp of the block, in milliseconds
+	Time   int64 `json:"time"`
+	Uncles int   `json:"uncles"`
+}
+
+// adapter fetches block headers from the nodes of a specific type of blockchain
+type adapter interface {
+	// head gets the number of the latest block of the node
+	head(client ssh.Client, node ssh.Node) (int64, error)
+
+	// headers gets the headers of the blocks from from to to, inclusive, as seen by the node
+	headers(client ssh.Client, node ssh.Node, from int64, to int64) ([]Header, error)
+}
+
+// getAdapter gets the adapter for the given blockchain
+func getAdapter(blockchain string) (adapter, error) {
+	switch blockchain {
+	case "ethereum", "geth", "pantheon", "parity", "mixedeth":
+		return ethAdapter{}, nil
+	case "tendermint", "cosmos":
+		return tmAdapter{}, nil
+	}
+	return nil, fmt.Errorf("reports are not supported for %s", blockchain)
+}
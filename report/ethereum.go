@@ -0,0 +1,85 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/whiteblock/genesis/protocols/ethereum"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/util"
+)
+
+const (
+	ethRPCPort = 8545
+	// ethBatchSize is the maximum number of blocks fetched in a single request
+	ethBatchSize = 100
+)
+
+// ethAdapter reads blocks over the json rpc of any ethereum client
+type ethAdapter struct{}
+
+func (ea ethAdapter) head(client ssh.Client, node ssh.Node) (int64, error) {
+	results, err := ethereum.BatchRPC(client, node, ethRPCPort,
+		[]ethereum.RPCCall{{Method: "eth_blockNumber", Params: []interface{}{}}})
+	if err != nil {
+		return 0, util.LogError(err)
+	}
+	var num hexutil.Uint64
+	err = json.Unmarshal(results[0].Result, &num)
+	return int64(num), util.LogError(err)
+}
+
+func (ea ethAdapter) headers(client ssh.Client, node ssh.Node, from int64, to int64) ([]Header, error) {
+	out := []Header{}
+	for start := from; start <= to; start += ethBatchSize {
+		calls := []ethereum.RPCCall{}
+		for num := start; num <= to && num < start+ethBatchSize; num++ {
+			calls = append(calls, ethereum.RPCCall{Method: "eth_getBlockByNumber",
+				Params: []interface{}{hexutil.EncodeUint64(uint64(num)), false}})
+		}
+		results, err := ethereum.BatchRPC(client, node, ethRPCPort, calls)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		for i := range calls {
+			var block struct {
+				Number     hexutil.Uint64 `json:"number"`
+				Hash       string         `json:"hash"`
+				ParentHash string         `json:"parentHash"`
+				Timestamp  hexutil.Uint64 `json:"timestamp"`
+				Uncles     []string       `json:"uncles"`
+			}
+			err = json.Unmarshal(results[i].Result, &block)
+			if err != nil || len(block.Hash) == 0 {
+				return nil, fmt.Errorf("could not get block %d from node %d", start+int64(i), node.GetAbsoluteNumber())
+			}
+			out = append(out, Header{
+				Number: int64(block.Number),
+				Hash:   block.Hash,
+				Parent: block.ParentHash,
+				Time:   int64(block.Timestamp) * 1000,
+				Uncles: len(block.Uncles),
+			})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,87 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package report
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date": func(unix int64) string { return time.Unix(unix, 0).UTC().Format(time.RFC1123) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Testnet {{.TestNetID}} report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: right; }
+th { background: #f0f0f0; }
+td.bad { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Testnet {{.TestNetID}} ({{.Blockchain}})</h1>
+<p>Generated {{date .Generated}} from blocks {{.From}} to {{.To}}</p>
+
+<h2>Consensus</h2>
+<table>
+<tr><th>Forked heights</th><td>{{.ForkedHeights}}</td></tr>
+<tr><th>Orphaned blocks</th><td>{{.Orphans}}</td></tr>
+<tr><th>Uncles</th><td>{{.Uncles}} ({{printf "%.3f" .UncleRate}} per block)</td></tr>
+<tr><th>Agreed height</th><td>{{.AgreedHeight}}</td></tr>
+<tr><th>Finality lag</th><td>{{.FinalityLag}} blocks</td></tr>
+{{if .Observed}}<tr><th>Reorgs</th><td>{{.Reorgs}}</td></tr>{{end}}
+</table>
+
+<h2>Distributions</h2>
+<table>
+<tr><th></th><th>count</th><th>mean</th><th>min</th><th>p50</th><th>p90</th><th>p99</th><th>max</th></tr>
+{{with .BlockInterval}}<tr><th>Block interval (s)</th><td>{{.Count}}</td><td>{{printf "%.2f" .Mean}}</td><td>{{printf "%.2f" .Min}}</td>
+<td>{{printf "%.2f" .P50}}</td><td>{{printf "%.2f" .P90}}</td><td>{{printf "%.2f" .P99}}</td><td>{{printf "%.2f" .Max}}</td></tr>{{end}}
+{{if .Observed}}{{with .Propagation}}<tr><th>Propagation (ms, &plusmn;{{$.Resolution}})</th><td>{{.Count}}</td><td>{{printf "%.0f" .Mean}}</td><td>{{printf "%.0f" .Min}}</td>
+<td>{{printf "%.0f" .P50}}</td><td>{{printf "%.0f" .P90}}</td><td>{{printf "%.0f" .P99}}</td><td>{{printf "%.0f" .Max}}</td></tr>{{end}}{{end}}
+</table>
+
+{{with .Load}}<h2>Load</h2>
+<table>
+<tr><th>Submitted</th><td>{{.Submitted}}</td></tr>
+<tr><th>Failed</th><td>{{.Failed}}</td></tr>
+<tr><th>Included</th><td>{{.Included}}</td></tr>
+<tr><th>TPS</th><td>{{printf "%.2f" .TPS}}</td></tr>
+<tr><th>Inclusion latency p50 / p99 (ms)</th><td>{{printf "%.0f" .InclusionLatency.P50}} / {{printf "%.0f" .InclusionLatency.P99}}</td></tr>
+</table>{{end}}
+
+<h2>Nodes</h2>
+<table>
+<tr><th>node</th><th>head</th><th>lag</th><th>canonical</th><th>hash</th></tr>
+{{range .Nodes}}<tr><td>{{.Node}}</td>{{if .Error}}<td class="bad" colspan="4">{{.Error}}</td>{{else}}<td>{{.Head}}</td><td>{{.Lag}}</td>
+<td{{if not .OnCanonical}} class="bad"{{end}}>{{.OnCanonical}}</td><td>{{.Hash}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as a standalone html page
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlReport.Execute(w, r)
+}
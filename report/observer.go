@@ -0,0 +1,232 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package report
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/testnet"
+)
+
+// maxObservedBlocks is the number of the most recent blocks whose propagation is kept for the report
+const maxObservedBlocks = 10000
+
+var (
+	observers   = map[string]*observer{}
+	observerMux = sync.Mutex{}
+)
+
+// observer polls the heads of the nodes of a testnet, recording when each node first saw each block.
+// This is what the propagation delay and reorg counts of the report are based on. As the heads are
+// polled, the propagation delay is only accurate to within the interval.
+type observer struct {
+	mux sync.Mutex
+	// firstSeen maps a block hash to the time in milliseconds at which each node first had it as its head
+	firstSeen map[string]map[int]int64
+	// order is the hashes of firstSeen, oldest first, so that the oldest blocks can be dropped
+	order []string
+	// heads is the last head seen on each node
+	heads    map[int]Header
+	reorgs   int
+	interval time.Duration
+	stop     chan bool
+}
+
+func newObserver() *observer {
+	return &observer{firstSeen: map[string]map[int]int64{}, heads: map[int]Header{}}
+}
+
+// IsSupported checks whether reports can be generated for the given blockchain
+func IsSupported(blockchain string) bool {
+	_, err := getAdapter(blockchain)
+	return err == nil
+}
+
+// StartObserving begins polling the heads of the nodes of the given testnet every interval.
+// If the testnet is already being observed, the previous observations are kept.
+func StartObserving(testnetID string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("observation interval must be positive")
+	}
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return err
+	}
+	ad, err := getAdapter(tn.LDD.Blockchain)
+	if err != nil {
+		return err
+	}
+
+	observerMux.Lock()
+	defer observerMux.Unlock()
+	obs, ok := observers[testnetID]
+	if ok && obs.stop != nil {
+		close(obs.stop)
+	}
+	if !ok {
+		obs = newObserver()
+		observers[testnetID] = obs
+	}
+	stop := make(chan bool)
+	obs.stop = stop
+	obs.mux.Lock()
+	obs.interval = interval
+	obs.mux.Unlock()
+
+	log.WithFields(log.Fields{"testnet": testnetID, "interval": interval}).Info("observing the blocks of the testnet")
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				obs.poll(tn, ad)
+			}
+		}
+	}()
+	return nil
+}
+
+// StopObserving stops polling the heads of the nodes of the given testnet. The observations are kept for the report.
+func StopObserving(testnetID string) {
+	observerMux.Lock()
+	defer observerMux.Unlock()
+	obs, ok := observers[testnetID]
+	if ok && obs.stop != nil {
+		close(obs.stop)
+		obs.stop = nil
+	}
+}
+
+// RefreshObserving restarts the observation of the given testnet with its current nodes, if it is being observed.
+// It should be called whenever nodes are added to or removed from the testnet.
+func RefreshObserving(testnetID string) error {
+	observerMux.Lock()
+	obs, ok := observers[testnetID]
+	observing := ok && obs.stop != nil
+	observerMux.Unlock()
+	if !observing {
+		return nil
+	}
+	return StartObserving(testnetID, time.Duration(obs.resolution())*time.Millisecond)
+}
+
+// ForgetObservations stops observing the given testnet and drops its observations, for when it is torn down
+func ForgetObservations(testnetID string) {
+	StopObserving(testnetID)
+	observerMux.Lock()
+	defer observerMux.Unlock()
+	delete(observers, testnetID)
+}
+
+func getObserver(testnetID string) *observer {
+	observerMux.Lock()
+	defer observerMux.Unlock()
+	return observers[testnetID]
+}
+
+// poll fetches the head of every node
+func (obs *observer) poll(tn *testnet.TestNet, ad adapter) {
+	wg := sync.WaitGroup{}
+	for _, node := range tn.Nodes {
+		wg.Add(1)
+		go func(node db.Node) {
+			defer wg.Done()
+			client := tn.Clients[node.Server]
+			num, err := ad.head(client, node)
+			if err != nil {
+				log.WithFields(log.Fields{"testnet": tn.TestNetID, "node": node.AbsoluteNum, "error": err}).Debug(
+					"failed to get the head of the node")
+				return
+			}
+			headers, err := ad.headers(client, node, num, num)
+			if err != nil || len(headers) == 0 {
+				return
+			}
+			obs.record(node.AbsoluteNum, headers[0], time.Now().UnixNano()/int64(time.Millisecond))
+		}(node)
+	}
+	wg.Wait()
+}
+
+// record records the head of a node at the given time, counting a reorg if it does not extend the previous head
+func (obs *observer) record(node int, head Header, now int64) {
+	obs.mux.Lock()
+	defer obs.mux.Unlock()
+	if _, ok := obs.firstSeen[head.Hash]; !ok {
+		obs.firstSeen[head.Hash] = map[int]int64{}
+		obs.order = append(obs.order, head.Hash)
+		if len(obs.order) > maxObservedBlocks {
+			delete(obs.firstSeen, obs.order[0])
+			obs.order = obs.order[1:]
+		}
+	}
+	if _, ok := obs.firstSeen[head.Hash][node]; !ok {
+		obs.firstSeen[head.Hash][node] = now
+	}
+	prev, ok := obs.heads[node]
+	if ok && prev.Hash != head.Hash {
+		if head.Number <= prev.Number || (head.Number == prev.Number+1 && head.Parent != prev.Hash) {
+			obs.reorgs++
+		}
+	}
+	obs.heads[node] = head
+}
+
+// propagation gets the time between the first and the last node seeing each block which was seen by more than one node
+func (obs *observer) propagation() []int64 {
+	obs.mux.Lock()
+	defer obs.mux.Unlock()
+	out := []int64{}
+	for _, seen := range obs.firstSeen {
+		if len(seen) < 2 {
+			continue
+		}
+		first, last := int64(-1), int64(-1)
+		for _, at := range seen {
+			if first == -1 || at < first {
+				first = at
+			}
+			if at > last {
+				last = at
+			}
+		}
+		out = append(out, last-first)
+	}
+	return out
+}
+
+// resolution gets the polling interval in milliseconds, which the propagation delays are accurate to within
+func (obs *observer) resolution() int64 {
+	obs.mux.Lock()
+	defer obs.mux.Unlock()
+	return int64(obs.interval / time.Millisecond)
+}
+
+func (obs *observer) reorgCount() int {
+	obs.mux.Lock()
+	defer obs.mux.Unlock()
+	return obs.reorgs
+}
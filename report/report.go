@@ -0,0 +1,273 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package report analyzes the chains of the nodes of a testnet to summarize how consensus performed
+package report
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/loadgen"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+// Distribution summarizes a set of values
+type Distribution struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+}
+
+// NodeSummary is the state of the chain of a single node
+type NodeSummary struct {
+	Node int    `json:"node"`
+	Head int64  `json:"head"`
+	Hash string `json:"hash,omitempty"`
+	// Lag is how many blocks the node is behind the highest head
+	Lag int64 `json:"lag"`
+	// OnCanonical is whether the head of the node is on the canonical chain
+	OnCanonical bool   `json:"onCanonical"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Report summarizes the consensus of a testnet
+type Report struct {
+	TestNetID  string        `json:"testnetId"`
+	Blockchain string        `json:"blockchain"`
+	Generated  int64         `json:"generated"`
+	Nodes      []NodeSummary `json:"nodes"`
+	// From and To are the range of blocks which were analyzed
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+	// BlockInterval is the distribution of the time between blocks of the canonical chain, in seconds
+	BlockInterval Distribution `json:"blockInterval"`
+	// ForkedHeights is the number of heights at which the nodes have different blocks
+	ForkedHeights int `json:"forkedHeights"`
+	// Orphans is the number of blocks held by some node which are not on the canonical chain
+	Orphans int `json:"orphans"`
+	// Uncles is the number of uncles referenced by the canonical chain
+	Uncles    int     `json:"uncles"`
+	UncleRate float64 `json:"uncleRate"`
+	// AgreedHeight is the highest block on which every node agrees
+	AgreedHeight int64 `json:"agreedHeight"`
+	// FinalityLag is how many blocks the highest head is ahead of the agreed height
+	FinalityLag int64 `json:"finalityLag"`
+	// Observed is whether the blocks of the testnet were observed, which is needed for propagation and reorgs
+	Observed bool `json:"observed"`
+	// Propagation is the distribution of the time between the first and last node having each block, in milliseconds
+	Propagation Distribution `json:"propagation"`
+	Reorgs      int          `json:"reorgs"`
+	// Resolution is the interval in milliseconds at which the blocks were observed, which Propagation is accurate to within
+	Resolution int64 `json:"resolution,omitempty"`
+	// Load is the result of the load generator of the testnet, if there was one
+	Load *loadgen.Summary `json:"load,omitempty"`
+}
+
+// distribution calculates the distribution of the given values
+func distribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, val := range sorted {
+		sum += val
+	}
+	at := func(p float64) float64 {
+		index := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if index < 0 {
+			index = 0
+		}
+		return sorted[index]
+	}
+	return Distribution{
+		Count: len(sorted),
+		Mean:  sum / float64(len(sorted)),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		P50:   at(0.5),
+		P90:   at(0.9),
+		P99:   at(0.99),
+	}
+}
+
+// Generate creates the report for the given testnet, from at most the last maxBlocks blocks
+func Generate(testnetID string, maxBlocks int64) (*Report, error) {
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	ad, err := getAdapter(tn.LDD.Blockchain)
+	if err != nil {
+		return nil, err
+	}
+	out := &Report{
+		TestNetID:  testnetID,
+		Blockchain: tn.LDD.Blockchain,
+		Generated:  time.Now().Unix(),
+		Nodes:      make([]NodeSummary, len(tn.Nodes)),
+	}
+
+	heads := make([]int64, len(tn.Nodes))
+	wg := sync.WaitGroup{}
+	for i, node := range tn.Nodes {
+		wg.Add(1)
+		go func(i int, node db.Node) {
+			defer wg.Done()
+			out.Nodes[i].Node = node.AbsoluteNum
+			head, err := ad.head(tn.Clients[node.Server], node)
+			heads[i] = head
+			if err != nil {
+				heads[i] = -1
+				out.Nodes[i].Error = err.Error()
+			}
+		}(i, node)
+	}
+	wg.Wait()
+
+	maxHead := int64(-1)
+	for _, head := range heads {
+		if head > maxHead {
+			maxHead = head
+		}
+	}
+	out.To = maxHead
+	out.From = maxHead - maxBlocks + 1
+	if out.From < 1 {
+		out.From = 1 //the genesis block is the same everywhere, and has no interval
+	}
+
+	chains := make([]map[int64]Header, len(tn.Nodes))
+	for i, node := range tn.Nodes {
+		if heads[i] < out.From {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, node db.Node) {
+			defer wg.Done()
+			headers, err := ad.headers(tn.Clients[node.Server], node, out.From, heads[i])
+			if err != nil {
+				out.Nodes[i].Error = err.Error()
+				return
+			}
+			chains[i] = map[int64]Header{}
+			for _, header := range headers {
+				chains[i][header.Number] = header
+			}
+		}(i, node)
+	}
+	wg.Wait()
+
+	out.analyze(heads, chains, maxHead)
+	if obs := getObserver(testnetID); obs != nil {
+		out.Observed = true
+		delays := []float64{}
+		for _, delay := range obs.propagation() {
+			delays = append(delays, float64(delay))
+		}
+		out.Propagation = distribution(delays)
+		out.Resolution = obs.resolution()
+		out.Reorgs = obs.reorgCount()
+	}
+	if load, err := loadgen.GetSummary(testnetID); err == nil {
+		out.Load = &load
+	}
+	return out, nil
+}
+
+// analyze compares the chains of the nodes, taking the chain of the node with the highest head as canonical
+func (r *Report) analyze(heads []int64, chains []map[int64]Header, maxHead int64) {
+	canonical := -1
+	for i, head := range heads {
+		if head == maxHead && chains[i] != nil {
+			canonical = i
+			break
+		}
+	}
+	if canonical == -1 {
+		return
+	}
+
+	intervals := []float64{}
+	for num := r.From + 1; num <= maxHead; num++ {
+		prev, hasPrev := chains[canonical][num-1]
+		block, ok := chains[canonical][num]
+		if !ok {
+			continue
+		}
+		r.Uncles += block.Uncles
+		if hasPrev {
+			intervals = append(intervals, float64(block.Time-prev.Time)/1000)
+		}
+	}
+	r.BlockInterval = distribution(intervals)
+	if blocks := maxHead - r.From + 1; blocks > 0 {
+		r.UncleRate = float64(r.Uncles) / float64(blocks)
+	}
+
+	orphans := map[string]bool{}
+	r.AgreedHeight = r.From - 1
+	agreed := true
+	for num := r.From; num <= maxHead; num++ {
+		main := chains[canonical][num]
+		forked := false
+		for i := range chains {
+			block, ok := chains[i][num]
+			if !ok {
+				agreed = false
+				continue
+			}
+			if block.Hash != main.Hash {
+				forked = true
+				orphans[block.Hash] = true
+			}
+		}
+		if forked {
+			r.ForkedHeights++
+			agreed = false
+		}
+		if agreed {
+			r.AgreedHeight = num
+		}
+	}
+	r.Orphans = len(orphans)
+	r.FinalityLag = maxHead - r.AgreedHeight
+
+	for i, head := range heads {
+		if head < 0 {
+			continue
+		}
+		r.Nodes[i].Head = head
+		r.Nodes[i].Lag = maxHead - head
+		if block, ok := chains[i][head]; ok {
+			r.Nodes[i].Hash = block.Hash
+			r.Nodes[i].OnCanonical = block.Hash == chains[canonical][head].Hash
+		}
+	}
+}
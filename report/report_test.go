@@ -0,0 +1,175 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package report
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDistribution(t *testing.T) {
+	var test = []struct {
+		values   []float64
+		expected Distribution
+	}{
+		{values: nil, expected: Distribution{}},
+		{
+			values:   []float64{5},
+			expected: Distribution{Count: 1, Mean: 5, Min: 5, Max: 5, P50: 5, P90: 5, P99: 5},
+		},
+		{
+			values:   []float64{4, 1, 3, 2},
+			expected: Distribution{Count: 4, Mean: 2.5, Min: 1, Max: 4, P50: 2, P90: 4, P99: 4},
+		},
+		{
+			values:   []float64{10, 1, 9, 2, 8, 3, 7, 4, 6, 5},
+			expected: Distribution{Count: 10, Mean: 5.5, Min: 1, Max: 10, P50: 5, P90: 9, P99: 10},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			res := distribution(tt.values)
+			if !reflect.DeepEqual(res, tt.expected) {
+				t.Errorf("distribution(%v) returned %+v, expected %+v", tt.values, res, tt.expected)
+			}
+		})
+	}
+}
+
+// chain creates the headers of blocks from to to, with a block every second and the given suffix on the hashes
+func chain(from int64, to int64, suffix string) map[int64]Header {
+	out := map[int64]Header{}
+	for num := from; num <= to; num++ {
+		out[num] = Header{
+			Number: num,
+			Hash:   fmt.Sprintf("%d%s", num, suffix),
+			Parent: fmt.Sprintf("%d%s", num-1, suffix),
+			Time:   num * 1000,
+		}
+	}
+	return out
+}
+
+func TestReport_analyze(t *testing.T) {
+	forked := chain(1, 2, "")
+	for num, header := range chain(3, 3, "b") {
+		forked[num] = header
+	}
+	uncled := chain(1, 3, "")
+	uncled[2] = Header{Number: 2, Hash: "2", Parent: "1", Time: 2000, Uncles: 1}
+
+	var test = []struct {
+		heads    []int64
+		chains   []map[int64]Header
+		maxHead  int64
+		expected Report
+	}{
+		{
+			heads:   []int64{3, 3},
+			chains:  []map[int64]Header{uncled, chain(1, 3, "")},
+			maxHead: 3,
+			expected: Report{
+				From:          1,
+				Nodes:         []NodeSummary{{Head: 3, Hash: "3", OnCanonical: true}, {Head: 3, Hash: "3", OnCanonical: true}},
+				BlockInterval: Distribution{Count: 2, Mean: 1, Min: 1, Max: 1, P50: 1, P90: 1, P99: 1},
+				Uncles:        1,
+				UncleRate:     1.0 / 3,
+				AgreedHeight:  3,
+			},
+		},
+		{
+			heads:   []int64{4, 3},
+			chains:  []map[int64]Header{chain(1, 4, ""), forked},
+			maxHead: 4,
+			expected: Report{
+				From: 1,
+				Nodes: []NodeSummary{
+					{Head: 4, Hash: "4", OnCanonical: true},
+					{Head: 3, Hash: "3b", Lag: 1, OnCanonical: false},
+				},
+				BlockInterval: Distribution{Count: 3, Mean: 1, Min: 1, Max: 1, P50: 1, P90: 1, P99: 1},
+				ForkedHeights: 1,
+				Orphans:       1,
+				AgreedHeight:  2,
+				FinalityLag:   2,
+			},
+		},
+		{
+			heads:   []int64{5, -1},
+			chains:  []map[int64]Header{chain(3, 5, ""), nil},
+			maxHead: 5,
+			expected: Report{
+				From:          3,
+				Nodes:         []NodeSummary{{Head: 5, Hash: "5", OnCanonical: true}, {}},
+				BlockInterval: Distribution{Count: 2, Mean: 1, Min: 1, Max: 1, P50: 1, P90: 1, P99: 1},
+				AgreedHeight:  2,
+				FinalityLag:   3,
+			},
+		},
+		{
+			heads:    []int64{-1, -1},
+			chains:   []map[int64]Header{nil, nil},
+			maxHead:  -1,
+			expected: Report{From: 1, Nodes: []NodeSummary{{}, {}}},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			res := Report{From: tt.expected.From, Nodes: make([]NodeSummary, len(tt.heads))}
+			res.analyze(tt.heads, tt.chains, tt.maxHead)
+			if !reflect.DeepEqual(res, tt.expected) {
+				t.Errorf("analyze returned %+v, expected %+v", res, tt.expected)
+			}
+		})
+	}
+}
+
+func TestObserver_record(t *testing.T) {
+	obs := newObserver()
+	obs.record(0, Header{Number: 1, Hash: "1", Parent: "0"}, 100)
+	obs.record(1, Header{Number: 1, Hash: "1", Parent: "0"}, 350)
+	obs.record(0, Header{Number: 2, Hash: "2", Parent: "1"}, 1000)
+	obs.record(0, Header{Number: 2, Hash: "2b", Parent: "1"}, 1100)
+	obs.record(1, Header{Number: 2, Hash: "2b", Parent: "1"}, 1200)
+
+	if obs.reorgCount() != 1 {
+		t.Errorf("counted %d reorgs, expected 1", obs.reorgCount())
+	}
+	delays := obs.propagation()
+	if len(delays) != 2 {
+		t.Fatalf("got %d propagation delays, expected 2", len(delays))
+	}
+	if delays[0]+delays[1] != 350 {
+		t.Errorf("got propagation delays %v, expected 250 and 100", delays)
+	}
+
+	for i := 0; i < maxObservedBlocks; i++ {
+		obs.record(0, Header{Number: int64(i + 3), Hash: strconv.Itoa(i + 3), Parent: strconv.Itoa(i + 2)}, 2000)
+	}
+	if len(obs.firstSeen) != maxObservedBlocks || len(obs.order) != maxObservedBlocks {
+		t.Errorf("kept %d blocks, expected only the last %d", len(obs.firstSeen), maxObservedBlocks)
+	}
+	if _, ok := obs.firstSeen["1"]; ok {
+		t.Error("the oldest block was not dropped")
+	}
+}
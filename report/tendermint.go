@@ -0,0 +1,110 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/util"
+)
+
+const (
+	tmRPCPort = 26657
+	// tmBatchSize is the maximum number of blocks tendermint returns from /blockchain
+	tmBatchSize = 20
+)
+
+// tmAdapter reads blocks over the rpc of tendermint based nodes
+type tmAdapter struct{}
+
+// get makes a request to the tendermint rpc of the node, and decodes its result into out
+func (ta tmAdapter) get(client ssh.Client, node ssh.Node, path string, out interface{}) error {
+	res, err := client.DockerExec(node, fmt.Sprintf(`curl -sS "http://%s:%d/%s"`, node.GetIP(), tmRPCPort, path))
+	if err != nil {
+		return util.LogError(err)
+	}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	err = json.Unmarshal([]byte(res), &resp)
+	if err != nil || len(resp.Result) == 0 {
+		return fmt.Errorf("unexpected response from the node: %s", res)
+	}
+	return util.LogError(json.Unmarshal(resp.Result, out))
+}
+
+func (ta tmAdapter) head(client ssh.Client, node ssh.Node) (int64, error) {
+	var status struct {
+		SyncInfo struct {
+			LatestBlockHeight string `json:"latest_block_height"`
+		} `json:"sync_info"`
+	}
+	err := ta.get(client, node, "status", &status)
+	if err != nil {
+		return 0, util.LogError(err)
+	}
+	return strconv.ParseInt(status.SyncInfo.LatestBlockHeight, 10, 64)
+}
+
+func (ta tmAdapter) headers(client ssh.Client, node ssh.Node, from int64, to int64) ([]Header, error) {
+	out := []Header{}
+	for start := from; start <= to; start += tmBatchSize {
+		end := start + tmBatchSize - 1
+		if end > to {
+			end = to
+		}
+		var chain struct {
+			BlockMetas []struct {
+				BlockID struct {
+					Hash string `json:"hash"`
+				} `json:"block_id"`
+				Header struct {
+					Height      string    `json:"height"`
+					Time        time.Time `json:"time"`
+					LastBlockID struct {
+						Hash string `json:"hash"`
+					} `json:"last_block_id"`
+				} `json:"header"`
+			} `json:"block_metas"`
+		}
+		err := ta.get(client, node, fmt.Sprintf("blockchain?minHeight=%d&maxHeight=%d", start, end), &chain)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		//block metas are returned from highest to lowest
+		for i := len(chain.BlockMetas) - 1; i >= 0; i-- {
+			meta := chain.BlockMetas[i]
+			height, err := strconv.ParseInt(meta.Header.Height, 10, 64)
+			if err != nil {
+				return nil, util.LogError(err)
+			}
+			out = append(out, Header{
+				Number: height,
+				Hash:   meta.BlockID.Hash,
+				Parent: meta.Header.LastBlockID.Hash,
+				Time:   meta.Header.Time.UnixNano() / int64(time.Millisecond),
+			})
+		}
+	}
+	return out, nil
+}
@@ -103,7 +103,11 @@ func getBlockChainParams(w http.ResponseWriter, r *http.Request) {
 
 	params := mux.Vars(r)
 	log.WithFields(log.Fields{"blockchain": params["blockchain"]}).Debug("getting params")
-	blockchainParams, err := manager.GetParams(params["blockchain"])
+	getParams := manager.GetParamPairs
+	if r.URL.Query().Get("format") == "schema" {
+		getParams = manager.GetParams
+	}
+	blockchainParams, err := getParams(params["blockchain"])
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
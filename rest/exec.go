@@ -0,0 +1,159 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/manager"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// execStream writes the output of the nodes back to the caller as newline delimited json
+type execStream struct {
+	enc     *json.Encoder
+	flusher http.Flusher
+	mux     sync.Mutex
+}
+
+func (es *execStream) send(msg interface{}) {
+	es.mux.Lock()
+	defer es.mux.Unlock()
+	es.enc.Encode(msg)
+	if es.flusher != nil {
+		es.flusher.Flush()
+	}
+}
+
+type nodeOutput struct {
+	node   int
+	stream *execStream
+}
+
+func (no nodeOutput) Write(p []byte) (int, error) {
+	no.stream.send(map[string]interface{}{"node": no.node, "output": string(p)})
+	return len(p), nil
+}
+
+// unverifiedKidPrefix marks a kid which was taken from a token whose signature could not be verified
+const unverifiedKidPrefix = "unverified:"
+
+// getCallerKid extracts the kid of the caller from the request, for the audit log. If authentication is not
+// required, an unauthenticated caller will have an empty kid. The kid of a token which could not be verified
+// is prefixed with unverified:, since anyone could have written it.
+func getCallerKid(r *http.Request) (string, error) {
+	jwt, err := util.ExtractJwt(r)
+	if err != nil {
+		if conf.RequireAuth {
+			return "", err
+		}
+		return "", nil
+	}
+	kid, err := conf.VerifyKid(jwt)
+	if err == nil {
+		return kid, nil
+	}
+	kid, err = util.GetKidFromJwt(jwt)
+	if err != nil {
+		if conf.RequireAuth {
+			return "", err
+		}
+		return "", nil
+	}
+	return unverifiedKidPrefix + kid, nil
+}
+
+// callerIsAdmin checks if the caller presented a verified token belonging to an administrator
+func callerIsAdmin(r *http.Request) bool {
+	jwt, err := util.ExtractJwt(r)
+	if err != nil {
+		return false
+	}
+	return conf.IsAdmin(jwt)
+}
+
+func execOnNodes(w http.ResponseWriter, r *http.Request, req manager.ExecRequest) {
+	params := mux.Vars(r)
+	kid, err := getCallerKid(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 403)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	stream := &execStream{enc: json.NewEncoder(w), flusher: flusher}
+
+	results, err := manager.ExecOnNodes(params["id"], req, kid, callerIsAdmin(r), func(node int) io.Writer {
+		return nodeOutput{node: node, stream: stream}
+	})
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	success := true
+	for _, result := range results {
+		if result.ExitCode != 0 {
+			success = false
+		}
+	}
+	stream.send(map[string]interface{}{"results": results, "success": success})
+}
+
+func execOnNode(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodes, err := resolveNodes(params["id"], params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	var req manager.ExecRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	req.Nodes = nodes
+	req.Selector = ""
+	execOnNodes(w, r, req)
+}
+
+func execOnAllNodes(w http.ResponseWriter, r *http.Request) {
+	var req manager.ExecRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	execOnNodes(w, r, req)
+}
+
+func getExecAuditLog(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	entries, err := db.GetExecAuditLog(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,196 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/fault"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func getFaults(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	node := -1
+	if len(r.URL.Query().Get("node")) > 0 {
+		var err error
+		node, err = strconv.Atoi(r.URL.Query().Get("node"))
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	history := r.URL.Query().Get("history") == "true"
+
+	faults, err := fault.GetFaults(params["id"], node, history)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(faults)
+}
+
+func setLimits(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodes, err := resolveNodes(params["id"], params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	var limits fault.Limits
+	err = json.NewDecoder(r.Body).Decode(&limits)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	for _, node := range nodes {
+		err = fault.SetLimits(params["id"], node, limits)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
+	}
+	w.Write([]byte("Success"))
+}
+
+func resetLimits(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodes, err := resolveNodes(params["id"], params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	for _, node := range nodes {
+		err = fault.ResetLimits(params["id"], node)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
+	}
+	w.Write([]byte("Success"))
+}
+
+func startStress(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodes, err := resolveNodes(params["id"], params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	var stress fault.Stress
+	err = json.NewDecoder(r.Body).Decode(&stress)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	for _, node := range nodes {
+		err = fault.StartStress(params["id"], node, stress)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
+	}
+	w.Write([]byte("Success"))
+}
+
+func stopStress(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodes, err := resolveNodes(params["id"], params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	for _, node := range nodes {
+		err = fault.StopStress(params["id"], node)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
+	}
+	w.Write([]byte("Success"))
+}
+
+// getNodeParam gets the nodes given in the path, or else those matching the given selector. If neither
+// is given, nil is returned.
+func getNodeParam(params map[string]string, selector string) ([]int, error) {
+	if _, ok := params["node"]; ok {
+		return resolveNodes(params["testnetID"], params["node"])
+	}
+	if len(selector) > 0 {
+		return resolveNodes(params["testnetID"], selector)
+	}
+	return nil, nil
+}
+
+func pauseNodes(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var req struct {
+		fault.Pause
+		Selector string `json:"selector"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	nodes, err := getNodeParam(params, req.Selector)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if nodes != nil {
+		req.Nodes = nodes
+	}
+	err = fault.PauseNodes(params["testnetID"], req.Pause)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func unpauseNodes(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var req struct {
+		Nodes    []int  `json:"nodes"`
+		Selector string `json:"selector"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	nodes, err := getNodeParam(params, req.Selector)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if nodes != nil {
+		req.Nodes = nodes
+	}
+	err = fault.UnpauseNodes(params["testnetID"], req.Nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,176 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/manager"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const maxUploadMemory = 32 << 20
+
+// parseUploads extracts the files to upload from the request body. Multipart uploads have each of their
+// files placed in the directory dest, tar archives are extracted into dest, and anything else is written to dest as is.
+func parseUploads(r *http.Request, dest string) ([]manager.FileUpload, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+	switch mediaType {
+	case "multipart/form-data":
+		err = r.ParseMultipartForm(maxUploadMemory)
+		if err != nil {
+			return nil, err
+		}
+		out := []manager.FileUpload{}
+		for _, headers := range r.MultipartForm.File {
+			for _, header := range headers {
+				file, err := header.Open()
+				if err != nil {
+					return nil, err
+				}
+				data, err := ioutil.ReadAll(file)
+				file.Close()
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, manager.FileUpload{
+					Dest: path.Join(dest, path.Base(header.Filename)),
+					Data: data,
+					Tar:  false,
+				})
+			}
+		}
+		if len(out) == 0 {
+			return nil, fmt.Errorf("no files given")
+		}
+		return out, nil
+	case "application/x-tar":
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		return []manager.FileUpload{{Dest: dest, Data: data, Tar: true}}, nil
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return []manager.FileUpload{{Dest: dest, Data: data, Tar: false}}, nil
+}
+
+func uploadFiles(w http.ResponseWriter, r *http.Request, nodes []int) {
+	params := mux.Vars(r)
+	dest := r.URL.Query().Get("path")
+	err := util.ValidateNodePath(dest)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, conf.MaxUploadSize)
+	uploads, err := parseUploads(r, dest)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	bs, err := state.GetBuildStateByID(params["id"])
+	if err != nil {
+		util.LogError(err)
+		http.Error(w, "Testnet is down, build a new one", 409)
+		return
+	}
+	if !bs.Done() {
+		http.Error(w, "There is a build in progress", 409)
+		return
+	}
+	bs.Reset()
+	defer bs.DoneBuilding() //the copy can fail before it starts, which must not leave the testnet building
+	err = manager.CopyToNodes(params["id"], nodes, uploads)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func uploadFilesToNode(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodes, err := resolveNodes(params["id"], params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	uploadFiles(w, r, nodes)
+}
+
+func uploadFilesToNodes(w http.ResponseWriter, r *http.Request) {
+	nodes := []int{}
+	if selector := r.URL.Query().Get("selector"); len(selector) > 0 {
+		var err error
+		nodes, err = resolveNodes(mux.Vars(r)["id"], selector)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	} else if len(r.URL.Query().Get("nodes")) > 0 {
+		for _, rawNode := range strings.Split(r.URL.Query().Get("nodes"), ",") {
+			node, err := strconv.Atoi(strings.TrimSpace(rawNode))
+			if err != nil {
+				http.Error(w, util.LogError(err).Error(), 400)
+				return
+			}
+			nodes = append(nodes, node)
+		}
+	}
+	uploadFiles(w, r, nodes)
+}
+
+func downloadFileFromNode(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	node, err := strconv.Atoi(params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	src := r.URL.Query().Get("path")
+	err = util.ValidateNodePath(src)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	archive, err := manager.DownloadFromNode(params["id"], node, src)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	defer archive.Close()
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.tar\"", path.Base(src)))
+	io.Copy(w, archive)
+}
@@ -0,0 +1,85 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/loadgen"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+func decodePattern(w http.ResponseWriter, r *http.Request) (loadgen.Pattern, bool) {
+	var pattern loadgen.Pattern
+	err := json.NewDecoder(r.Body).Decode(&pattern)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return pattern, false
+	}
+	return pattern, true
+}
+
+func startLoad(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	pattern, ok := decodePattern(w, r)
+	if !ok {
+		return
+	}
+	err := loadgen.Start(params["id"], pattern)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func adjustLoad(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	pattern, ok := decodePattern(w, r)
+	if !ok {
+		return
+	}
+	err := loadgen.Adjust(params["id"], pattern)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func stopLoad(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	err := loadgen.Stop(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func getLoad(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	summary, err := loadgen.GetSummary(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	json.NewEncoder(w).Encode(summary)
+}
@@ -19,6 +19,7 @@
 package rest
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
@@ -27,7 +28,7 @@ import (
 	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/util"
 	"net/http"
-	"strconv"
+	"strings"
 )
 
 func handleNet(w http.ResponseWriter, r *http.Request) {
@@ -41,6 +42,13 @@ func handleNet(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
+	for _, nc := range netConf {
+		err = nc.Validate()
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
 
 	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
@@ -68,12 +76,24 @@ func handleNetAll(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
+	err = netConf.Validate()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
 
 	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
 	}
+	if selector := r.URL.Query().Get("selector"); len(selector) > 0 {
+		nodes, err = db.ResolveNodes(nodes, selector)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
 
 	netem.RemoveAll(nodes)
 	err = netem.ApplyToAll(netConf, nodes)
@@ -122,60 +142,197 @@ func getNet(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(out)
 }
 
-func removeOrAddOutage(w http.ResponseWriter, r *http.Request) {
+func setClocks(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	testnetID := params["testnetID"]
-	nodeNum1, err := strconv.Atoi(params["node1"])
+
+	var skews []netem.ClockSkew
+	err := json.NewDecoder(r.Body).Decode(&skews)
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
 
-	nodeNum2, err := strconv.Atoi(params["node2"])
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 400)
+		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
 
-	nodes, err := db.GetAllNodesByTestNet(testnetID)
+	err = netem.ApplyClockSkews(skews, nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func resetClocks(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
 
-	node1, err := db.GetNodeByAbsNum(nodes, nodeNum1)
+	err = netem.RemoveClockSkews(nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func getClocks(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
 
-	node2, err := db.GetNodeByAbsNum(nodes, nodeNum2)
+	skews, err := netem.GetClockSkews(nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(skews)
+}
+
+// getOutageFunc gets the function which creates or removes an outage between node1 and node2 for the
+// given http method. The direction is either "both", "forward" for only the traffic sent from node1 to
+// node2, or "backward" for only the traffic sent from node2 to node1.
+func getOutageFunc(method string, direction string) (func(db.Node, db.Node) error, error) {
+	if method != "POST" && method != "DELETE" {
+		return nil, fmt.Errorf("unexpected http method")
+	}
+	create := method == "POST"
+	switch direction {
+	case "", "both":
+		if create {
+			return netem.MakeOutage, nil
+		}
+		return netem.RemoveOutage, nil
+	case "forward", "backward":
+		outage := netem.RemoveDirectedOutage
+		if create {
+			outage = netem.MakeDirectedOutage
+		}
+		if direction == "forward" {
+			return outage, nil
+		}
+		return func(node1 db.Node, node2 db.Node) error {
+			return outage(node2, node1)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown direction \"%s\"", direction)
+}
+
+func removeOrAddOutage(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	testnetID := params["testnetID"]
+
+	nodes, err := db.GetAllNodesByTestNet(testnetID)
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
-	switch r.Method {
-	case "POST":
-		err = netem.MakeOutage(node1, node2)
-	case "DELETE":
-		err = netem.RemoveOutage(node1, node2)
-	default:
-		err = fmt.Errorf("unexpected http method")
+
+	side1, err := db.ResolveNodes(nodes, params["node1"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
 	}
+
+	side2, err := db.ResolveNodes(nodes, params["node2"])
 	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	outage, err := getOutageFunc(r.Method, r.URL.Query().Get("direction"))
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
+	for _, node1 := range side1 {
+		for _, node2 := range side2 {
+			if node1.AbsoluteNum == node2.AbsoluteNum {
+				continue
+			}
+			err = outage(node1, node2)
+			if err != nil {
+				http.Error(w, util.LogError(err).Error(), 500)
+				return
+			}
+		}
+	}
 	w.Write([]byte("Success"))
 }
 
+// getPartitionSide gets the absolute numbers of the nodes on one side of a partition, which
+// may be given as either node numbers or label selectors
+func getPartitionSide(nodes []db.Node, targets []interface{}) ([]int, error) {
+	out := []int{}
+	seen := map[int]bool{}
+	for _, target := range targets {
+		var selected []db.Node
+		var err error
+		switch t := target.(type) {
+		case json.Number:
+			selected, err = db.ResolveNodes(nodes, t.String())
+		case string:
+			selected, err = db.ResolveNodes(nodes, t)
+		default:
+			err = fmt.Errorf("expected a node number or a selector, got %v", target)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range selected {
+			if !seen[node.AbsoluteNum] {
+				seen[node.AbsoluteNum] = true
+				out = append(out, node.AbsoluteNum)
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no nodes given")
+	}
+	return out, nil
+}
+
+// partitionRequest is the body of a request to create a partition, which may also be given as just
+// the nodes on one side
+type partitionRequest struct {
+	// Nodes are the nodes on one side of the partition, as node numbers or selectors
+	Nodes []interface{} `json:"nodes"`
+
+	// Bridges are the nodes which stay connected to both sides, as node numbers or selectors
+	Bridges []interface{} `json:"bridges"`
+
+	// Direction is either "both", "forward" to only drop the traffic sent from the given nodes to
+	// the other side, or "backward" to only drop the traffic sent to the given nodes
+	Direction string `json:"direction"`
+}
+
 func partitionOutage(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	nodeNums := []int{}
-	decoder := json.NewDecoder(r.Body)
+	var raw json.RawMessage
+	err := json.NewDecoder(r.Body).Decode(&raw)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	var req partitionRequest
+	decoder := json.NewDecoder(bytes.NewReader(raw))
 	decoder.UseNumber()
-	err := decoder.Decode(&nodeNums)
+	if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+		err = decoder.Decode(&req.Nodes)
+	} else {
+		err = decoder.Decode(&req)
+	}
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
@@ -185,35 +342,54 @@ func partitionOutage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
+	if len(req.Bridges) > 0 {
+		bridgeNums, err := getPartitionSide(nodes, req.Bridges)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+		_, nodes, err = db.DivideNodesByAbsMatch(nodes, bridgeNums)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	nodeNums, err := getPartitionSide(nodes, req.Nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
 	side1, side2, err := db.DivideNodesByAbsMatch(nodes, nodeNums)
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
-	netem.CreatePartitionOutage(side1, side2)
+	switch req.Direction {
+	case "", "both":
+		netem.CreatePartitionOutage(side1, side2)
+	case "forward":
+		netem.CreateDirectedPartitionOutage(side1, side2)
+	case "backward":
+		netem.CreateDirectedPartitionOutage(side2, side1)
+	default:
+		http.Error(w, fmt.Sprintf("unknown direction \"%s\"", req.Direction), 400)
+		return
+	}
 	w.Write([]byte("success"))
 }
 
 func removeAllOutages(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	servers, err := status.GetLatestServers(params["testnetID"])
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
-
-	for _, server := range servers {
-		client, err := status.GetClient(server.ID)
-		if err != nil {
-			http.Error(w, util.LogError(err).Error(), 404)
-			return
-		}
-		err = netem.RemoveAllOutages(client)
-		if err != nil {
-			http.Error(w, util.LogError(err).Error(), 500)
-			return
-		}
+	err = netem.RemoveTestNetOutages(params["testnetID"], nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
 	}
 	w.Write([]byte("Success"))
 }
@@ -221,39 +397,80 @@ func removeAllOutages(w http.ResponseWriter, r *http.Request) {
 func getAllOutages(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	servers, err := status.GetLatestServers(params["testnetID"])
+	out, err := netem.GetOutages(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	target, exists := params["node"]
+	if !exists {
+		json.NewEncoder(w).Encode(out)
+		return
+	}
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
-	out := []netem.Connection{}
-	for _, server := range servers {
-		client, err := status.GetClient(server.ID)
-		if err != nil {
-			http.Error(w, util.LogError(err).Error(), 404)
-			return
-		}
-		conns, err := netem.GetCutConnections(client)
-		if err != nil {
-			http.Error(w, util.LogError(err).Error(), 500)
-			return
-		}
-		out = append(out, conns...)
+	selected, err := db.ResolveNodes(nodes, target)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
 	}
-	nodeRaw, exists := params["node"]
-	if exists {
-		node, err := strconv.Atoi(nodeRaw)
-		if err != nil {
-			http.Error(w, util.LogError(err).Error(), 400)
-			return
-		}
-		filteredOut := []netem.Connection{}
-		for _, conn := range out {
-			if conn.To == node || conn.From == node {
-				filteredOut = append(filteredOut, conn)
-			}
+	nums := map[int]bool{}
+	for _, node := range selected {
+		nums[node.AbsoluteNum] = true
+	}
+	filteredOut := []netem.Connection{}
+	for _, conn := range out {
+		if nums[conn.To] || nums[conn.From] {
+			filteredOut = append(filteredOut, conn)
 		}
-		json.NewEncoder(w).Encode(filteredOut)
+	}
+	json.NewEncoder(w).Encode(filteredOut)
+}
+
+func getOutageDrift(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	drift, err := netem.GetOutageDrift(params["testnetID"], nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(drift)
+}
+
+func reconcileOutages(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	drift, err := netem.ReconcileOutages(params["testnetID"], nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(drift)
+}
+
+func getReachability(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+
+	out, err := netem.CalculateReachability(params["testnetID"], nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
 		return
 	}
 	json.NewEncoder(w).Encode(out)
@@ -267,7 +484,7 @@ func getAllPartitions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	out, err := netem.CalculatePartitions(nodes)
+	out, err := netem.CalculatePartitions(params["testnetID"], nodes)
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
@@ -0,0 +1,86 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/report"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultReportBlocks is the number of the most recent blocks analyzed when not given
+	defaultReportBlocks = 1000
+	// maxReportBlocks is the most blocks which can be analyzed, as the headers of each are fetched from every node
+	maxReportBlocks = 10000
+)
+
+func getReport(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	blocks := int64(defaultReportBlocks)
+	if r.URL.Query().Get("blocks") != "" {
+		var err error
+		blocks, err = strconv.ParseInt(r.URL.Query().Get("blocks"), 10, 64)
+		if err != nil || blocks <= 0 || blocks > maxReportBlocks {
+			http.Error(w, fmt.Sprintf("blocks must be a positive integer of at most %d", maxReportBlocks), 400)
+			return
+		}
+	}
+	rep, err := report.Generate(params["id"], blocks)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if r.URL.Query().Get("format") != "html" {
+		json.NewEncoder(w).Encode(rep)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	util.LogError(rep.WriteHTML(w))
+}
+
+func startObserving(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	interval := conf.BlockObserveInterval
+	if r.URL.Query().Get("interval") != "" {
+		var err error
+		interval, err = strconv.Atoi(r.URL.Query().Get("interval"))
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	err := report.StartObserving(params["id"], time.Duration(interval)*time.Millisecond)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func stopObserving(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	report.StopObserving(params["id"])
+	w.Write([]byte("Success"))
+}
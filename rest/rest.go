@@ -24,6 +24,7 @@ import (
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/metrics"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/util"
@@ -54,6 +55,52 @@ func StartServer() {
 
 	router.HandleFunc("/testnets/{id}/nodes", getTestNetNodes).Methods("GET")
 
+	router.HandleFunc("/testnets/{id}/nodes", delTestNetNodes).Methods("DELETE")
+
+	router.HandleFunc("/testnets/{id}/files", uploadFilesToNodes).Methods("PUT")
+
+	router.HandleFunc("/testnets/{id}/nodes/{node}/files", uploadFilesToNode).Methods("PUT")
+
+	router.HandleFunc("/testnets/{id}/nodes/{node}/files", downloadFileFromNode).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/exec", execOnAllNodes).Methods("POST")
+
+	router.HandleFunc("/testnets/{id}/exec", getExecAuditLog).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/nodes/{node}/exec", execOnNode).Methods("POST")
+
+	router.HandleFunc("/testnets/{id}/nodes/{node}/shell", nodeShell).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/load", startLoad).Methods("POST")
+
+	router.HandleFunc("/testnets/{id}/load", adjustLoad).Methods("PUT")
+
+	router.HandleFunc("/testnets/{id}/load", stopLoad).Methods("DELETE")
+
+	router.HandleFunc("/testnets/{id}/load", getLoad).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/report", getReport).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/report/observer", startObserving).Methods("POST")
+
+	router.HandleFunc("/testnets/{id}/report/observer", stopObserving).Methods("DELETE")
+
+	router.HandleFunc("/testnets/{id}/faults", getFaults).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/nodes/{node}/limits", setLimits).Methods("PUT")
+
+	router.HandleFunc("/testnets/{id}/nodes/{node}/limits", resetLimits).Methods("DELETE")
+
+	router.HandleFunc("/testnets/{id}/nodes/{node}/stress", startStress).Methods("POST")
+
+	router.HandleFunc("/testnets/{id}/nodes/{node}/stress", stopStress).Methods("DELETE")
+
+	router.HandleFunc("/testnets/{id}/upgrade", upgradeNodes).Methods("POST")
+
+	router.HandleFunc("/testnets/{id}/upgrade", getUpgradeStatus).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/topology", getTopology).Methods("GET")
+
 	/**Management Functions**/
 	router.HandleFunc("/status/nodes/{testnetID}", nodesStatus).Methods("GET")
 
@@ -75,12 +122,26 @@ func StartServer() {
 
 	router.HandleFunc("/nodes/{id}/{num}", delNodes).Methods("DELETE") //Completely remove x nodes
 
-	router.HandleFunc("/nodes/restart/{testnetID}/{num}", restartNode).Methods("POST")
+	router.HandleFunc("/nodes/restart/{testnetID}/{node}", restartNode).Methods("POST")
+
+	router.HandleFunc("/nodes/start/{testnetID}/{node}", startNode).Methods("POST")
+
+	router.HandleFunc("/nodes/stop/{testnetID}/{node}", stopNode).Methods("POST")
+
+	router.HandleFunc("/nodes/process/{testnetID}/{node}", getNodeProcess).Methods("GET")
 
 	router.HandleFunc("/nodes/raise/{testnetID}/{node}/{signal}", signalNode).Methods("POST")
 
 	router.HandleFunc("/nodes/kill/{testnetID}/{node}", killNode).Methods("POST")
 
+	router.HandleFunc("/nodes/pause/{testnetID}", pauseNodes).Methods("POST")
+
+	router.HandleFunc("/nodes/pause/{testnetID}/{node}", pauseNodes).Methods("POST")
+
+	router.HandleFunc("/nodes/unpause/{testnetID}", unpauseNodes).Methods("POST")
+
+	router.HandleFunc("/nodes/unpause/{testnetID}/{node}", unpauseNodes).Methods("POST")
+
 	router.HandleFunc("/build/{id}", stopBuild).Methods("DELETE")
 
 	router.HandleFunc("/build", getPreviousBuild).Methods("GET")
@@ -100,6 +161,10 @@ func StartServer() {
 
 	router.HandleFunc("/emulate/all/{testnetID}", handleNetAll).Methods("POST")
 
+	router.HandleFunc("/emulate/clock/{testnetID}", getClocks).Methods("GET")
+	router.HandleFunc("/emulate/clock/{testnetID}", setClocks).Methods("POST")
+	router.HandleFunc("/emulate/clock/{testnetID}", resetClocks).Methods("DELETE")
+
 	router.HandleFunc("/resources/{blockchain}", getConfFiles).Methods("GET")
 
 	router.HandleFunc("/resources/{blockchain}/{file}", getConfFile).Methods("GET")
@@ -112,13 +177,29 @@ func StartServer() {
 
 	router.HandleFunc("/outage/{testnetID}", getAllOutages).Methods("GET")
 
+	router.HandleFunc("/outage/drift/{testnetID}", getOutageDrift).Methods("GET")
+
+	router.HandleFunc("/outage/reconcile/{testnetID}", reconcileOutages).Methods("POST")
+
 	router.HandleFunc("/outage/{testnetID}/{node}", getAllOutages).Methods("GET")
 
 	router.HandleFunc("/partition/{testnetID}", partitionOutage).Methods("POST")
 
 	router.HandleFunc("/partition/{testnetID}", getAllPartitions).Methods("GET")
 
+	router.HandleFunc("/partition/{testnetID}/reachability", getReachability).Methods("GET")
+
+	router.HandleFunc("/stats/{testnetID}", getResourceUsage).Methods("GET")
+
+	router.HandleFunc("/stats/{testnetID}/{node}", getResourceUsage).Methods("GET")
+
+	router.HandleFunc("/stats/{testnetID}", startResourceSampling).Methods("POST")
+
+	router.HandleFunc("/stats/{testnetID}", stopResourceSampling).Methods("DELETE")
+
 	router.HandleFunc("/blockchains", getAllSupportedBlockchains).Methods("GET")
+
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
 	log.WithFields(log.Fields{"socket": conf.Listen}).Info("listening for requests")
 	log.Fatal(http.ListenAndServe(conf.Listen, removeTrailingSlash(router)))
 }
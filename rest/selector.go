@@ -0,0 +1,42 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+)
+
+// resolveNodes gets the absolute numbers of the nodes a node given in a request refers to, which can
+// be either the absolute number of a node or a label selector
+func resolveNodes(testnetID string, target string) ([]int, error) {
+	nodes, err := db.GetAllNodesByTestNet(testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	selected, err := db.ResolveNodes(nodes, target)
+	if err != nil {
+		return nil, err
+	}
+	out := []int{}
+	for _, node := range selected {
+		out = append(out, node.AbsoluteNum)
+	}
+	return out, nil
+}
@@ -0,0 +1,240 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultShellRows = 24
+	defaultShellCols = 80
+)
+
+// shellMessage is the message passed in both directions over the shell websocket.
+// The client sends "stdin" and "resize" messages, the server sends "stdout" and "exit" messages.
+type shellMessage struct {
+	Type   string `json:"type"`
+	Data   string `json:"data,omitempty"`
+	Rows   int    `json:"rows,omitempty"`
+	Cols   int    `json:"cols,omitempty"`
+	Code   int    `json:"code,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// shellProxy proxies a shell session over a websocket connection
+type shellProxy struct {
+	ws    *websocket.Conn
+	shell *ssh.Shell
+	mux   sync.Mutex
+
+	activity chan bool
+}
+
+func (sp *shellProxy) send(msg shellMessage) error {
+	sp.mux.Lock()
+	defer sp.mux.Unlock()
+	return websocket.JSON.Send(sp.ws, msg)
+}
+
+func (sp *shellProxy) touch() {
+	select {
+	case sp.activity <- true:
+	default:
+	}
+}
+
+// forwardOutput sends the output of the shell to the client until the shell closes
+func (sp *shellProxy) forwardOutput() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := sp.shell.Stdout.Read(buf)
+		if n > 0 {
+			sp.touch()
+			if sp.send(shellMessage{Type: "stdout", Data: string(buf[:n])}) != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// forwardInput applies the messages from the client to the shell until the connection closes
+func (sp *shellProxy) forwardInput() {
+	for {
+		var msg shellMessage
+		err := websocket.JSON.Receive(sp.ws, &msg)
+		if err != nil {
+			sp.shell.Close()
+			return
+		}
+		sp.touch()
+		switch msg.Type {
+		case "stdin":
+			_, err = sp.shell.Stdin.Write([]byte(msg.Data))
+		case "resize":
+			err = sp.shell.Resize(msg.Rows, msg.Cols)
+		default:
+			err = fmt.Errorf("unknown message type \"%s\"", msg.Type)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Warn("failed to handle shell message")
+		}
+	}
+}
+
+// watchIdle closes the shell once there has been no activity for the configured idle timeout
+func (sp *shellProxy) watchIdle(done chan bool) {
+	if conf.ShellIdleTimeout <= 0 {
+		return
+	}
+	timeout := time.Duration(conf.ShellIdleTimeout) * time.Second
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-sp.activity:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(timeout)
+		case <-timer.C:
+			sp.send(shellMessage{Type: "exit", Code: -1, Reason: "idle timeout"})
+			sp.shell.Close()
+			return
+		}
+	}
+}
+
+// checkShellOrigin prevents cross-site websocket hijacking by only accepting connections from browsers on the
+// same host as genesis or on one of the shellOrigins. Clients which are not browsers do not send an Origin.
+func checkShellOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 {
+		return nil
+	}
+	for _, allowed := range conf.ShellOrigins {
+		if origin == allowed {
+			return nil
+		}
+	}
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if originURL.Host != r.Host {
+		return fmt.Errorf("origin %s is not allowed", origin)
+	}
+	return nil
+}
+
+func nodeShell(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	kid, err := getCallerKid(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 403)
+		return
+	}
+	if !callerIsAdmin(r) {
+		http.Error(w, "only administrators may open a shell", 403)
+		return
+	}
+	nodeNum, err := strconv.Atoi(params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	nodes, err := db.GetAllNodesByTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	node, err := db.GetNodeByAbsNum(nodes, nodeNum)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	client, err := status.GetClient(node.Server)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	rows, err := strconv.Atoi(r.URL.Query().Get("rows"))
+	if err != nil || rows <= 0 {
+		rows = defaultShellRows
+	}
+	cols, err := strconv.Atoi(r.URL.Query().Get("cols"))
+	if err != nil || cols <= 0 {
+		cols = defaultShellCols
+	}
+
+	server := websocket.Server{
+		Handshake: checkShellOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			start := time.Now()
+			shell, err := client.DockerShell(node, "bash", rows, cols)
+			if err != nil {
+				websocket.JSON.Send(ws, shellMessage{Type: "exit", Code: -1, Reason: err.Error()})
+				return
+			}
+			defer shell.Close()
+			log.WithFields(log.Fields{"testnet": params["id"], "node": nodeNum, "kid": kid}).Info("opened a shell")
+
+			proxy := &shellProxy{ws: ws, shell: shell, activity: make(chan bool, 1)}
+			done := make(chan bool)
+			go proxy.watchIdle(done)
+			go proxy.forwardInput()
+			proxy.forwardOutput()
+
+			code, err := shell.Wait()
+			close(done)
+			if err != nil {
+				proxy.send(shellMessage{Type: "exit", Code: code, Reason: err.Error()})
+			} else {
+				proxy.send(shellMessage{Type: "exit", Code: code})
+			}
+			util.LogError(db.InsertExecAuditEntry(db.ExecAuditEntry{
+				TestNetID: params["id"],
+				Node:      nodeNum,
+				Kid:       kid,
+				Command:   "bash (interactive)",
+				Time:      start.UnixNano() / int64(time.Millisecond),
+				ExitCode:  code,
+			}))
+		},
+	}
+	server.ServeHTTP(w, r)
+}
@@ -0,0 +1,127 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func getResourceUsage(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	node := -1
+	if _, ok := params["node"]; ok {
+		var err error
+		node, err = strconv.Atoi(params["node"])
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	var from, to int64
+	var err error
+	if r.URL.Query().Get("from") != "" {
+		from, err = strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	if r.URL.Query().Get("to") != "" {
+		to, err = strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+
+	samples, err := db.GetResourceSamples(params["testnetID"], node, from, to)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	if r.URL.Query().Get("format") != "csv" {
+		json.NewEncoder(w).Encode(samples)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-stats.csv\"", params["testnetID"]))
+	out := csv.NewWriter(w)
+	out.Write([]string{"time", "node", "cpu", "memoryUsage", "memoryLimit", "netRx", "netTx", "blockRead", "blockWrite"})
+	for _, sample := range samples {
+		out.Write([]string{
+			strconv.FormatInt(sample.Time, 10),
+			strconv.Itoa(sample.Node),
+			strconv.FormatFloat(sample.CPU, 'f', 2, 64),
+			strconv.FormatInt(sample.MemoryUsage, 10),
+			strconv.FormatInt(sample.MemoryLimit, 10),
+			strconv.FormatInt(sample.NetRx, 10),
+			strconv.FormatInt(sample.NetTx, 10),
+			strconv.FormatInt(sample.BlockRead, 10),
+			strconv.FormatInt(sample.BlockWrite, 10),
+		})
+	}
+	out.Flush()
+	util.LogError(out.Error())
+}
+
+func startResourceSampling(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	_, err := db.GetBuildByTestnet(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	interval := conf.ResourceSampleInterval
+	if r.URL.Query().Get("interval") != "" {
+		interval, err = strconv.Atoi(r.URL.Query().Get("interval"))
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	err = status.StartSampling(params["testnetID"], time.Duration(interval)*time.Second)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func stopResourceSampling(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	status.StopSampling(params["testnetID"])
+	if r.URL.Query().Get("purge") == "true" {
+		err := db.DeleteResourceSamples(params["testnetID"])
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
+	}
+	w.Write([]byte("Success"))
+}
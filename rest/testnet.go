@@ -26,12 +26,11 @@ import (
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/manager"
 	"github.com/whiteblock/genesis/state"
-	"github.com/whiteblock/genesis/status"
-	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
-	"strings"
 )
 
 func createTestNet(w http.ResponseWriter, r *http.Request) {
@@ -91,6 +90,13 @@ func getTestNetNodes(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
+	if selector := r.URL.Query().Get("selector"); len(selector) > 0 {
+		nodes, err = db.ResolveNodes(nodes, selector)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
 	json.NewEncoder(w).Encode(nodes)
 }
 
@@ -119,11 +125,36 @@ func addNodes(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Testnet is down, build a new one", 409)
 		return
 	}
+	if !bs.Done() {
+		http.Error(w, "There is a build in progress", 409)
+		return
+	}
 	bs.Reset()
 	w.Write([]byte("Adding the nodes"))
 	go manager.AddNodes(&tn, testnetID)
 }
 
+// removeNodes removes the given nodes from a testnet, while holding the build state of the testnet
+func removeNodes(w http.ResponseWriter, testnetID string, absNums []int) {
+	bs, err := state.GetBuildStateByID(testnetID)
+	if err != nil {
+		util.LogError(err)
+		http.Error(w, "Testnet is down, build a new one", 409)
+		return
+	}
+	if !bs.Done() {
+		http.Error(w, "There is a build in progress", 409)
+		return
+	}
+	bs.Reset()
+	err = manager.DelNodes(testnetID, absNums)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
 func delNodes(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	num, err := strconv.Atoi(params["num"])
@@ -135,175 +166,173 @@ func delNodes(w http.ResponseWriter, r *http.Request) {
 
 	testnetID := params["id"]
 
-	tn, err := db.GetBuildByTestnet(testnetID)
+	nodes, err := db.GetAllNodesByTestNet(testnetID)
 	if err != nil {
 		util.LogError(err)
 		http.Error(w, "Could not find the given testnet id", 400)
 		return
 	}
-
-	err = state.AcquireBuilding(tn.Servers, testnetID) //TODO: THIS IS WRONG
-	if err != nil {
-		util.LogError(err)
-		http.Error(w, "There is a build in progress", 409)
+	if num <= 0 || num > len(nodes) {
+		http.Error(w, "Invalid number of nodes", 400)
 		return
 	}
-	w.Write([]byte("Deleting the nodes"))
-	go manager.DelNodes(num, testnetID)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].AbsoluteNum < nodes[j].AbsoluteNum })
+	absNums := []int{}
+	for _, node := range nodes[len(nodes)-num:] {
+		absNums = append(absNums, node.AbsoluteNum)
+	}
+	removeNodes(w, testnetID, absNums)
 }
 
-func restartNode(w http.ResponseWriter, r *http.Request) {
+func delTestNetNodes(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	testnetID := params["id"]
-	nodeNum := params["num"]
-	log.WithFields(log.Fields{"testnet": testnetID, "node": nodeNum}).Info("restarting a node")
-	tn, err := testnet.RestoreTestNet(testnetID)
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 404)
+	var absNums []int
+	err := json.NewDecoder(r.Body).Decode(&absNums)
+	if err != nil && err != io.EOF {
+		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
-	cmdRaw, ok := tn.BuildState.Get(nodeNum)
-	log.WithFields(log.Fields{"extras": tn.BuildState.GetExtras()}).Debug("fetched the previous build state")
-	if !ok {
-		log.WithFields(log.Fields{"node": nodeNum}).Error("node not found")
-		http.Error(w, fmt.Sprintf("Node %s not found", nodeNum), 404)
-		return
+	if selector := r.URL.Query().Get("selector"); len(selector) > 0 {
+		absNums, err = resolveNodes(params["id"], selector)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
 	}
-	cmd := cmdRaw.(util.Command)
+	removeNodes(w, params["id"], absNums)
+}
 
-	client, err := status.GetClient(cmd.ServerID)
+// decodeLifecycleRequest decodes the optional body of a lifecycle request, and gets the nodes it is for
+func decodeLifecycleRequest(w http.ResponseWriter, r *http.Request) ([]int, manager.LifecycleRequest, bool) {
+	var req manager.LifecycleRequest
+	params := mux.Vars(r)
+	nodes, err := resolveNodes(params["testnetID"], params["node"])
 	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
-		return
+		http.Error(w, util.LogError(err).Error(), 400)
+		return nil, req, false
 	}
-	cmdgexCmd := fmt.Sprintf("ps aux | grep '%s' | grep -v grep|  awk '{print $2}'| tail -n 1", strings.Split(cmd.Cmdline, " ")[0])
-	node, err := db.GetNodeByLocalID(tn.Nodes, cmd.Node)
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
-		return
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return nil, req, false
 	}
-	pid, err := client.DockerExec(node, cmdgexCmd)
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
+	if req.ChangesCommand() && !callerIsAdmin(r) {
+		http.Error(w, "only administrators may change the command of a node", 403)
+		return nil, req, false
+	}
+	if len(req.SideCar) == 0 {
+		req.SideCar = r.URL.Query().Get("sidecar")
+	}
+	return nodes, req, true
+}
+
+func restartNode(w http.ResponseWriter, r *http.Request) {
+	nodes, req, ok := decodeLifecycleRequest(w, r)
+	if !ok {
 		return
 	}
-	_, err = client.DockerExec(node, fmt.Sprintf("kill -INT %s", pid))
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
+	testnetID := mux.Vars(r)["testnetID"]
+	for _, node := range nodes {
+		log.WithFields(log.Fields{"testnet": testnetID, "node": node, "sidecar": req.SideCar}).Info("restarting a node")
+		err := manager.RestartNode(testnetID, node, req)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
+	}
+	w.Write([]byte("Success"))
+}
+
+func startNode(w http.ResponseWriter, r *http.Request) {
+	nodes, req, ok := decodeLifecycleRequest(w, r)
+	if !ok {
 		return
 	}
+	testnetID := mux.Vars(r)["testnetID"]
+	for _, node := range nodes {
+		log.WithFields(log.Fields{"testnet": testnetID, "node": node, "sidecar": req.SideCar}).Info("starting a node")
+		err := manager.StartNode(testnetID, node, req)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
+	}
+	w.Write([]byte("Success"))
+}
 
-	for {
-		_, err = client.DockerExec(node, fmt.Sprintf("ps aux | grep '%s' | grep -v grep", strings.Split(cmd.Cmdline, " ")[0]))
+func stopNode(w http.ResponseWriter, r *http.Request) {
+	nodes, req, ok := decodeLifecycleRequest(w, r)
+	if !ok {
+		return
+	}
+	testnetID := mux.Vars(r)["testnetID"]
+	for _, node := range nodes {
+		log.WithFields(log.Fields{"testnet": testnetID, "node": node, "sidecar": req.SideCar}).Info("stopping a node")
+		err := manager.StopNode(testnetID, node, req)
 		if err != nil {
-			break
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
 		}
 	}
+	w.Write([]byte("Success"))
+}
 
-	err = client.DockerExecdLogAppend(node, cmd.Cmdline)
+func getNodeProcess(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	node, err := strconv.Atoi(params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	out, err := manager.GetProcessStatus(params["testnetID"], node, r.URL.Query().Get("sidecar"))
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
 	}
-	w.Write([]byte("Success"))
+	json.NewEncoder(w).Encode(out)
 }
 
 func signalNode(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	testnetID := params["testnetID"]
 	node := params["node"]
-	nodeNum, err := strconv.Atoi(node)
+	nodes, err := resolveNodes(testnetID, node)
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
 	signal := params["signal"]
-	log.WithFields(log.Fields{"testnet": testnetID, "node": nodeNum, "signal": signal}).Info("sending signal to node")
 	err = util.ValidateCommandLine(signal)
 	if err != nil {
 		util.LogError(err)
 		http.Error(w, fmt.Sprintf("Invalid signal \"%s\", see `man 7 signal` for help", signal), 400)
-	}
-
-	tn, err := testnet.RestoreTestNet(testnetID)
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 404)
-		return
-	}
-	if nodeNum >= len(tn.Nodes) {
-		http.Error(w, fmt.Sprintf("Node %d does not exist. Try node 0 through node %d", nodeNum, len(tn.Nodes)), 400)
 		return
 	}
-	n := &tn.Nodes[nodeNum]
-	cmdRaw, ok := tn.BuildState.Get(node)
-	if !ok {
-		log.WithFields(log.Fields{"node": node}).Error("node not found")
-		http.Error(w, fmt.Sprintf("Node %s not found", node), 404)
-		return
-	}
-	cmd := cmdRaw.(util.Command)
 
-	cmdgexCmd := fmt.Sprintf("ps aux | grep '%s' | grep -v grep|  awk '{print $2}'| tail -n 1", strings.Split(cmd.Cmdline, " ")[0])
-	pid, err := tn.Clients[n.Server].DockerExec(n, cmdgexCmd)
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
-		return
-	}
-
-	_, err = tn.Clients[n.Server].DockerExec(n, fmt.Sprintf("kill -%s %s", signal, pid))
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
-		return
+	for _, nodeNum := range nodes {
+		log.WithFields(log.Fields{"testnet": testnetID, "node": nodeNum, "signal": signal}).Info("sending signal to node")
+		err = manager.SignalNode(testnetID, nodeNum, r.URL.Query().Get("sidecar"), signal)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
 	}
 	w.Write([]byte(fmt.Sprintf("Sent signal %s to node %s", signal, node)))
 }
 
 func killNode(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	testnetID := params["testnetID"]
-	log.WithFields(log.Fields{"testnet": testnetID, "node": params["node"]}).Info("killing a node's main process")
-	tn, err := testnet.RestoreTestNet(testnetID)
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 404)
-		return
-	}
-	cmdRaw, ok := tn.BuildState.Get(params["node"])
+	nodes, req, ok := decodeLifecycleRequest(w, r)
 	if !ok {
-		log.WithFields(log.Fields{"node": params["node"]}).Warn("node not found")
-		http.Error(w, fmt.Sprintf("Node %s not found", params["node"]), 404)
-		return
-	}
-	cmd := cmdRaw.(util.Command)
-
-	client, err := status.GetClient(cmd.ServerID)
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
-		return
-	}
-	cmdgexCmd := fmt.Sprintf("ps aux | grep '%s' | grep -v grep|  awk '{print $2}'| tail -n 1", strings.Split(cmd.Cmdline, " ")[0])
-	node, err := db.GetNodeByLocalID(tn.Nodes, cmd.Node)
-	if err != nil {
-		log.WithFields(log.Fields{"node": cmd.Node, "error": err}).Error("error getting node from db")
-		http.Error(w, err.Error(), 500)
 		return
 	}
-
-	pid, err := client.DockerExec(node, cmdgexCmd)
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
-		return
-	}
-	_, err = client.DockerExec(node, fmt.Sprintf("kill -INT %s", pid))
-	if err != nil {
-		http.Error(w, util.LogError(err).Error(), 500)
-		return
-	}
-
-	for {
-		_, err = client.DockerExec(node, fmt.Sprintf("ps aux | grep '%s' | grep -v grep", strings.Split(cmd.Cmdline, " ")[0]))
+	testnetID := mux.Vars(r)["testnetID"]
+	for _, node := range nodes {
+		log.WithFields(log.Fields{"testnet": testnetID, "node": node}).Info("killing a node's main process")
+		err := manager.KillNode(testnetID, node, req)
 		if err != nil {
-			break
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
 		}
 	}
-	w.Write([]byte(fmt.Sprintf("Killed node %s", params["node"])))
+	w.Write([]byte(fmt.Sprintf("Killed node %s", mux.Vars(r)["node"])))
 }
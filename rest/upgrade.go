@@ -0,0 +1,57 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/manager"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+func upgradeNodes(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var req manager.UpgradeRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if req.ChangesCommand() && !callerIsAdmin(r) {
+		http.Error(w, "only administrators may change the command of a node", 403)
+		return
+	}
+	err = manager.UpgradeNodes(params["id"], req)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte("Upgrade started"))
+}
+
+func getUpgradeStatus(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	out, err := manager.GetUpgradeStatus(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
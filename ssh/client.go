@@ -24,12 +24,15 @@ import (
 	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/metrics"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/util"
 	"github.com/whiteblock/scp"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/sync/semaphore"
+	"io"
 	"io/ioutil"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -57,9 +60,20 @@ type Client interface {
 	// DockerExec executes a command inside of a node
 	DockerExec(node Node, command string) (string, error)
 
+	// DockerExecStream executes a command inside of a node, writing the combined output to out as it
+	// is produced. Returns the exit code of the command.
+	DockerExecStream(node Node, command string, out io.Writer) (int, error)
+
+	// DockerShell starts the given command in a node with a pseudo terminal of the given size
+	DockerShell(node Node, command string, rows int, cols int) (*Shell, error)
+
 	// DockerCp copies a file on a remote machine from source to the dest in the node
 	DockerCp(node Node, source string, dest string) error
 
+	// DockerCpFrom streams the file or directory at source in the node to out as a tar archive.
+	// Fails if the archive is larger than limit bytes.
+	DockerCpFrom(node Node, source string, out io.Writer, limit int64) error
+
 	// KeepTryDockerExec is like KeepTryRun for nodes
 	KeepTryDockerExec(node Node, command string) (string, error)
 
@@ -86,6 +100,10 @@ type Client interface {
 	// Should only be used for the blockchain process. Will append to existing logs.
 	DockerExecdLogAppend(node Node, command string) error
 
+	// DockerExecdLogCommand starts the given command as the main process of a node, with its output
+	// stored in the logs. If appendLogs is set, the existing logs are kept.
+	DockerExecdLogCommand(node Node, cmd util.Command, appendLogs bool) error
+
 	// DockerRead will read a file on a node, if lines > -1 then
 	// it will return the last `lines` lines of the file
 	DockerRead(node Node, file string, lines int) (string, error)
@@ -129,9 +147,17 @@ func NewClient(host string, serverID int) (Client, error) {
 	out.serverID = serverID
 	out.mux = &sync.RWMutex{}
 	out.sem = semaphore.NewWeighted(int64(conf.MaxConnections))
+	metrics.SSHSessionsLimit.Set(float64(conf.MaxConnections), strconv.Itoa(serverID))
 	return out, nil
 }
 
+func (sshClient *client) newSession(session *ssh.Session) *Session {
+	out := NewSession(session, sshClient.sem)
+	out.server = strconv.Itoa(sshClient.serverID)
+	metrics.SSHSessionsActive.Inc(out.server)
+	return out
+}
+
 func (sshClient *client) getSession() (*Session, error) {
 	sshClient.mux.RLock()
 	ctx := context.TODO()
@@ -142,7 +168,7 @@ func (sshClient *client) getSession() (*Session, error) {
 			continue
 		}
 		sshClient.mux.RUnlock()
-		return NewSession(session, sshClient.sem), nil
+		return sshClient.newSession(session), nil
 	}
 	sshClient.mux.RUnlock()
 
@@ -168,7 +194,7 @@ func (sshClient *client) getSession() (*Session, error) {
 	sshClient.mux.Lock()
 	sshClient.clients = append(sshClient.clients, client)
 	sshClient.mux.Unlock()
-	return NewSession(session, sshClient.sem), nil
+	return sshClient.newSession(session), nil
 }
 
 // MultiRun provides an easy shorthand for multiple calls to sshExec
@@ -213,7 +239,11 @@ func (sshClient *client) Run(command string) (string, error) {
 		return "", bs.GetError()
 	}
 
+	start := time.Now()
 	out, err := session.Get().CombinedOutput(command)
+	server := strconv.Itoa(sshClient.serverID)
+	metrics.SSHCommandsTotal.Inc(server)
+	metrics.SSHCommandDuration.Observe(time.Since(start).Seconds(), server)
 	if conf.MaxCommandOutputLogSize == -1 || len(out) <= conf.MaxCommandOutputLogSize {
 		log.Infof("$ %s\n%s\n", command, out)
 	} else {
@@ -221,6 +251,7 @@ func (sshClient *client) Run(command string) (string, error) {
 	}
 
 	if err != nil {
+		metrics.SSHCommandErrors.Inc(server)
 		return string(out), util.FormatError(string(out), err)
 	}
 	return string(out), nil
@@ -249,12 +280,103 @@ func (sshClient *client) DockerExec(node Node, command string) (string, error) {
 	return sshClient.Run(fmt.Sprintf("docker exec %s %s", node.GetNodeName(), command))
 }
 
+// lockedWriter serializes writes to the underlying writer, as stdout and stderr are copied concurrently
+type lockedWriter struct {
+	out io.Writer
+	mux sync.Mutex
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mux.Lock()
+	defer lw.mux.Unlock()
+	return lw.out.Write(p)
+}
+
+// DockerExecStream executes a command inside of a node, writing the combined output to out as it
+// is produced. Returns the exit code of the command.
+func (sshClient *client) DockerExecStream(node Node, command string, out io.Writer) (int, error) {
+	session, err := sshClient.getSession()
+	if err != nil {
+		return -1, util.LogError(err)
+	}
+	defer session.Close()
+	command = fmt.Sprintf("docker exec %s %s", node.GetNodeName(), command)
+	log.WithFields(log.Fields{"host": sshClient.host, "command": command}).Info("executing command with streamed output")
+
+	writer := &lockedWriter{out: out}
+	session.Get().Stdout = writer
+	session.Get().Stderr = writer
+
+	start := time.Now()
+	err = session.Get().Run(command)
+	server := strconv.Itoa(sshClient.serverID)
+	metrics.SSHCommandsTotal.Inc(server)
+	metrics.SSHCommandDuration.Observe(time.Since(start).Seconds(), server)
+
+	if exitErr, ok := err.(*ssh.ExitError); ok {
+		return exitErr.ExitStatus(), nil
+	}
+	if err != nil {
+		metrics.SSHCommandErrors.Inc(server)
+		return -1, util.LogError(err)
+	}
+	return 0, nil
+}
+
 // DockerCp copies a file on a remote machine from source to the dest in the node
 func (sshClient *client) DockerCp(node Node, source string, dest string) error {
 	_, err := sshClient.Run(fmt.Sprintf("docker cp %s %s:%s", source, node.GetNodeName(), dest))
 	return util.LogError(err)
 }
 
+// countingWriter counts the number of bytes written through it
+type countingWriter struct {
+	out io.Writer
+	n   int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.out.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// DockerCpFrom streams the file or directory at source in the node to out as a tar archive.
+// Fails if the archive is larger than limit bytes.
+func (sshClient *client) DockerCpFrom(node Node, source string, out io.Writer, limit int64) error {
+	session, err := sshClient.getSession()
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer session.Close()
+	//head stops the transfer as soon as it is known to be over the limit
+	command := fmt.Sprintf("docker cp %s:%s - | head -c %d", node.GetNodeName(), source, limit+1)
+	log.WithFields(log.Fields{"host": sshClient.host, "command": command}).Info("executing command with streamed output")
+
+	writer := &countingWriter{out: out}
+	stderr := &strings.Builder{}
+	session.Get().Stdout = writer
+	session.Get().Stderr = stderr
+
+	start := time.Now()
+	err = session.Get().Run(command)
+	server := strconv.Itoa(sshClient.serverID)
+	metrics.SSHCommandsTotal.Inc(server)
+	metrics.SSHCommandDuration.Observe(time.Since(start).Seconds(), server)
+
+	if writer.n > limit {
+		return fmt.Errorf("%s is larger than the limit of %d bytes", source, limit)
+	}
+	if err == nil && stderr.Len() > 0 { //the exit status of docker cp is hidden by head
+		err = fmt.Errorf("docker cp failed")
+	}
+	if err != nil {
+		metrics.SSHCommandErrors.Inc(server)
+		return util.LogError(util.FormatError(stderr.String(), err))
+	}
+	return nil
+}
+
 // KeepTryDockerExec is like KeepTryRun for nodes
 func (sshClient *client) KeepTryDockerExec(node Node, command string) (string, error) {
 	return sshClient.KeepTryRun(fmt.Sprintf("docker exec %s %s", node.GetNodeName(), command))
@@ -289,30 +411,34 @@ func (sshClient *client) DockerExecdit(node Node, command string) (string, error
 	return sshClient.Run(fmt.Sprintf("docker exec -itd %s %s", node.GetNodeName(), command))
 }
 
-func (sshClient *client) logSanitizeAndStore(node Node, command string) {
-	if strings.Count(command, "'") != strings.Count(command, "\\'") {
+func (sshClient *client) logSanitizeAndStore(node Node, cmd util.Command) {
+	if strings.Count(cmd.Cmdline, "'") != strings.Count(cmd.Cmdline, "\\'") {
 		log.Panic("DockerExecdLog commands cannot contain unescaped ' characters")
 	}
+	cmd.ServerID = sshClient.serverID
+	cmd.Node = node.GetRelativeNumber()
 	bs := state.GetBuildStateByServerID(sshClient.serverID)
-	bs.Set(fmt.Sprintf("%d", node.GetAbsoluteNumber()), util.Command{Cmdline: command, ServerID: sshClient.serverID, Node: node.GetRelativeNumber()})
+	bs.Set(CommandKey(node), cmd)
 }
 
 // DockerExecdLog will cause the stdout and stderr of the command to be stored in the logs.
 // Should only be used for the blockchain process.
 func (sshClient *client) DockerExecdLog(node Node, command string) error {
-	sshClient.logSanitizeAndStore(node, command)
-
-	_, err := sshClient.Run(fmt.Sprintf("docker exec -d %s bash -c '%s 2>&1 > %s'", node.GetNodeName(),
-		command, conf.DockerOutputFile))
-	return util.LogError(err)
+	return sshClient.DockerExecdLogCommand(node, util.Command{Cmdline: command}, false)
 }
 
 // DockerExecdLogAppend will cause the stdout and stderr of the command to be stored in the logs.
 // Should only be used for the blockchain process. Will append to existing logs.
 func (sshClient *client) DockerExecdLogAppend(node Node, command string) error {
-	sshClient.logSanitizeAndStore(node, command)
-	_, err := sshClient.Run(fmt.Sprintf("docker exec -d %s bash -c '%s 2>&1 >> %s'", node.GetNodeName(),
-		command, conf.DockerOutputFile))
+	return sshClient.DockerExecdLogCommand(node, util.Command{Cmdline: command}, true)
+}
+
+// DockerExecdLogCommand starts the given command as the main process of a node, with its output
+// stored in the logs. If appendLogs is set, the existing logs are kept. The process is tracked through
+// MainPidFile, so that it can later be signalled with SignalMainProcess.
+func (sshClient *client) DockerExecdLogCommand(node Node, cmd util.Command, appendLogs bool) error {
+	sshClient.logSanitizeAndStore(node, cmd)
+	_, err := sshClient.Run(fmt.Sprintf("docker exec -d %s %s", node.GetNodeName(), mainProcessCommand(cmd, appendLogs)))
 	return util.LogError(err)
 }
 
@@ -20,6 +20,8 @@ package ssh
 
 import (
 	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"io"
 )
 
 type fakeClient struct {
@@ -74,11 +76,33 @@ func (fc *fakeClient) DockerExec(node Node, command string) (string, error) {
 	return fc.Run(command)
 }
 
+// DockerExecStream executes a command inside of a node, writing the combined output to out as it
+// is produced. Returns the exit code of the command.
+func (fc *fakeClient) DockerExecStream(node Node, command string, out io.Writer) (int, error) {
+	res, err := fc.Run(command)
+	if err != nil {
+		return -1, err
+	}
+	_, err = out.Write([]byte(res))
+	return 0, err
+}
+
+// DockerShell starts the given command in a node with a pseudo terminal of the given size
+func (fc *fakeClient) DockerShell(node Node, command string, rows int, cols int) (*Shell, error) {
+	return nil, fmt.Errorf("interactive sessions are not supported by the test client")
+}
+
 // DockerCp copies a file on a remote machine from source to the dest in the node
 func (fc *fakeClient) DockerCp(node Node, source string, dest string) error {
 	return nil
 }
 
+// DockerCpFrom streams the file or directory at source in the node to out as a tar archive.
+// Fails if the archive is larger than limit bytes.
+func (fc *fakeClient) DockerCpFrom(node Node, source string, out io.Writer, limit int64) error {
+	return nil
+}
+
 // KeepTryDockerExec is like KeepTryRun for nodes
 func (fc *fakeClient) KeepTryDockerExec(node Node, command string) (string, error) {
 	return fc.Run(command)
@@ -117,6 +141,12 @@ func (fc *fakeClient) DockerExecdLogAppend(node Node, command string) error {
 	return nil
 }
 
+// DockerExecdLogCommand starts the given command as the main process of a node, with its output
+// stored in the logs. If appendLogs is set, the existing logs are kept.
+func (fc *fakeClient) DockerExecdLogCommand(node Node, cmd util.Command, appendLogs bool) error {
+	return nil
+}
+
 // DockerRead will read a file on a node, if lines > -1 then
 // it will return the last `lines` lines of the file
 func (fc *fakeClient) DockerRead(node Node, file string, lines int) (string, error) {
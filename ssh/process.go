@@ -0,0 +1,73 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ssh
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"strings"
+)
+
+// MainPidFile is the file within a node which holds the process group id of its main process
+const MainPidFile = "/tmp/wb_main.pid"
+
+// CommandKey gives the key under which the main command of a node or side car is stored in the build state
+func CommandKey(node Node) string {
+	return node.GetNodeName()
+}
+
+// escapeEnvValue escapes a value to be placed within double quotes
+func escapeEnvValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value)
+}
+
+// mainProcessCommand wraps the main process of a node, so that it is run in its own process group, with the
+// id of that group written to MainPidFile. This allows the process and all of its children to be
+// signalled together.
+func mainProcessCommand(cmd util.Command, appendLogs bool) string {
+	redirect := ">"
+	if appendLogs {
+		redirect = ">>"
+	}
+	keys := make([]string, 0, len(cmd.Env))
+	for key := range cmd.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	env := ""
+	for _, key := range keys {
+		env += fmt.Sprintf("export %s=\"%s\"; ", key, escapeEnvValue(cmd.Env[key]))
+	}
+	return fmt.Sprintf("bash -c 'set -m; (%s%s) 2>&1 %s %s & echo $! > %s; wait'",
+		env, cmd.Cmdline, redirect, conf.DockerOutputFile, MainPidFile)
+}
+
+// SignalMainProcess sends a signal to the main process of a node, along with all of its children
+func SignalMainProcess(client Client, node Node, signal string) error {
+	_, err := client.DockerExec(node, fmt.Sprintf("bash -c 'kill -%s -- -$(cat %s)'", signal, MainPidFile))
+	return err
+}
+
+// MainProcessRunning checks whether the main process of a node, or any of its children, is still running
+func MainProcessRunning(client Client, node Node) (bool, error) {
+	res, err := client.DockerExec(node, fmt.Sprintf(
+		"bash -c 'kill -0 -- -$(cat %s 2>/dev/null) 2>/dev/null && echo running || true'", MainPidFile))
+	return strings.TrimSpace(res) == "running", err
+}
@@ -19,6 +19,7 @@
 package ssh
 
 import (
+	"github.com/whiteblock/genesis/metrics"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/sync/semaphore"
 )
@@ -28,6 +29,8 @@ import (
 type Session struct {
 	sess *ssh.Session
 	sem  *semaphore.Weighted
+
+	server string //the server id, used for tracking the sessions in use
 }
 
 // NewSession creates a new session from a native library ssh session and a semaphore
@@ -44,4 +47,7 @@ func (session Session) Get() *ssh.Session {
 func (session Session) Close() {
 	session.sem.Release(1)
 	session.sess.Close()
+	if session.server != "" {
+		metrics.SSHSessionsActive.Dec(session.server)
+	}
 }
@@ -0,0 +1,101 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ssh
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/util"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"sync"
+)
+
+// Shell is an interactive session with a pseudo terminal, attached to a process in a node
+type Shell struct {
+	session *Session
+	once    *sync.Once
+
+	// Stdin is the input to the process
+	Stdin io.WriteCloser
+	// Stdout is the output of the process, stderr is merged into it by the terminal
+	Stdout io.Reader
+}
+
+// Resize changes the size of the terminal
+func (shell *Shell) Resize(rows int, cols int) error {
+	return shell.session.Get().WindowChange(rows, cols)
+}
+
+// Wait waits for the process to exit, and returns its exit code
+func (shell *Shell) Wait() (int, error) {
+	err := shell.session.Get().Wait()
+	if exitErr, ok := err.(*ssh.ExitError); ok {
+		return exitErr.ExitStatus(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
+
+// Close terminates the session, it is safe to call more than once
+func (shell *Shell) Close() {
+	shell.once.Do(func() {
+		shell.Stdin.Close()
+		shell.session.Close()
+	})
+}
+
+// DockerShell starts the given command in a node with a pseudo terminal of the given size
+func (sshClient *client) DockerShell(node Node, command string, rows int, cols int) (*Shell, error) {
+	session, err := sshClient.getSession()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	err = session.Get().RequestPty("xterm", rows, cols, modes)
+	if err != nil {
+		session.Close()
+		return nil, util.LogError(err)
+	}
+	stdin, err := session.Get().StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, util.LogError(err)
+	}
+	stdout, err := session.Get().StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, util.LogError(err)
+	}
+	command = fmt.Sprintf("docker exec -it %s %s", node.GetNodeName(), command)
+	log.WithFields(log.Fields{"host": sshClient.host, "command": command}).Info("starting an interactive session")
+
+	err = session.Get().Start(command)
+	if err != nil {
+		session.Close()
+		return nil, util.LogError(err)
+	}
+	return &Shell{session: session, once: &sync.Once{}, Stdin: stdin, Stdout: stdout}, nil
+}
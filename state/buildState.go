@@ -24,11 +24,13 @@ import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/metrics"
 	"io/ioutil"
 	"os"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 //This code is full of potential race conditions but these race conditons are extremely rare
@@ -64,6 +66,7 @@ type BuildState struct {
 
 	BuildError CustomError
 	BuildStage string
+	stageStart time.Time
 
 	DeployProgress uint64
 	DeployTotal    uint64
@@ -226,6 +229,7 @@ func (bs *BuildState) DoneBuilding() {
 	bs.asyncWaiter.Wait() //Wait for the async calls to complete
 
 	bs.mutex.Lock()
+	bs.finishStage()
 	bs.BuildStage = "Finished"
 	bs.mutex.Unlock()
 
@@ -508,8 +512,18 @@ func (bs *BuildState) GetProgress() float64 {
 func (bs *BuildState) SetBuildStage(stage string) {
 	bs.mutex.Lock()
 	defer bs.mutex.Unlock()
+	bs.finishStage()
 	bs.BuildStage = stage
+	bs.stageStart = time.Now()
+}
 
+// finishStage records how long the current build stage took. bs.mutex must be held by the caller.
+func (bs *BuildState) finishStage() {
+	if len(bs.BuildStage) == 0 || bs.stageStart.IsZero() {
+		return
+	}
+	metrics.BuildStageDuration.Observe(time.Since(bs.stageStart).Seconds(), bs.BuildStage)
+	bs.stageStart = time.Time{}
 }
 
 // Reset sets the build state back the beginning. Used for when
@@ -526,6 +540,7 @@ func (bs *BuildState) Reset() {
 
 	bs.BuildError = CustomError{What: "", err: nil}
 	bs.BuildStage = ""
+	bs.stageStart = time.Time{}
 
 	atomic.StoreUint64(&bs.DeployProgress, 0)
 	atomic.StoreUint64(&bs.DeployTotal, 1)
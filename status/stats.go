@@ -0,0 +1,261 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package status
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dockerStatsFormat = "{{.Name}}\\t{{.CPUPerc}}\\t{{.MemUsage}}\\t{{.NetIO}}\\t{{.BlockIO}}"
+
+// samplersKey is the meta key under which the intervals of the running samplers are stored, so that
+// they can be restarted after a restart
+const samplersKey = "resource_samplers"
+
+var (
+	samplers         = map[string]chan bool{}
+	samplerIntervals = map[string]time.Duration{}
+	samplerMux       = sync.Mutex{}
+)
+
+var byteUnits = map[string]float64{
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"kib": 1024,
+	"mib": 1024 * 1024,
+	"gib": 1024 * 1024 * 1024,
+	"tib": 1024 * 1024 * 1024 * 1024,
+}
+
+// parseByteSize converts a human readable size as given by docker, such as 1.5MiB, into bytes
+func parseByteSize(size string) (int64, error) {
+	size = strings.TrimSpace(size)
+	i := 0
+	for ; i < len(size); i++ {
+		if (size[i] < '0' || size[i] > '9') && size[i] != '.' {
+			break
+		}
+	}
+	val, err := strconv.ParseFloat(size[:i], 64)
+	if err != nil {
+		return -1, fmt.Errorf("invalid size \"%s\"", size)
+	}
+	multiplier, ok := byteUnits[strings.ToLower(strings.TrimSpace(size[i:]))]
+	if !ok {
+		return -1, fmt.Errorf("unknown unit in size \"%s\"", size)
+	}
+	return int64(val * multiplier), nil
+}
+
+// parseBytePair parses a pair of sizes in the form of "<size> / <size>"
+func parseBytePair(pair string) (int64, int64, error) {
+	sizes := strings.Split(pair, "/")
+	if len(sizes) != 2 {
+		return -1, -1, fmt.Errorf("unexpected value \"%s\"", pair)
+	}
+	first, err := parseByteSize(sizes[0])
+	if err != nil {
+		return -1, -1, err
+	}
+	second, err := parseByteSize(sizes[1])
+	if err != nil {
+		return -1, -1, err
+	}
+	return first, second, nil
+}
+
+// parseDockerStatsLine parses a single entry of the output of docker stats into the name of the
+// container and its resource usage
+func parseDockerStatsLine(line string) (string, db.ResourceSample, error) {
+	var sample db.ResourceSample
+	values := strings.Split(line, "\t")
+	if len(values) != 5 {
+		return "", sample, fmt.Errorf("unexpected docker stats entry \"%s\"", line)
+	}
+	var err error
+	sample.CPU, err = strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(values[1]), "%"), 64)
+	if err != nil {
+		return "", sample, fmt.Errorf("invalid cpu usage \"%s\"", values[1])
+	}
+	sample.MemoryUsage, sample.MemoryLimit, err = parseBytePair(values[2])
+	if err != nil {
+		return "", sample, err
+	}
+	sample.NetRx, sample.NetTx, err = parseBytePair(values[3])
+	if err != nil {
+		return "", sample, err
+	}
+	sample.BlockRead, sample.BlockWrite, err = parseBytePair(values[4])
+	if err != nil {
+		return "", sample, err
+	}
+	return strings.TrimSpace(values[0]), sample, nil
+}
+
+// ParseDockerStats parses the output of docker stats, formatted with dockerStatsFormat,
+// into a map of container name to the resource usage of that container. Entries which cannot
+// be parsed, such as those of a container which is still starting and reports "--", are skipped.
+func ParseDockerStats(res string) (map[string]db.ResourceSample, error) {
+	out := map[string]db.ResourceSample{}
+	for _, line := range strings.Split(res, "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		name, sample, err := parseDockerStatsLine(line)
+		if err != nil {
+			log.WithFields(log.Fields{"entry": line, "error": err}).Warn("skipping docker stats entry")
+			continue
+		}
+		out[name] = sample
+	}
+	return out, nil
+}
+
+// SampleResourceUsage takes a single snapshot of the resource usage of every node in the given testnet
+func SampleResourceUsage(testnetID string) ([]db.ResourceSample, error) {
+	nodes, err := db.GetAllNodesByTestNet(testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	out := []db.ResourceSample{}
+
+	for _, serverID := range db.GetUniqueServerIDs(nodes) {
+		client, err := GetClient(serverID)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		names := []string{}
+		for _, node := range nodes {
+			if node.Server == serverID {
+				names = append(names, node.GetNodeName())
+			}
+		}
+		res, err := client.Run(fmt.Sprintf("docker stats --no-stream --format \"%s\" %s",
+			dockerStatsFormat, strings.Join(names, " ")))
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		stats, err := ParseDockerStats(res)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		for _, node := range nodes {
+			if node.Server != serverID {
+				continue
+			}
+			sample, ok := stats[node.GetNodeName()]
+			if !ok {
+				log.WithFields(log.Fields{"testnet": testnetID, "node": node.AbsoluteNum}).Warn("no stats found for node")
+				continue
+			}
+			sample.TestNetID = testnetID
+			sample.Node = node.AbsoluteNum
+			sample.Time = now
+			out = append(out, sample)
+		}
+	}
+	return out, nil
+}
+
+// StartSampling begins recording the resource usage of the nodes in the given testnet every interval.
+// If the testnet is already being sampled, the previous sampler will be replaced.
+func StartSampling(testnetID string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("sampling interval must be positive")
+	}
+	StopSampling(testnetID)
+
+	samplerMux.Lock()
+	defer samplerMux.Unlock()
+	stop := make(chan bool)
+	samplers[testnetID] = stop
+	samplerIntervals[testnetID] = interval
+	storeSamplers()
+
+	log.WithFields(log.Fields{"testnet": testnetID, "interval": interval}).Info("starting the resource usage sampler")
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				samples, err := SampleResourceUsage(testnetID)
+				if err != nil {
+					log.WithFields(log.Fields{"testnet": testnetID, "error": err}).Error("failed to sample resource usage")
+					continue
+				}
+				util.LogError(db.InsertResourceSamples(samples))
+			}
+		}
+	}()
+	return nil
+}
+
+// StopSampling stops the resource usage sampler for the given testnet, if there is one
+func StopSampling(testnetID string) {
+	samplerMux.Lock()
+	defer samplerMux.Unlock()
+	stop, ok := samplers[testnetID]
+	if !ok {
+		return
+	}
+	close(stop)
+	delete(samplers, testnetID)
+	delete(samplerIntervals, testnetID)
+	storeSamplers()
+}
+
+// storeSamplers stores the intervals of the running samplers. samplerMux must be held by the caller.
+func storeSamplers() {
+	db.DeleteMeta(samplersKey)
+	util.LogError(db.SetMeta(samplersKey, samplerIntervals))
+}
+
+// RestoreSampling restarts the samplers which were running when genesis last stopped
+func RestoreSampling() {
+	stored := map[string]time.Duration{}
+	err := db.GetMetaP(samplersKey, &stored)
+	if err != nil {
+		return //nothing has been sampled yet
+	}
+	for testnetID, interval := range stored {
+		util.LogError(StartSampling(testnetID, interval))
+	}
+}
+
+// IsSampling checks if the resource usage of the given testnet is currently being recorded
+func IsSampling(testnetID string) bool {
+	samplerMux.Lock()
+	defer samplerMux.Unlock()
+	_, ok := samplers[testnetID]
+	return ok
+}
@@ -0,0 +1,143 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package status
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+)
+
+func Test_parseByteSize(t *testing.T) {
+	var test = []struct {
+		size     string
+		expected int64
+		err      bool
+	}{
+		{size: "0B", expected: 0},
+		{size: "512B", expected: 512},
+		{size: "1.5kB", expected: 1500},
+		{size: "2MB", expected: 2000000},
+		{size: "1KiB", expected: 1024},
+		{size: " 1.5GiB ", expected: 1610612736},
+		{size: "--", err: true},
+		{size: "12", err: true},
+		{size: "4XB", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			size, err := parseByteSize(tt.size)
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseByteSize(\"%s\") did not return an error", tt.size)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			if size != tt.expected {
+				t.Errorf("parseByteSize(\"%s\") returned %d, expected %d", tt.size, size, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_parseBytePair(t *testing.T) {
+	var test = []struct {
+		pair   string
+		first  int64
+		second int64
+		err    bool
+	}{
+		{pair: "1kB / 2kB", first: 1000, second: 2000},
+		{pair: "10MiB / 1.944GiB", first: 10485760, second: 2087354105},
+		{pair: "-- / --", err: true},
+		{pair: "1kB", err: true},
+		{pair: "1kB / 2kB / 3kB", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			first, second, err := parseBytePair(tt.pair)
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseBytePair(\"%s\") did not return an error", tt.pair)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			if first != tt.first || second != tt.second {
+				t.Errorf("parseBytePair(\"%s\") returned %d, %d, expected %d, %d", tt.pair,
+					first, second, tt.first, tt.second)
+			}
+		})
+	}
+}
+
+func TestParseDockerStats(t *testing.T) {
+	var test = []struct {
+		res      string
+		expected map[string]db.ResourceSample
+	}{
+		{res: "", expected: map[string]db.ResourceSample{}},
+		{
+			res: "whiteblock-node0\t1.50%\t1MB / 2GB\t3kB / 4kB\t5B / 6B\n",
+			expected: map[string]db.ResourceSample{
+				"whiteblock-node0": {CPU: 1.5, MemoryUsage: 1000000, MemoryLimit: 2000000000,
+					NetRx: 3000, NetTx: 4000, BlockRead: 5, BlockWrite: 6},
+			},
+		},
+		{
+			res: "whiteblock-node0\t1.50%\t1MB / 2GB\t3kB / 4kB\t5B / 6B\n" +
+				"whiteblock-node1\t--\t-- / --\t-- / --\t-- / --\n" +
+				"whiteblock-node2\t200.00%\t1KiB / 1MiB\t0B / 0B\t0B / 0B",
+			expected: map[string]db.ResourceSample{
+				"whiteblock-node0": {CPU: 1.5, MemoryUsage: 1000000, MemoryLimit: 2000000000,
+					NetRx: 3000, NetTx: 4000, BlockRead: 5, BlockWrite: 6},
+				"whiteblock-node2": {CPU: 200, MemoryUsage: 1024, MemoryLimit: 1048576},
+			},
+		},
+		{
+			res: "whiteblock-node0\t1.50%\n" +
+				"whiteblock-node1\t0.10%\t1B / 1B\t1B / 1B\t1B / 1B",
+			expected: map[string]db.ResourceSample{
+				"whiteblock-node1": {CPU: 0.1, MemoryUsage: 1, MemoryLimit: 1,
+					NetRx: 1, NetTx: 1, BlockRead: 1, BlockWrite: 1},
+			},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := ParseDockerStats(tt.res)
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("ParseDockerStats returned %+v, expected %+v", out, tt.expected)
+			}
+		})
+	}
+}
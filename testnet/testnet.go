@@ -27,6 +27,7 @@ import (
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
 	"sync"
 )
 
@@ -55,7 +56,12 @@ type TestNet struct {
 	CombinedDetails db.DeploymentDetails
 	// LDD is a pointer to latest deployment details
 	LDD *db.DeploymentDetails `json:"-"`
-	mux *sync.RWMutex
+	// Topology is the topology which the peers of the nodes were generated from, if they were given peers
+	Topology *util.Topology
+	// Peers contains the absolute numbers of the peers given to each node, indexed by absolute number.
+	// Nodes which were added afterwards, or have been removed, have no peers.
+	Peers [][]int
+	mux   *sync.RWMutex
 }
 
 // RestoreTestNet fetches a testnet which already exists.
@@ -133,26 +139,112 @@ func NewTestNet(details db.DeploymentDetails, buildID string) (*TestNet, error)
 func (tn *TestNet) AddNode(node db.Node) *db.Node {
 	tn.mux.Lock()
 	defer tn.mux.Unlock()
+	//nodes may have been removed, so the absolute number of the last node may be past the length
 	node.AbsoluteNum = len(tn.Nodes)
+	if len(tn.Nodes) > 0 && tn.Nodes[len(tn.Nodes)-1].AbsoluteNum >= node.AbsoluteNum {
+		node.AbsoluteNum = tn.Nodes[len(tn.Nodes)-1].AbsoluteNum + 1
+	}
 	tn.NewlyBuiltNodes = append(tn.NewlyBuiltNodes, node)
 	tn.Nodes = append(tn.Nodes, node)
-	return &tn.Nodes[node.AbsoluteNum]
+	if tn.Topology != nil {
+		//added nodes are not given peers from the topology
+		for len(tn.Peers) <= node.AbsoluteNum {
+			tn.Peers = append(tn.Peers, []int{})
+		}
+	}
+	return &tn.Nodes[len(tn.Nodes)-1]
+}
+
+// NextLocalID finds the lowest local id which is not taken by a node on the given server, so that the
+// slots of removed nodes get reused
+func (tn *TestNet) NextLocalID(serverID int) int {
+	tn.mux.RLock()
+	defer tn.mux.RUnlock()
+	taken := map[int]bool{}
+	for _, node := range tn.Nodes {
+		if node.Server == serverID {
+			taken[node.LocalID] = true
+		}
+	}
+	localID := 0
+	for taken[localID] {
+		localID++
+	}
+	return localID
+}
+
+// RemoveNodes removes the nodes with the given absolute numbers, along with their side cars, from the
+// testnet and frees up their slots on their servers
+func (tn *TestNet) RemoveNodes(absNums []int) {
+	tn.mux.Lock()
+	defer tn.mux.Unlock()
+	remove := map[int]bool{}
+	for _, absNum := range absNums {
+		remove[absNum] = true
+	}
+
+	nodes := []db.Node{}
+	for _, node := range tn.Nodes {
+		if !remove[node.AbsoluteNum] {
+			nodes = append(nodes, node)
+			continue
+		}
+		for i := range tn.Servers {
+			if tn.Servers[i].ID != node.Server {
+				continue
+			}
+			if tn.Servers[i].Nodes > 0 {
+				tn.Servers[i].Nodes--
+			}
+			for j, ip := range tn.Servers[i].Ips {
+				if ip == node.IP {
+					tn.Servers[i].Ips = append(tn.Servers[i].Ips[:j], tn.Servers[i].Ips[j+1:]...)
+					break
+				}
+			}
+		}
+	}
+	tn.Nodes = nodes
+
+	for i := range tn.SideCars {
+		sidecars := []db.SideCar{}
+		for _, sidecar := range tn.SideCars[i] {
+			if !remove[sidecar.AbsoluteNodeNum] {
+				sidecars = append(sidecars, sidecar)
+			}
+		}
+		tn.SideCars[i] = sidecars
+	}
+
+	for i := range tn.Peers {
+		if remove[i] {
+			tn.Peers[i] = []int{}
+			continue
+		}
+		peers := []int{}
+		for _, peer := range tn.Peers[i] {
+			if !remove[peer] {
+				peers = append(peers, peer)
+			}
+		}
+		tn.Peers[i] = peers
+	}
 }
 
 // AddSideCar adds a side car to the testnet
 func (tn *TestNet) AddSideCar(node db.SideCar, index int) {
 	tn.mux.Lock()
 	defer tn.mux.Unlock()
-	if len(tn.NewlyBuiltSideCars) <= index {
-		tn.NewlyBuiltSideCars = append(tn.NewlyBuiltSideCars, []db.SideCar{node})
-	} else {
-		tn.NewlyBuiltSideCars[index] = append(tn.NewlyBuiltSideCars[index], node)
+	//sidecars may be attached to only some of the nodes, so the lower indexes may not have been seen yet
+	for len(tn.NewlyBuiltSideCars) <= index {
+		tn.NewlyBuiltSideCars = append(tn.NewlyBuiltSideCars, []db.SideCar{})
 	}
-	if len(tn.SideCars) <= index {
-		tn.SideCars = append(tn.SideCars, []db.SideCar{node})
-	} else {
-		tn.SideCars[index] = append(tn.SideCars[index], node)
+	tn.NewlyBuiltSideCars[index] = append(tn.NewlyBuiltSideCars[index], node)
+
+	for len(tn.SideCars) <= index {
+		tn.SideCars = append(tn.SideCars, []db.SideCar{})
 	}
+	tn.SideCars[index] = append(tn.SideCars[index], node)
 }
 
 // AddDetails adds the details of a new deployment to the TestNet
@@ -209,9 +301,26 @@ func (tn *TestNet) AddDetails(dd db.DeploymentDetails) error {
 			tn.CombinedDetails.Images = append(tn.CombinedDetails.Images, image)
 		}
 	}
+
+	/**Handle Labels**/
+	tn.CombinedDetails.Labels = oldCD.Labels
+	if len(dd.Labels) > 0 {
+		for len(tn.CombinedDetails.Labels) < oldCD.Nodes {
+			tn.CombinedDetails.Labels = append(tn.CombinedDetails.Labels, nil)
+		}
+		tn.CombinedDetails.Labels = append(tn.CombinedDetails.Labels, dd.Labels...)
+	}
 	return nil
 }
 
+// SetPeers records the peers given to each node, along with the topology they were generated from
+func (tn *TestNet) SetPeers(topology util.Topology, peers [][]int) {
+	tn.mux.Lock()
+	defer tn.mux.Unlock()
+	tn.Topology = &topology
+	tn.Peers = peers
+}
+
 // FinishedBuilding empties the NewlyBuiltNodes, signals DoneBuilding on the BuildState, and
 // stores the current data of tn testnet
 func (tn *TestNet) FinishedBuilding() {
@@ -267,6 +376,9 @@ func (tn *TestNet) PreOrderNodes(newNodes bool, sidecar bool, index int) map[int
 			out[node.Server] = append(out[node.Server], node)
 		}
 	} else if newNodes && sidecar {
+		if index >= len(tn.NewlyBuiltSideCars) {
+			return out
+		}
 		for _, node := range tn.NewlyBuiltSideCars[index] {
 			out[node.Server] = append(out[node.Server], node)
 		}
@@ -292,9 +404,10 @@ func (tn *TestNet) PreOrderNewNodes(sidecar bool) map[int][]ssh.Node {
 	return out
 }
 
-// Store stores the TestNets data for later retrieval
-func (tn *TestNet) Store() {
-	db.SetMeta("testnet_"+tn.TestNetID, *tn)
+// Store stores the TestNets data for later retrieval, replacing what was previously stored
+func (tn *TestNet) Store() error {
+	db.DeleteMeta("testnet_" + tn.TestNetID)
+	return util.LogError(db.SetMeta("testnet_"+tn.TestNetID, *tn))
 }
 
 // Destroy removes all the testnets data
@@ -329,6 +442,9 @@ func (tn *TestNet) GetSSHNodes(newNodes bool, sidecar bool, index int) []ssh.Nod
 			out = append(out, node)
 		}
 	} else if newNodes && sidecar {
+		if index >= len(tn.NewlyBuiltSideCars) {
+			return out
+		}
 		for _, node := range tn.NewlyBuiltSideCars[index] {
 			out = append(out, node)
 		}
@@ -356,19 +472,20 @@ func (tn *TestNet) SpawnAdjunct(newNodes bool, index int) (*Adjunct, error) {
 
 // GetNodesSideCar Get's a nodes sidecar by name
 func (tn *TestNet) GetNodesSideCar(node ssh.Node, name string) (*db.SideCar, error) {
-	index := -1
+	found := false
 	for i := range tn.SideCars {
-		if tn.SideCars[i][0].Type == name {
-			index = i
-			break
+		for j := range tn.SideCars[i] {
+			if tn.SideCars[i][j].Type != name {
+				break
+			}
+			found = true
+			if tn.SideCars[i][j].AbsoluteNodeNum == node.GetAbsoluteNumber() {
+				return &tn.SideCars[i][j], nil
+			}
 		}
 	}
-	if index == -1 {
+	if !found {
 		return nil, fmt.Errorf("could not find any side cars of type \"%s\"", name)
 	}
-	if node.GetAbsoluteNumber() >= len(tn.SideCars[index]) {
-		return nil, fmt.Errorf("given node index out of range")
-	}
-
-	return &tn.SideCars[index][node.GetAbsoluteNumber()], nil
+	return nil, fmt.Errorf("node %d does not have a side car of type \"%s\"", node.GetAbsoluteNumber(), name)
 }
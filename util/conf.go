@@ -19,54 +19,68 @@
 package util
 
 import (
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"io/ioutil"
 	"os"
 )
 
 // Config groups all of the global configuration parameters into
 // a single struct
 type Config struct {
-	SSHUser                 string  `mapstructure:"sshUser"`
-	SSHKey                  string  `mapstructure:"sshKey"`
-	SSHHost                 string  `mapstructure:"sshHost"`
-	ServerBits              uint32  `mapstructure:"serverBits"`
-	ClusterBits             uint32  `mapstructure:"clusterBits"`
-	NodeBits                uint32  `mapstructure:"nodeBits"`
-	IPPrefix                uint32  `mapstructure:"ipPrefix"`
-	Listen                  string  `mapstructure:"listen"`
-	Verbosity               string  `mapstructure:"verbosity"`
-	DockerOutputFile        string  `mapstructure:"dockerOutputFile"`
-	Influx                  string  `mapstructure:"influx"`         //No default
-	InfluxUser              string  `mapstructure:"influxUser"`     //No default
-	InfluxPassword          string  `mapstructure:"influxPassword"` //No default
-	ServiceNetwork          string  `mapstructure:"serviceNetwork"`
-	ServiceNetworkName      string  `mapstructure:"serviceNetworkName"`
-	NodePrefix              string  `mapstructure:"nodePrefix"`
-	NodeNetworkPrefix       string  `mapstructure:"nodeNetworkPrefix"`
-	ServicePrefix           string  `mapstructure:"servicePrefix"`
-	NodesPublicKey          string  `mapstructure:"nodesPublicKey"`  //No default
-	NodesPrivateKey         string  `mapstructure:"nodesPrivateKey"` //No default
-	HandleNodeSSHKeys       bool    `mapstructure:"handleNodeSshKeys"`
-	MaxNodes                int     `mapstructure:"maxNodes"`
-	MaxNodeMemory           string  `mapstructure:"maxNodeMemory"`
-	MaxNodeCPU              float64 `mapstructure:"maxNodeCpu"`
-	BridgePrefix            string  `mapstructure:"bridgePrefix"`
-	APIEndpoint             string  `mapstructure:"apiEndpoint"`
-	NibblerEndPoint         string  `mapstructure:"nibblerEndPoint"`
-	LogJSON                 bool    `mapstructure:"logJson"`
-	PrometheusConfig        string  `mapstructure:"prometheusConfig"`
-	PrometheusPort          int     `mapstructure:"prometheusPort"`
-	MaxRunAttempts          int     `mapstructure:"maxRunAttempts"`
-	MaxConnections          int     `mapstructure:"maxConnections"`
-	DataDirectory           string  `mapstructure:"datadir"`
-	DisableNibbler          bool    `mapstructure:"disableNibbler"`
-	DisableTestnetReporting bool    `mapstructure:"disableTestnetReporting"`
-	RequireAuth             bool    `mapstructure:"requireAuth"`
-	MaxCommandOutputLogSize int     `mapstructure:"maxCommandOutputLogSize"`
-	ResourceDir             string  `mapstructure:"resourceDir"`
-	RemoveNodesOnFailure    bool    `mapstructure:"removeNodesOnFailure"`
-	NibblerRetries          uint    `mapstructure:"nibblerRetries"`
+	SSHUser                 string   `mapstructure:"sshUser"`
+	SSHKey                  string   `mapstructure:"sshKey"`
+	SSHHost                 string   `mapstructure:"sshHost"`
+	ServerBits              uint32   `mapstructure:"serverBits"`
+	ClusterBits             uint32   `mapstructure:"clusterBits"`
+	NodeBits                uint32   `mapstructure:"nodeBits"`
+	IPPrefix                uint32   `mapstructure:"ipPrefix"`
+	Listen                  string   `mapstructure:"listen"`
+	Verbosity               string   `mapstructure:"verbosity"`
+	DockerOutputFile        string   `mapstructure:"dockerOutputFile"`
+	Influx                  string   `mapstructure:"influx"`         //No default
+	InfluxUser              string   `mapstructure:"influxUser"`     //No default
+	InfluxPassword          string   `mapstructure:"influxPassword"` //No default
+	ServiceNetwork          string   `mapstructure:"serviceNetwork"`
+	ServiceNetworkName      string   `mapstructure:"serviceNetworkName"`
+	NodePrefix              string   `mapstructure:"nodePrefix"`
+	NodeNetworkPrefix       string   `mapstructure:"nodeNetworkPrefix"`
+	ServicePrefix           string   `mapstructure:"servicePrefix"`
+	NodesPublicKey          string   `mapstructure:"nodesPublicKey"`  //No default
+	NodesPrivateKey         string   `mapstructure:"nodesPrivateKey"` //No default
+	HandleNodeSSHKeys       bool     `mapstructure:"handleNodeSshKeys"`
+	MaxNodes                int      `mapstructure:"maxNodes"`
+	MaxNodeMemory           string   `mapstructure:"maxNodeMemory"`
+	MaxNodeCPU              float64  `mapstructure:"maxNodeCpu"`
+	BridgePrefix            string   `mapstructure:"bridgePrefix"`
+	APIEndpoint             string   `mapstructure:"apiEndpoint"`
+	NibblerEndPoint         string   `mapstructure:"nibblerEndPoint"`
+	LogJSON                 bool     `mapstructure:"logJson"`
+	PrometheusConfig        string   `mapstructure:"prometheusConfig"`
+	PrometheusPort          int      `mapstructure:"prometheusPort"`
+	MaxRunAttempts          int      `mapstructure:"maxRunAttempts"`
+	MaxConnections          int      `mapstructure:"maxConnections"`
+	DataDirectory           string   `mapstructure:"datadir"`
+	DisableNibbler          bool     `mapstructure:"disableNibbler"`
+	DisableTestnetReporting bool     `mapstructure:"disableTestnetReporting"`
+	RequireAuth             bool     `mapstructure:"requireAuth"`
+	MaxCommandOutputLogSize int      `mapstructure:"maxCommandOutputLogSize"`
+	ResourceDir             string   `mapstructure:"resourceDir"`
+	RemoveNodesOnFailure    bool     `mapstructure:"removeNodesOnFailure"`
+	NibblerRetries          uint     `mapstructure:"nibblerRetries"`
+	ResourceSampleInterval  int      `mapstructure:"resourceSampleInterval"`
+	AdminKids               []string `mapstructure:"adminKids"`
+	AdminJwtKey             string   `mapstructure:"adminJwtKey"`
+	ShellIdleTimeout        int      `mapstructure:"shellIdleTimeout"`
+	ShellOrigins            []string `mapstructure:"shellOrigins"`
+	PluginDir               string   `mapstructure:"pluginDir"`
+	PluginDescribeTimeout   int      `mapstructure:"pluginDescribeTimeout"`
+	PluginCallTimeout       int      `mapstructure:"pluginCallTimeout"`
+	FaketimeLib             string   `mapstructure:"faketimeLib"`
+	MaxDownloadSize         int64    `mapstructure:"maxDownloadSize"`
+	MaxUploadSize           int64    `mapstructure:"maxUploadSize"`
+	BlockObserveInterval    int      `mapstructure:"blockObserveInterval"`
 }
 
 //NodesPerCluster represents the maximum number of nodes allowed in a cluster
@@ -112,6 +126,18 @@ func setViperEnvBindings() {
 	viper.BindEnv("resourceDir", "RESOURCE_DIR")
 	viper.BindEnv("removeNodesOnFailure", "REMOVE_NODES_ON_FAILURE")
 	viper.BindEnv("nibblerRetries", "NIBBLER_RETRIES")
+	viper.BindEnv("resourceSampleInterval", "RESOURCE_SAMPLE_INTERVAL")
+	viper.BindEnv("adminKids", "ADMIN_KIDS")
+	viper.BindEnv("adminJwtKey", "ADMIN_JWT_KEY")
+	viper.BindEnv("shellIdleTimeout", "SHELL_IDLE_TIMEOUT")
+	viper.BindEnv("shellOrigins", "SHELL_ORIGINS")
+	viper.BindEnv("pluginDir", "PLUGIN_DIR")
+	viper.BindEnv("pluginDescribeTimeout", "PLUGIN_DESCRIBE_TIMEOUT")
+	viper.BindEnv("pluginCallTimeout", "PLUGIN_CALL_TIMEOUT")
+	viper.BindEnv("faketimeLib", "FAKETIME_LIB")
+	viper.BindEnv("maxDownloadSize", "MAX_DOWNLOAD_SIZE")
+	viper.BindEnv("maxUploadSize", "MAX_UPLOAD_SIZE")
+	viper.BindEnv("blockObserveInterval", "BLOCK_OBSERVE_INTERVAL")
 }
 func setViperDefaults() {
 	viper.SetDefault("sshUser", os.Getenv("USER"))
@@ -149,6 +175,18 @@ func setViperDefaults() {
 	viper.SetDefault("resourceDir", "./resources")
 	viper.SetDefault("removeNodesOnFailure", false)
 	viper.SetDefault("nibblerRetries", 2)
+	viper.SetDefault("resourceSampleInterval", 30)
+	viper.SetDefault("adminKids", []string{})
+	viper.SetDefault("adminJwtKey", "")
+	viper.SetDefault("shellIdleTimeout", 600)
+	viper.SetDefault("shellOrigins", []string{})
+	viper.SetDefault("pluginDir", "./plugins")
+	viper.SetDefault("pluginDescribeTimeout", 30)
+	viper.SetDefault("pluginCallTimeout", 3600)
+	viper.SetDefault("faketimeLib", "")
+	viper.SetDefault("maxDownloadSize", 512<<20)
+	viper.SetDefault("maxUploadSize", 512<<20)
+	viper.SetDefault("blockObserveInterval", 250)
 }
 
 // GCPFormatter enables the ability to use genesis logging with Stackdriver
@@ -216,3 +254,41 @@ func init() {
 func GetConfig() *Config {
 	return conf
 }
+
+// VerifyKid gets the kid of the given jwt, once it has been verified to be signed by the public key at adminJwtKey
+func (c Config) VerifyKid(jwt string) (string, error) {
+	if len(c.AdminJwtKey) == 0 {
+		return "", fmt.Errorf("no key has been configured to verify tokens with")
+	}
+	if len(jwt) == 0 {
+		return "", fmt.Errorf("given empty string for JWT")
+	}
+	data, err := ioutil.ReadFile(c.AdminJwtKey)
+	if err != nil {
+		return "", LogError(err)
+	}
+	key, err := ParseRSAPublicKey(data)
+	if err != nil {
+		return "", LogError(err)
+	}
+	return VerifyJwt(jwt, key)
+}
+
+// IsAdmin checks if the given jwt belongs to an administrator. The jwt must be signed by
+// the public key at adminJwtKey, and have one of the adminKids as its kid.
+func (c Config) IsAdmin(jwt string) bool {
+	if len(c.AdminJwtKey) == 0 || len(jwt) == 0 {
+		return false
+	}
+	kid, err := c.VerifyKid(jwt)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Warn("rejected an admin token")
+		return false
+	}
+	for _, adminKid := range c.AdminKids {
+		if adminKid == kid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseRSAPublicKey parses a PEM encoded RSA public key, in either the PKIX or PKCS1 format
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+func decodeJwtSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// VerifyJwt checks that the given jwt is signed with RS256 by the given key and has not expired,
+// and returns the kid from its header.
+func VerifyJwt(jwt string, key *rsa.PublicKey) (string, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed JWT")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err := decodeJwtSegment(parts[0], &header)
+	if err != nil {
+		return "", fmt.Errorf("malformed JWT header: %s", err.Error())
+	}
+	if header.Alg != "RS256" {
+		return "", fmt.Errorf("unsupported JWT algorithm \"%s\"", header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed JWT signature: %s", err.Error())
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig)
+	if err != nil {
+		return "", fmt.Errorf("invalid JWT signature")
+	}
+
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	err = decodeJwtSegment(parts[1], &claims)
+	if err != nil {
+		return "", fmt.Errorf("malformed JWT payload: %s", err.Error())
+	}
+	if claims.Exp != nil && int64(*claims.Exp) < time.Now().Unix() {
+		return "", fmt.Errorf("JWT has expired")
+	}
+	if len(header.Kid) == 0 {
+		return "", fmt.Errorf("JWT does not have kid in header")
+	}
+	return header.Kid, nil
+}
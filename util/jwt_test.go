@@ -0,0 +1,182 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signTestJwt(t *testing.T, key *rsa.PrivateKey, header map[string]interface{}, claims map[string]interface{}) string {
+	rawHeader, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawClaims, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(rawHeader) + "." + base64.RawURLEncoding.EncodeToString(rawClaims)
+	hash := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestParseRSAPublicKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var test = []struct {
+		data []byte
+		err  bool
+	}{
+		{data: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})},
+		{data: pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})},
+		{data: []byte("not a key"), err: true},
+		{data: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("garbage")}), err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			parsed, err := ParseRSAPublicKey(tt.data)
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.N.Cmp(key.PublicKey.N) != 0 || parsed.E != key.PublicKey.E {
+				t.Error("parsed key does not match the original")
+			}
+		})
+	}
+}
+
+func TestVerifyJwt(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := map[string]interface{}{"alg": "RS256", "kid": "admin"}
+	valid := signTestJwt(t, key, header, map[string]interface{}{"exp": time.Now().Add(time.Hour).Unix()})
+	parts := strings.Split(valid, ".")
+	forgedClaims, _ := json.Marshal(map[string]interface{}{"exp": time.Now().Add(48 * time.Hour).Unix()})
+
+	var test = []struct {
+		jwt string
+		kid string
+		err bool
+	}{
+		{jwt: valid, kid: "admin"},
+		{jwt: signTestJwt(t, key, header, map[string]interface{}{}), kid: "admin"},
+		{jwt: signTestJwt(t, key, header, map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}), err: true},
+		{jwt: signTestJwt(t, otherKey, header, map[string]interface{}{}), err: true},
+		{jwt: signTestJwt(t, key, map[string]interface{}{"alg": "RS256"}, map[string]interface{}{}), err: true},
+		{jwt: signTestJwt(t, key, map[string]interface{}{"alg": "none", "kid": "admin"}, map[string]interface{}{}), err: true},
+		{jwt: parts[0] + "." + parts[1] + ".", err: true},
+		{jwt: parts[0] + "." + base64.RawURLEncoding.EncodeToString(forgedClaims) + "." + parts[2], err: true},
+		{jwt: parts[0] + "." + parts[1], err: true},
+		{jwt: "", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			kid, err := VerifyJwt(tt.jwt, &key.PublicKey)
+			if tt.err {
+				if err == nil {
+					t.Errorf("VerifyJwt did not return an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kid != tt.kid {
+				t.Errorf("VerifyJwt returned kid \"%s\", expected \"%s\"", kid, tt.kid)
+			}
+		})
+	}
+}
+
+func TestConfig_IsAdmin(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile, err := ioutil.TempFile("", "genesis-jwt-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(keyFile.Name())
+	pem.Encode(keyFile, &pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})
+	keyFile.Close()
+
+	adminJwt := signTestJwt(t, key, map[string]interface{}{"alg": "RS256", "kid": "admin"}, map[string]interface{}{})
+	userJwt := signTestJwt(t, key, map[string]interface{}{"alg": "RS256", "kid": "user"}, map[string]interface{}{})
+	unsignedJwt := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"admin"}`)) + ".e30."
+
+	var test = []struct {
+		conf     Config
+		jwt      string
+		expected bool
+	}{
+		{conf: Config{AdminJwtKey: keyFile.Name(), AdminKids: []string{"admin"}}, jwt: adminJwt, expected: true},
+		{conf: Config{AdminJwtKey: keyFile.Name(), AdminKids: []string{"admin"}}, jwt: userJwt, expected: false},
+		{conf: Config{AdminJwtKey: keyFile.Name(), AdminKids: []string{"admin"}}, jwt: unsignedJwt, expected: false},
+		{conf: Config{AdminJwtKey: keyFile.Name(), AdminKids: []string{"admin"}}, jwt: "", expected: false},
+		{conf: Config{AdminJwtKey: "", AdminKids: []string{"admin"}}, jwt: adminJwt, expected: false},
+		{conf: Config{AdminJwtKey: keyFile.Name() + ".missing", AdminKids: []string{"admin"}}, jwt: adminJwt, expected: false},
+		{conf: Config{AdminJwtKey: keyFile.Name(), AdminKids: []string{}}, jwt: adminJwt, expected: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if tt.conf.IsAdmin(tt.jwt) != tt.expected {
+				t.Errorf("IsAdmin returned %v, expected %v", !tt.expected, tt.expected)
+			}
+		})
+	}
+}
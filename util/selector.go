@@ -0,0 +1,114 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+	labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9._/-]*$`)
+)
+
+// splitLabel splits a label into its key and value. A label without a value, such as
+// "validators", has an empty value.
+func splitLabel(label string) (string, string) {
+	parts := strings.SplitN(label, "=", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// ValidateLabel checks that a label is either a plain name, such as "validators", or a key
+// value pair, such as "region=eu"
+func ValidateLabel(label string) error {
+	key, value := splitLabel(label)
+	if !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid label \"%s\"", label)
+	}
+	if !labelValuePattern.MatchString(value) {
+		return fmt.Errorf("invalid value for label \"%s\"", label)
+	}
+	return nil
+}
+
+// requirement is a single condition of a Selector
+type requirement struct {
+	key      string
+	value    string
+	hasValue bool
+	negate   bool
+}
+
+func (req requirement) matches(labels []string) bool {
+	found := false
+	for _, label := range labels {
+		key, value := splitLabel(label)
+		if key == req.key && (!req.hasValue || value == req.value) {
+			found = true
+			break
+		}
+	}
+	return found != req.negate
+}
+
+// Selector picks out nodes by their labels
+type Selector []requirement
+
+// ParseSelector parses a comma separated list of conditions, all of which must hold for a set of labels
+// to match. A condition of "key" requires the label key with any value and "key=value" requires it with
+// the given value, while "!key" and "key!=value" require the opposite.
+func ParseSelector(selector string) (Selector, error) {
+	out := Selector{}
+	for _, cond := range strings.Split(selector, ",") {
+		cond = strings.TrimSpace(cond)
+		var req requirement
+		switch {
+		case strings.Contains(cond, "!="):
+			parts := strings.SplitN(cond, "!=", 2)
+			req = requirement{key: parts[0], value: parts[1], hasValue: true, negate: true}
+		case strings.Contains(cond, "="):
+			parts := strings.SplitN(cond, "=", 2)
+			req = requirement{key: parts[0], value: parts[1], hasValue: true}
+		case strings.HasPrefix(cond, "!"):
+			req = requirement{key: cond[1:], negate: true}
+		default:
+			req = requirement{key: cond}
+		}
+		if !labelKeyPattern.MatchString(req.key) || !labelValuePattern.MatchString(req.value) {
+			return nil, fmt.Errorf("invalid condition \"%s\" in selector \"%s\"", cond, selector)
+		}
+		out = append(out, req)
+	}
+	return out, nil
+}
+
+// Matches checks whether the given labels satisfy all of the conditions of the selector
+func (sel Selector) Matches(labels []string) bool {
+	for _, req := range sel {
+		if !req.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
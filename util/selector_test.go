@@ -0,0 +1,86 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestValidateLabel(t *testing.T) {
+	var test = []struct {
+		label string
+		valid bool
+	}{
+		{label: "validators", valid: true},
+		{label: "region=eu", valid: true},
+		{label: "zone=eu-west/1", valid: true},
+		{label: "client=", valid: true},
+		{label: "", valid: false},
+		{label: "=eu", valid: false},
+		{label: "!validators", valid: false},
+		{label: "region=eu,asia", valid: false},
+		{label: "region=eu=1", valid: false},
+		{label: "my label", valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := ValidateLabel(tt.label)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateLabel(\"%s\") returned %v, expected valid to be %v", tt.label, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	labels := []string{"validators", "region=eu"}
+
+	var test = []struct {
+		selector string
+		valid    bool
+		matches  bool
+	}{
+		{selector: "validators", valid: true, matches: true},
+		{selector: "observers", valid: true, matches: false},
+		{selector: "!observers", valid: true, matches: true},
+		{selector: "region", valid: true, matches: true},
+		{selector: "region=eu", valid: true, matches: true},
+		{selector: "region=asia", valid: true, matches: false},
+		{selector: "region!=asia", valid: true, matches: true},
+		{selector: "validators, region=eu", valid: true, matches: true},
+		{selector: "validators,region!=eu", valid: true, matches: false},
+		{selector: "", valid: false},
+		{selector: "validators,", valid: false},
+		{selector: "region=eu;asia", valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sel, err := ParseSelector(tt.selector)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ParseSelector(\"%s\") returned %v, expected valid to be %v", tt.selector, err, tt.valid)
+			}
+			if tt.valid && sel.Matches(labels) != tt.matches {
+				t.Errorf("selector \"%s\" matching %v != %v", tt.selector, labels, tt.matches)
+			}
+		})
+	}
+}
@@ -31,6 +31,8 @@ type Command struct {
 	Cmdline  string
 	Node     int
 	ServerID int
+	// Env holds the environment variables the command is run with
+	Env map[string]string
 }
 
 // EndPoint represents an endpoint with basic auth
@@ -0,0 +1,290 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	// TopologyFullMesh peers every node with every other node
+	TopologyFullMesh = "full"
+	// TopologyRing peers each node with the nodes before and after it, wrapping around at the ends
+	TopologyRing = "ring"
+	// TopologyLine peers each node with the nodes before and after it, without wrapping around
+	TopologyLine = "line"
+	// TopologyWorstCase peers the nodes along a random path through all of them, see GenerateWorstCaseNetwork
+	TopologyWorstCase = "worstcase"
+	// TopologyRandomRegular peers each node with Degree random nodes
+	TopologyRandomRegular = "random"
+	// TopologyStar peers every node with the Center node only
+	TopologyStar = "star"
+	// TopologySmallWorld peers the nodes in a Watts-Strogatz small world graph
+	TopologySmallWorld = "smallworld"
+	// TopologyExplicit peers the nodes according to the given Adjacency list
+	TopologyExplicit = "explicit"
+)
+
+// Topology describes the peer graph to give to the nodes of a testnet
+type Topology struct {
+	// Type is the kind of peer graph, such as ring or star
+	Type string `json:"type"`
+
+	// Degree is the number of peers of each node in the random and smallworld topologies
+	Degree int `json:"degree,omitempty"`
+
+	// Rewire is the probability of rewiring each connection in the smallworld topology, between 0 and 1
+	Rewire float64 `json:"rewire,omitempty"`
+
+	// Center is the node which every other node peers with in the star topology
+	Center int `json:"center,omitempty"`
+
+	// Seed seeds the random topologies so that they can be reproduced, the current time is used if it is 0
+	Seed int64 `json:"seed,omitempty"`
+
+	// Adjacency is the peers of each node in the explicit topology
+	Adjacency [][]int `json:"adjacency,omitempty"`
+}
+
+// GenerateTopology generates the peers of each of the given number of nodes according to the given
+// topology. Except for the explicit topology, a node is always a peer of each of its peers.
+func GenerateTopology(topology Topology, nodes int) ([][]int, error) {
+	if nodes < 1 {
+		return nil, fmt.Errorf("there must be at least one node")
+	}
+	seed := topology.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	switch topology.Type {
+	case TopologyFullMesh:
+		graph := newPeerGraph(nodes)
+		for i := 0; i < nodes; i++ {
+			for j := i + 1; j < nodes; j++ {
+				graph.connect(i, j)
+			}
+		}
+		return graph.peers(), nil
+	case TopologyRing, TopologyLine:
+		graph := newPeerGraph(nodes)
+		for i := 0; i < nodes-1; i++ {
+			graph.connect(i, i+1)
+		}
+		if topology.Type == TopologyRing {
+			graph.connect(nodes-1, 0)
+		}
+		return graph.peers(), nil
+	case TopologyWorstCase:
+		graph := newPeerGraph(nodes)
+		for i, peers := range generateWorstCaseNetwork(nodes, seed) {
+			for _, peer := range peers {
+				graph.connect(i, peer)
+			}
+		}
+		return graph.peers(), nil
+	case TopologyRandomRegular:
+		return generateRandomRegularTopology(nodes, topology.Degree, rng)
+	case TopologyStar:
+		if topology.Center < 0 || topology.Center >= nodes {
+			return nil, fmt.Errorf("the center %d is not one of the %d nodes", topology.Center, nodes)
+		}
+		graph := newPeerGraph(nodes)
+		for i := 0; i < nodes; i++ {
+			graph.connect(topology.Center, i)
+		}
+		return graph.peers(), nil
+	case TopologySmallWorld:
+		return generateSmallWorldTopology(nodes, topology.Degree, topology.Rewire, rng)
+	case TopologyExplicit:
+		return checkAdjacency(topology.Adjacency, nodes)
+	}
+	return nil, fmt.Errorf("unknown topology \"%s\"", topology.Type)
+}
+
+// peerGraph is an undirected graph of the peers of each node
+type peerGraph []map[int]bool
+
+func newPeerGraph(nodes int) peerGraph {
+	out := make(peerGraph, nodes)
+	for i := range out {
+		out[i] = map[int]bool{}
+	}
+	return out
+}
+
+// connect makes the given nodes peers of each other, ignoring a node being given as its own peer
+func (graph peerGraph) connect(node1 int, node2 int) {
+	if node1 == node2 {
+		return
+	}
+	graph[node1][node2] = true
+	graph[node2][node1] = true
+}
+
+func (graph peerGraph) disconnect(node1 int, node2 int) {
+	delete(graph[node1], node2)
+	delete(graph[node2], node1)
+}
+
+// peers gets the sorted peers of each node
+func (graph peerGraph) peers() [][]int {
+	out := make([][]int, len(graph))
+	for i, peers := range graph {
+		out[i] = []int{}
+		for peer := range peers {
+			out[i] = append(out[i], peer)
+		}
+		sort.Ints(out[i])
+	}
+	return out
+}
+
+// connected checks whether there is a path between every pair of nodes
+func (graph peerGraph) connected() bool {
+	seen := map[int]bool{0: true}
+	toVisit := []int{0}
+	for len(toVisit) > 0 {
+		node := toVisit[0]
+		toVisit = toVisit[1:]
+		for peer := range graph[node] {
+			if !seen[peer] {
+				seen[peer] = true
+				toVisit = append(toVisit, peer)
+			}
+		}
+	}
+	return len(seen) == len(graph)
+}
+
+// latticeGraph creates a ring in which each node is a peer of the degree/2 nodes on either side of it
+func latticeGraph(nodes int, degree int) peerGraph {
+	graph := newPeerGraph(nodes)
+	for i := 0; i < nodes; i++ {
+		for j := 1; j <= degree/2; j++ {
+			graph.connect(i, (i+j)%nodes)
+		}
+	}
+	return graph
+}
+
+// generateRandomRegularTopology generates a random connected graph in which every node has exactly degree
+// peers. It starts from a lattice and randomizes it by repeatedly swapping the ends of two random connections,
+// which keeps the number of peers of every node the same.
+func generateRandomRegularTopology(nodes int, degree int, rng *rand.Rand) ([][]int, error) {
+	if degree < 1 || degree >= nodes {
+		return nil, fmt.Errorf("the degree must be between 1 and %d", nodes-1)
+	}
+	if degree == 1 && nodes > 2 {
+		return nil, fmt.Errorf("the nodes cannot all be connected with a degree of 1")
+	}
+	if (nodes*degree)%2 != 0 {
+		return nil, fmt.Errorf("there is no graph of %d nodes in which every node has %d peers", nodes, degree)
+	}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		graph := latticeGraph(nodes, degree)
+		if degree%2 == 1 {
+			for i := 0; i < nodes/2; i++ {
+				graph.connect(i, i+nodes/2)
+			}
+		}
+		edges := [][2]int{}
+		for i, peers := range graph.peers() {
+			for _, peer := range peers {
+				if i < peer {
+					edges = append(edges, [2]int{i, peer})
+				}
+			}
+		}
+
+		for swap := 0; swap < 10*len(edges); swap++ {
+			i, j := rng.Intn(len(edges)), rng.Intn(len(edges))
+			a, b := edges[i][0], edges[i][1]
+			c, d := edges[j][0], edges[j][1]
+			if rng.Intn(2) == 0 {
+				c, d = d, c
+			}
+			if a == c || a == d || b == c || b == d || graph[a][d] || graph[c][b] {
+				continue
+			}
+			graph.disconnect(a, b)
+			graph.disconnect(c, d)
+			graph.connect(a, d)
+			graph.connect(c, b)
+			edges[i] = [2]int{a, d}
+			edges[j] = [2]int{c, b}
+		}
+		if graph.connected() {
+			return graph.peers(), nil
+		}
+	}
+	return nil, fmt.Errorf("failed to generate a connected graph of %d nodes with %d peers each", nodes, degree)
+}
+
+// generateSmallWorldTopology generates a Watts-Strogatz small world graph, a lattice in which each of the
+// connections of a node to the nodes after it is rewired to a random node with the given probability
+func generateSmallWorldTopology(nodes int, degree int, rewire float64, rng *rand.Rand) ([][]int, error) {
+	if degree < 2 || degree >= nodes || degree%2 != 0 {
+		return nil, fmt.Errorf("the degree must be an even number between 2 and %d", nodes-1)
+	}
+	if rewire < 0 || rewire > 1 {
+		return nil, fmt.Errorf("the rewire probability must be between 0 and 1")
+	}
+	graph := latticeGraph(nodes, degree)
+	for j := 1; j <= degree/2; j++ {
+		for i := 0; i < nodes; i++ {
+			if rng.Float64() >= rewire || len(graph[i]) >= nodes-1 {
+				continue
+			}
+			peer := rng.Intn(nodes)
+			for peer == i || graph[i][peer] {
+				peer = rng.Intn(nodes)
+			}
+			graph.disconnect(i, (i+j)%nodes)
+			graph.connect(i, peer)
+		}
+	}
+	return graph.peers(), nil
+}
+
+// checkAdjacency checks that the given adjacency list gives the peers of each of the given number of nodes
+func checkAdjacency(adjacency [][]int, nodes int) ([][]int, error) {
+	if len(adjacency) != nodes {
+		return nil, fmt.Errorf("given the peers of %d nodes, but there are %d nodes", len(adjacency), nodes)
+	}
+	out := make([][]int, nodes)
+	for i, peers := range adjacency {
+		out[i] = []int{}
+		for _, peer := range peers {
+			if peer < 0 || peer >= nodes {
+				return nil, fmt.Errorf("node %d has the peer %d, which is not one of the %d nodes", i, peer, nodes)
+			}
+			if peer == i {
+				return nil, fmt.Errorf("node %d cannot be its own peer", i)
+			}
+			out[i] = append(out[i], peer)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,131 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    Genesis is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestGenerateTopology(t *testing.T) {
+	var test = []struct {
+		topology Topology
+		nodes    int
+		expected [][]int
+	}{
+		{topology: Topology{Type: TopologyFullMesh}, nodes: 3, expected: [][]int{{1, 2}, {0, 2}, {0, 1}}},
+		{topology: Topology{Type: TopologyRing}, nodes: 4, expected: [][]int{{1, 3}, {0, 2}, {1, 3}, {0, 2}}},
+		{topology: Topology{Type: TopologyLine}, nodes: 4, expected: [][]int{{1}, {0, 2}, {1, 3}, {2}}},
+		{topology: Topology{Type: TopologyLine}, nodes: 1, expected: [][]int{{}}},
+		{topology: Topology{Type: TopologyStar, Center: 2}, nodes: 4, expected: [][]int{{2}, {2}, {0, 1, 3}, {2}}},
+		{
+			topology: Topology{Type: TopologyExplicit, Adjacency: [][]int{{1}, {}, {0, 1}}},
+			nodes:    3,
+			expected: [][]int{{1}, {}, {0, 1}},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := GenerateTopology(tt.topology, tt.nodes)
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("GenerateTopology returned %v, expected %v", out, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateTopology_Random(t *testing.T) {
+	var test = []struct {
+		topology Topology
+		nodes    int
+		degree   int //degree is the expected number of peers of every node, or 0 if it varies
+	}{
+		{topology: Topology{Type: TopologyWorstCase, Seed: 3}, nodes: 10},
+		{topology: Topology{Type: TopologyRandomRegular, Degree: 4, Seed: 1}, nodes: 20, degree: 4},
+		{topology: Topology{Type: TopologyRandomRegular, Degree: 3, Seed: 2}, nodes: 30, degree: 3},
+		{topology: Topology{Type: TopologyRandomRegular, Degree: 2, Seed: 5}, nodes: 3, degree: 2},
+		{topology: Topology{Type: TopologySmallWorld, Degree: 4, Rewire: 0, Seed: 1}, nodes: 12, degree: 4},
+		{topology: Topology{Type: TopologySmallWorld, Degree: 4, Rewire: 0.2, Seed: 1}, nodes: 50},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := GenerateTopology(tt.topology, tt.nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(out) != tt.nodes {
+				t.Fatalf("expected the peers of %d nodes, got %d", tt.nodes, len(out))
+			}
+			graph := newPeerGraph(tt.nodes)
+			for node, peers := range out {
+				if tt.degree > 0 && len(peers) != tt.degree {
+					t.Errorf("node %d has %d peers, expected %d", node, len(peers), tt.degree)
+				}
+				for _, peer := range peers {
+					graph.connect(node, peer)
+				}
+			}
+			if !reflect.DeepEqual(graph.peers(), out) {
+				t.Errorf("the peers of %v are not symmetric", out)
+			}
+			if !graph.connected() {
+				t.Errorf("the nodes of %v are not connected", out)
+			}
+
+			again, _ := GenerateTopology(tt.topology, tt.nodes)
+			if !reflect.DeepEqual(again, out) {
+				t.Errorf("the same seed gave %v and %v", out, again)
+			}
+		})
+	}
+}
+
+func TestGenerateTopology_Invalid(t *testing.T) {
+	var test = []struct {
+		topology Topology
+		nodes    int
+	}{
+		{topology: Topology{Type: "tree"}, nodes: 3},
+		{topology: Topology{Type: TopologyFullMesh}, nodes: 0},
+		{topology: Topology{Type: TopologyStar, Center: 3}, nodes: 3},
+		{topology: Topology{Type: TopologyRandomRegular, Degree: 3}, nodes: 5},
+		{topology: Topology{Type: TopologyRandomRegular, Degree: 1}, nodes: 4},
+		{topology: Topology{Type: TopologyRandomRegular, Degree: 5}, nodes: 5},
+		{topology: Topology{Type: TopologySmallWorld, Degree: 3}, nodes: 10},
+		{topology: Topology{Type: TopologySmallWorld, Degree: 2, Rewire: 1.5}, nodes: 10},
+		{topology: Topology{Type: TopologyExplicit, Adjacency: [][]int{{1}}}, nodes: 2},
+		{topology: Topology{Type: TopologyExplicit, Adjacency: [][]int{{2}, {}}}, nodes: 2},
+		{topology: Topology{Type: TopologyExplicit, Adjacency: [][]int{{0}, {}}}, nodes: 2},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := GenerateTopology(tt.topology, tt.nodes)
+			if err == nil {
+				t.Errorf("GenerateTopology(%v, %d) did not return an error", tt.topology, tt.nodes)
+			}
+		})
+	}
+}
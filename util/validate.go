@@ -83,3 +83,25 @@ func ValidateCommandLine(str string) error {
 	}
 	return nil
 }
+
+// ValidateNodePath checks that the given path is an absolute path which is safe to
+// use inside of a command run on a node
+func ValidateNodePath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path \"%s\" must be absolute", path)
+	}
+	err := ValidateFilePath(path)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(path, " ") {
+		return fmt.Errorf("path \"%s\" cannot contain spaces", path)
+	}
+	return ValidateCommandLine(path)
+}
+
+// ShellQuote quotes the given string so that it is passed as a single argument to a posix shell,
+// without any of its contents being interpreted by the shell
+func ShellQuote(str string) string {
+	return "'" + strings.Replace(str, "'", `'\''`, -1) + "'"
+}
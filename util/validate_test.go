@@ -19,6 +19,8 @@
 package util
 
 import (
+	"os/exec"
+	"strconv"
 	"testing"
 )
 
@@ -106,3 +108,58 @@ func TestValidateCommandLine(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateNodePath(t *testing.T) {
+	var test = []struct {
+		path      string
+		expectErr bool
+	}{
+		{path: "/geth/genesis.json", expectErr: false},
+		{path: "/keys", expectErr: false},
+		{path: "geth/genesis.json", expectErr: true},
+		{path: "", expectErr: true},
+		{path: "/", expectErr: true},
+		{path: "/../etc/passwd", expectErr: true},
+		{path: "/tmp/a b", expectErr: true},
+		{path: "/tmp/a;rm", expectErr: true},
+		{path: "/tmp/$(whoami)", expectErr: true},
+		{path: "/tmp/a|b", expectErr: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := ValidateNodePath(tt.path)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("unexpected result for \"%s\": %v", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	var test = []struct {
+		str      string
+		expected string
+	}{
+		{str: "", expected: "''"},
+		{str: "ls -la /geth", expected: "'ls -la /geth'"},
+		{str: "echo 'hi'; rm -rf /", expected: `'echo '\''hi'\''; rm -rf /'`},
+		{str: "$(whoami) `id` \\ \"", expected: "'$(whoami) `id` \\ \"'"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			quoted := ShellQuote(tt.str)
+			if quoted != tt.expected {
+				t.Errorf("ShellQuote(%q) returned %s, expected %s", tt.str, quoted, tt.expected)
+			}
+			out, err := exec.Command("sh", "-c", "printf %s "+quoted).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.str {
+				t.Errorf("the shell interpreted %s as %q, expected %q", quoted, string(out), tt.str)
+			}
+		})
+	}
+}